@@ -0,0 +1,20 @@
+// Package clock provides a small injectable abstraction over wall-clock
+// time, so that code needing to reason about elapsed time (rate limiters,
+// backoff, cache expiry) can be driven by a fake clock in tests instead
+// of waiting on real time to pass. See the testclock subpackage.
+package clock
+
+import "time"
+
+// Clock is the minimal interface production code should depend on
+// instead of calling time.Now directly.
+type Clock interface {
+	// Now returns the clock's current time.
+	Now() time.Time
+}
+
+// SystemClock is a Clock backed by the real wall clock.
+type SystemClock struct{}
+
+// Now implements Clock.
+func (SystemClock) Now() time.Time { return time.Now() }
@@ -0,0 +1,41 @@
+// Package testclock provides a clock.Clock implementation whose time is
+// advanced explicitly by tests, rather than by the passage of real time.
+package testclock
+
+import (
+	"sync"
+	"time"
+)
+
+// TestClock is a clock.Clock whose current time is set and advanced
+// explicitly. The zero value is not usable; construct one with New.
+type TestClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// New returns a TestClock starting at start.
+func New(start time.Time) *TestClock {
+	return &TestClock{now: start}
+}
+
+// Now implements clock.Clock.
+func (t *TestClock) Now() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.now
+}
+
+// Set pins the clock's current time to now.
+func (t *TestClock) Set(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.now = now
+}
+
+// Add advances the clock's current time by d, which may be negative.
+func (t *TestClock) Add(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.now = t.now.Add(d)
+}
@@ -0,0 +1,346 @@
+// Package dscache provides a datastore filter implementing a
+// memcache-backed, cache-aside read cache: GetMulti checks memcache
+// first and populates it on a miss; PutMulti and DeleteMulti invalidate
+// the affected keys. A short-lived lock value is written to memcache
+// before a write lands, so a read racing with a concurrent write finds
+// the lock instead of caching data that write is about to make stale.
+package dscache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+
+	ds "github.com/luci/gae/service/datastore"
+	mc "github.com/luci/gae/service/memcache"
+)
+
+func init() {
+	gob.Register(int64(0))
+	gob.Register(float64(0))
+	gob.Register("")
+	gob.Register(false)
+	gob.Register([]byte(nil))
+	gob.Register(time.Time{})
+	gob.Register([]interface{}(nil))
+}
+
+// DefaultExpiration is how long a cached entity lives in memcache when
+// Config.Expiration is zero.
+const DefaultExpiration = 30 * time.Minute
+
+// lockExpiration is how long a write lock blocks caching for the keys
+// it covers. It only needs to outlive the single PutMulti/DeleteMulti
+// call that set it.
+const lockExpiration = 10 * time.Second
+
+// lockValue marks a memcache slot as mid-write. GetMulti treats it as a
+// miss, like an absent entry, but — unlike an absent entry — does not
+// repopulate the cache from what it reads: the write holding the lock
+// may still be in flight, so that read could already be stale by the
+// time it lands.
+var lockValue = []byte("dscache:locked")
+
+// Config controls which kinds dscache caches and for how long. The zero
+// Config (or a nil *Config passed to FilterDSCache) caches every kind
+// with DefaultExpiration.
+type Config struct {
+	// Expiration is how long a cached entity lives in memcache. Zero
+	// means DefaultExpiration.
+	Expiration time.Duration
+
+	mu       sync.Mutex
+	disabled map[string]bool
+}
+
+// DisableKind excludes kind from caching from now on: GetMulti no
+// longer checks memcache for it, and PutMulti/DeleteMulti no longer
+// lock or invalidate it.
+func (c *Config) DisableKind(kind string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled == nil {
+		c.disabled = map[string]bool{}
+	}
+	c.disabled[kind] = true
+}
+
+// EnableKind reverses a prior DisableKind.
+func (c *Config) EnableKind(kind string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.disabled, kind)
+}
+
+func (c *Config) enabled(kind string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.disabled[kind]
+}
+
+func (c *Config) expiration() time.Duration {
+	if c.Expiration > 0 {
+		return c.Expiration
+	}
+	return DefaultExpiration
+}
+
+type insideTxnKeyType struct{}
+
+var insideTxnKey insideTxnKeyType
+
+// FilterDSCache installs a filter into ctx that caches GetMulti results
+// in memcache under cfg's policy, invalidating on PutMulti/DeleteMulti.
+// A nil cfg behaves like a zero Config (cache every kind,
+// DefaultExpiration).
+//
+// Operations running inside a RunInTransaction body bypass the cache
+// entirely — no lookups, no locks, no invalidation — the same way
+// production Cloud Datastore transactions always read consistently
+// straight from the datastore itself.
+func FilterDSCache(ctx context.Context, cfg *Config) context.Context {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	return ds.AddRawFilters(ctx, func(fctx context.Context, raw ds.RawInterface) ds.RawInterface {
+		inTxn, _ := fctx.Value(insideTxnKey).(bool)
+		return &dsCacheFilter{RawInterface: raw, ctx: fctx, cfg: cfg, inTxn: inTxn}
+	})
+}
+
+// dsCacheFilter wraps a datastore.RawInterface with the cache-aside
+// scheme described by FilterDSCache.
+type dsCacheFilter struct {
+	ds.RawInterface
+	ctx   context.Context
+	cfg   *Config
+	inTxn bool
+}
+
+func cacheKey(k *ds.Key) string { return "dscache:" + k.String() }
+
+func (f *dsCacheFilter) RunInTransaction(ctx context.Context, fn func(context.Context) error, opts *ds.TransactionOptions) error {
+	return f.RawInterface.RunInTransaction(context.WithValue(ctx, insideTxnKey, true), fn, opts)
+}
+
+// cacheableComplete returns the subset of keys that are both complete
+// (PutMulti may hand us incomplete keys that can't have been cached
+// yet) and of a kind cfg hasn't disabled.
+func (f *dsCacheFilter) cacheableComplete(keys []*ds.Key) []*ds.Key {
+	var out []*ds.Key
+	for _, k := range keys {
+		if !k.Incomplete() && f.cfg.enabled(k.Kind()) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func (f *dsCacheFilter) lock(keys []*ds.Key) {
+	targets := f.cacheableComplete(keys)
+	if len(targets) == 0 {
+		return
+	}
+	items := make([]*mc.Item, len(targets))
+	for i, k := range targets {
+		items[i] = mc.NewItem(cacheKey(k)).SetValue(lockValue).SetExpiration(lockExpiration)
+	}
+	_ = mc.Raw(f.ctx).SetMulti(items) // best-effort: a memcache outage shouldn't fail the write
+}
+
+func (f *dsCacheFilter) invalidate(keys []*ds.Key) {
+	targets := f.cacheableComplete(keys)
+	if len(targets) == 0 {
+		return
+	}
+	ks := make([]string, len(targets))
+	for i, k := range targets {
+		ks[i] = cacheKey(k)
+	}
+	_ = mc.Raw(f.ctx).DeleteMulti(ks) // best-effort, same reasoning as lock
+}
+
+func (f *dsCacheFilter) PutMulti(keys []*ds.Key, vals []ds.PropertyMap) ([]*ds.Key, error) {
+	if f.inTxn {
+		return f.RawInterface.PutMulti(keys, vals)
+	}
+	f.lock(keys)
+	out, err := f.RawInterface.PutMulti(keys, vals)
+	f.invalidate(keys)
+	return out, err
+}
+
+func (f *dsCacheFilter) DeleteMulti(keys []*ds.Key) error {
+	if f.inTxn {
+		return f.RawInterface.DeleteMulti(keys)
+	}
+	f.lock(keys)
+	err := f.RawInterface.DeleteMulti(keys)
+	f.invalidate(keys)
+	return err
+}
+
+func (f *dsCacheFilter) GetMulti(keys []*ds.Key, vals []ds.PropertyMap) error {
+	if f.inTxn {
+		return f.RawInterface.GetMulti(keys, vals)
+	}
+
+	ckeys := make([]string, len(keys))
+	cacheable := make([]bool, len(keys))
+	var lookup []string
+	for i, k := range keys {
+		if f.cfg.enabled(k.Kind()) {
+			cacheable[i] = true
+			ckeys[i] = cacheKey(k)
+			lookup = append(lookup, ckeys[i])
+		}
+	}
+
+	found, _ := mc.Raw(f.ctx).GetMulti(lookup) // a memcache outage degrades to an all-miss, not a failure
+
+	var missKeys []*ds.Key
+	var missIdx []int
+	repopulate := map[int]bool{}
+	for i, k := range keys {
+		if !cacheable[i] {
+			missKeys = append(missKeys, k)
+			missIdx = append(missIdx, i)
+			continue
+		}
+		item, ok := found[ckeys[i]]
+		switch {
+		case !ok:
+			missKeys = append(missKeys, k)
+			missIdx = append(missIdx, i)
+			repopulate[i] = true
+		case bytes.Equal(item.Value(), lockValue):
+			missKeys = append(missKeys, k)
+			missIdx = append(missIdx, i)
+		default:
+			pm, err := decodePropertyMap(item.Value())
+			if err != nil {
+				missKeys = append(missKeys, k)
+				missIdx = append(missIdx, i)
+				repopulate[i] = true
+				continue
+			}
+			for p, v := range pm {
+				vals[i][p] = v
+			}
+		}
+	}
+
+	if len(missKeys) == 0 {
+		return nil
+	}
+
+	missVals := make([]ds.PropertyMap, len(missKeys))
+	for i := range missVals {
+		missVals[i] = ds.PropertyMap{}
+	}
+	err := f.RawInterface.GetMulti(missKeys, missVals)
+	var me ds.MultiError
+	if err != nil {
+		var ok bool
+		if me, ok = err.(ds.MultiError); !ok {
+			return err
+		}
+	}
+
+	out := make(ds.MultiError, len(keys))
+	anyErr := false
+	var toCache []*mc.Item
+	for i, origIdx := range missIdx {
+		if me != nil && me[i] != nil {
+			out[origIdx] = me[i]
+			anyErr = true
+			continue
+		}
+		for p, v := range missVals[i] {
+			vals[origIdx][p] = v
+		}
+		if repopulate[origIdx] {
+			if data, encErr := encodePropertyMap(missVals[i]); encErr == nil {
+				toCache = append(toCache, mc.NewItem(ckeys[origIdx]).SetValue(data).SetExpiration(f.cfg.expiration()))
+			}
+		}
+	}
+	if len(toCache) > 0 {
+		_ = mc.Raw(f.ctx).SetMulti(toCache) // best-effort: a cache-populate failure doesn't fail the read
+	}
+
+	if anyErr {
+		return out
+	}
+	return nil
+}
+
+// encodePropertyMap gob-encodes pm's data for storage in memcache. Each
+// Property/[]Property value is unwrapped to its underlying value first,
+// since Property's only field is unexported and so carries nothing
+// through gob on its own.
+//
+// A *Key-valued property (Key is also all-unexported fields, and has no
+// GobEncode of its own) can't be round-tripped this way; encoding such a
+// PropertyMap fails, and the caller falls back to leaving that entity
+// uncached rather than caching something that would come back wrong.
+func encodePropertyMap(pm ds.PropertyMap) ([]byte, error) {
+	raw := make(map[string]interface{}, len(pm))
+	for k, v := range pm {
+		rv, err := toRawValue(v)
+		if err != nil {
+			return nil, err
+		}
+		raw[k] = rv
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(raw); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func toRawValue(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case ds.Property:
+		if _, ok := x.Value().(*ds.Key); ok {
+			return nil, fmt.Errorf("dscache: *datastore.Key-valued properties aren't cacheable")
+		}
+		return x.Value(), nil
+	case []ds.Property:
+		vals := make([]interface{}, len(x))
+		for i, p := range x {
+			rv, err := toRawValue(p)
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = rv
+		}
+		return vals, nil
+	default:
+		return nil, fmt.Errorf("dscache: unsupported PropertyMap value %#v", v)
+	}
+}
+
+func decodePropertyMap(data []byte) (ds.PropertyMap, error) {
+	var raw map[string]interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&raw); err != nil {
+		return nil, err
+	}
+	pm := make(ds.PropertyMap, len(raw))
+	for k, v := range raw {
+		if vals, ok := v.([]interface{}); ok {
+			props := make([]ds.Property, len(vals))
+			for i, vv := range vals {
+				props[i] = ds.MkProperty(vv)
+			}
+			pm[k] = props
+		} else {
+			pm[k] = ds.MkProperty(v)
+		}
+	}
+	return pm, nil
+}
@@ -0,0 +1,132 @@
+package dscache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+	mc "github.com/luci/gae/service/memcache"
+)
+
+func setup() context.Context {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ctx = mc.SetRaw(ctx, memory.NewMemcache())
+	return FilterDSCache(ctx, nil)
+}
+
+func TestGetMultiPopulatesCacheOnMissAndHitsItAfter(t *testing.T) {
+	ctx := setup()
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": ds.MkProperty(int64(1))}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	vals := []ds.PropertyMap{{}}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals); err != nil {
+		t.Fatalf("GetMulti (miss): %v", err)
+	}
+	if got := vals[0]["Count"].(ds.Property).Value(); got != int64(1) {
+		t.Fatalf("Count = %v, want 1", got)
+	}
+
+	// The cache should now be populated: a memcache item exists for k.
+	if _, err := mc.Raw(ctx).Get(cacheKey(k)); err != nil {
+		t.Fatalf("expected a cached entry for %v after the miss populated it: %v", k, err)
+	}
+
+	vals2 := []ds.PropertyMap{{}}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals2); err != nil {
+		t.Fatalf("GetMulti (hit): %v", err)
+	}
+	if got := vals2[0]["Count"].(ds.Property).Value(); got != int64(1) {
+		t.Fatalf("Count = %v, want 1 (cached)", got)
+	}
+}
+
+func TestPutMultiInvalidatesCache(t *testing.T) {
+	ctx := setup()
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": ds.MkProperty(int64(1))}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	vals := []ds.PropertyMap{{}}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals); err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if _, err := mc.Raw(ctx).Get(cacheKey(k)); err != nil {
+		t.Fatalf("expected a cached entry before the second put: %v", err)
+	}
+
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": ds.MkProperty(int64(2))}}); err != nil {
+		t.Fatalf("second PutMulti: %v", err)
+	}
+	if _, err := mc.Raw(ctx).Get(cacheKey(k)); !mc.IsErrCacheMiss(err) {
+		t.Fatalf("cache entry err = %v, want ErrCacheMiss right after invalidating put", err)
+	}
+
+	vals2 := []ds.PropertyMap{{}}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals2); err != nil {
+		t.Fatalf("GetMulti after invalidation: %v", err)
+	}
+	if got := vals2[0]["Count"].(ds.Property).Value(); got != int64(2) {
+		t.Fatalf("Count = %v, want 2 (fresh value re-cached)", got)
+	}
+}
+
+func TestTransactionsBypassTheCache(t *testing.T) {
+	ctx := setup()
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": ds.MkProperty(int64(1))}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		vals := []ds.PropertyMap{{}}
+		if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals); err != nil {
+			return err
+		}
+		_, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": ds.MkProperty(int64(2))}})
+		return err
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+
+	// The transactional put never locked or invalidated anything in
+	// memcache; whatever was there before (nothing, in this test) is
+	// untouched, and a read outside the transaction sees the committed
+	// value straight from the store.
+	if _, err := mc.Raw(ctx).Get(cacheKey(k)); !mc.IsErrCacheMiss(err) {
+		t.Fatalf("cache entry err = %v, want ErrCacheMiss (dscache never wrote to memcache inside the transaction)", err)
+	}
+
+	vals := []ds.PropertyMap{{}}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals); err != nil {
+		t.Fatalf("GetMulti after transaction: %v", err)
+	}
+	if got := vals[0]["Count"].(ds.Property).Value(); got != int64(2) {
+		t.Fatalf("Count = %v, want 2 (committed by the transaction)", got)
+	}
+}
+
+func TestDisableKindBypassesCaching(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ctx = mc.SetRaw(ctx, memory.NewMemcache())
+	cfg := &Config{}
+	cfg.DisableKind("Widget")
+	ctx = FilterDSCache(ctx, cfg)
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": ds.MkProperty(int64(1))}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+	vals := []ds.PropertyMap{{}}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals); err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if _, err := mc.Raw(ctx).Get(cacheKey(k)); !mc.IsErrCacheMiss(err) {
+		t.Fatalf("cache entry err = %v, want ErrCacheMiss for a disabled kind", err)
+	}
+}
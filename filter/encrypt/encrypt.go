@@ -0,0 +1,195 @@
+// Package encrypt provides a datastore filter that transparently
+// encrypts configured properties at rest.
+package encrypt
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// PropertyKey identifies a single property on a specific kind whose
+// value should be encrypted at rest.
+type PropertyKey struct {
+	Kind     string
+	Property string
+}
+
+// KeySet resolves a key ID (embedded as a prefix in stored ciphertext)
+// to the AEAD cipher that can open it, and names which key ID new
+// writes should use. Retiring a key from Current while leaving it in
+// Keys lets previously-written ciphertext keep decrypting until it is
+// next read and rewritten under the new key; removing it from Keys
+// entirely makes that data permanently unreadable.
+type KeySet struct {
+	Current string
+	Keys    map[string]cipher.AEAD
+}
+
+func (ks *KeySet) encrypt(plaintext []byte) ([]byte, error) {
+	aead, ok := ks.Keys[ks.Current]
+	if !ok {
+		return nil, fmt.Errorf("filter/encrypt: current key id %q not present in KeySet", ks.Current)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+
+	out := make([]byte, 0, 1+len(ks.Current)+len(sealed))
+	out = append(out, byte(len(ks.Current)))
+	out = append(out, ks.Current...)
+	out = append(out, sealed...)
+	return out, nil
+}
+
+func (ks *KeySet) decrypt(data []byte) ([]byte, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("filter/encrypt: ciphertext too short to hold a key id")
+	}
+	idLen := int(data[0])
+	if len(data) < 1+idLen {
+		return nil, fmt.Errorf("filter/encrypt: ciphertext too short to hold its key id")
+	}
+	keyID := string(data[1 : 1+idLen])
+	aead, ok := ks.Keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("filter/encrypt: key id %q not present in KeySet (rotated out?)", keyID)
+	}
+	rest := data[1+idLen:]
+	if len(rest) < aead.NonceSize() {
+		return nil, fmt.Errorf("filter/encrypt: ciphertext too short to hold a nonce")
+	}
+	nonce, sealed := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
+
+// encryptFilter wraps a datastore.RawInterface, encrypting configured
+// properties on PutMulti and decrypting them on GetMulti/Run results.
+//
+// This package's Property has no indexed/unindexed distinction to turn
+// off for an encrypted property (unlike production Cloud Datastore,
+// where an encrypted value must be marked unindexed because ciphertext
+// ordering is meaningless) — there is simply nothing to index here yet.
+// If that distinction is ever added to service/datastore, encrypted
+// properties should be marked unindexed here.
+type encryptFilter struct {
+	ds.RawInterface
+	keys  *KeySet
+	props map[PropertyKey]bool
+}
+
+// FilterEncrypt installs a filter into ctx that encrypts the named
+// (kind, property) pairs using keys on PutMulti, and decrypts them again
+// on GetMulti and Run results. A configured property's value must be a
+// string; anything else is rejected.
+func FilterEncrypt(ctx context.Context, keys *KeySet, props ...PropertyKey) context.Context {
+	set := make(map[PropertyKey]bool, len(props))
+	for _, p := range props {
+		set[p] = true
+	}
+	return ds.AddRawFilters(ctx, func(_ context.Context, raw ds.RawInterface) ds.RawInterface {
+		return &encryptFilter{RawInterface: raw, keys: keys, props: set}
+	})
+}
+
+func (f *encryptFilter) encryptPM(kind string, pm ds.PropertyMap) (ds.PropertyMap, error) {
+	var out ds.PropertyMap
+	for name, v := range pm {
+		if !f.props[PropertyKey{Kind: kind, Property: name}] {
+			continue
+		}
+		prop, ok := v.(ds.Property)
+		if !ok {
+			return nil, fmt.Errorf("filter/encrypt: %s.%s is multi-valued, which this filter does not support", kind, name)
+		}
+		s, ok := prop.Value().(string)
+		if !ok {
+			return nil, fmt.Errorf("filter/encrypt: %s.%s must be a string to encrypt, got %T", kind, name, prop.Value())
+		}
+		ciphertext, err := f.keys.encrypt([]byte(s))
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			out = make(ds.PropertyMap, len(pm))
+			for k, v := range pm {
+				out[k] = v
+			}
+		}
+		out[name] = ds.MkProperty(ciphertext)
+	}
+	if out == nil {
+		return pm, nil
+	}
+	return out, nil
+}
+
+func (f *encryptFilter) decryptPM(kind string, pm ds.PropertyMap) error {
+	for name, v := range pm {
+		if !f.props[PropertyKey{Kind: kind, Property: name}] {
+			continue
+		}
+		prop, ok := v.(ds.Property)
+		if !ok {
+			continue
+		}
+		ciphertext, ok := prop.Value().([]byte)
+		if !ok {
+			continue
+		}
+		plaintext, err := f.keys.decrypt(ciphertext)
+		if err != nil {
+			return err
+		}
+		pm[name] = ds.MkProperty(string(plaintext))
+	}
+	return nil
+}
+
+func (f *encryptFilter) PutMulti(keys []*ds.Key, vals []ds.PropertyMap) ([]*ds.Key, error) {
+	encVals := make([]ds.PropertyMap, len(vals))
+	for i, pm := range vals {
+		enc, err := f.encryptPM(keys[i].Kind(), pm)
+		if err != nil {
+			return nil, err
+		}
+		encVals[i] = enc
+	}
+	return f.RawInterface.PutMulti(keys, encVals)
+}
+
+func (f *encryptFilter) GetMulti(keys []*ds.Key, vals []ds.PropertyMap) error {
+	err := f.RawInterface.GetMulti(keys, vals)
+	me, isMulti := err.(ds.MultiError)
+	if err != nil && !isMulti {
+		return err
+	}
+
+	for i, pm := range vals {
+		if pm == nil || (isMulti && me[i] != nil) {
+			// Either nothing came back for this key, or it already failed
+			// for another reason; either way there's no ciphertext here to
+			// decrypt.
+			continue
+		}
+		if decErr := f.decryptPM(keys[i].Kind(), pm); decErr != nil {
+			return decErr
+		}
+	}
+	return err
+}
+
+func (f *encryptFilter) Run(q *ds.Query, cb ds.RunCB) error {
+	return f.RawInterface.Run(q, func(k *ds.Key, pm ds.PropertyMap) error {
+		if err := f.decryptPM(k.Kind(), pm); err != nil {
+			return err
+		}
+		return cb(k, pm)
+	})
+}
@@ -0,0 +1,125 @@
+// Package featureBreaker provides test-only RawInterface filters that
+// force specific methods to fail a configured number of times, so tests
+// can exercise retry and error-handling paths deterministically.
+package featureBreaker
+
+import (
+	"context"
+	"sync"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// Breaker tracks, per method name, how many times that method should
+// still be forced to fail.
+type Breaker struct {
+	mu       sync.Mutex
+	err      error
+	failures map[string]int
+}
+
+// NewBreaker returns a Breaker that, once armed via BreakMethod, fails
+// the named method with err.
+func NewBreaker(err error) *Breaker {
+	return &Breaker{err: err, failures: map[string]int{}}
+}
+
+// BreakMethod arms method to fail the next n times it is called.
+func (b *Breaker) BreakMethod(method string, n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures[method] = n
+}
+
+// consume reports whether method should fail right now, decrementing its
+// remaining forced-failure count if so.
+func (b *Breaker) consume(method string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures[method] <= 0 {
+		return false
+	}
+	b.failures[method]--
+	return true
+}
+
+// rdsBreaker wraps a datastore.RawInterface, consulting b before
+// RunInTransaction to decide whether to return the Breaker's forced
+// error instead of delegating.
+type rdsBreaker struct {
+	ds.RawInterface
+	b *Breaker
+}
+
+// FilterRDS wraps raw with a filter that, while b has RunInTransaction
+// armed via BreakMethod, makes the transaction body fail with b's error
+// before it runs, instead of calling through to the real body. This lets
+// tests force the backend's own retry loop to see N consecutive failures
+// (typically ds.ErrConcurrentTransaction) and then succeed, without
+// faking the retry loop itself.
+func FilterRDS(raw ds.RawInterface, b *Breaker) ds.RawInterface {
+	return rdsBreaker{RawInterface: raw, b: b}
+}
+
+func (f rdsBreaker) RunInTransaction(ctx context.Context, fn func(context.Context) error, opts *ds.TransactionOptions) error {
+	wrapped := func(c context.Context) error {
+		if f.b.consume("RunInTransaction") {
+			return f.b.err
+		}
+		return fn(c)
+	}
+	return f.RawInterface.RunInTransaction(ctx, wrapped, opts)
+}
+
+// rawBreaker wraps a datastore.RawInterface, consulting b before each of
+// the non-transactional RawInterface methods to decide whether to return
+// the Breaker's forced error instead of delegating.
+type rawBreaker struct {
+	ds.RawInterface
+	b *Breaker
+}
+
+// FilterRaw wraps raw with a filter that, for each RawInterface method
+// armed via b.BreakMethod (by its method name, e.g. "GetMulti"), returns
+// b's forced error instead of delegating, the configured number of
+// times. Unlike FilterRDS, the forced error here is the non-per-item
+// error GetMulti/PutMulti/DeleteMulti return for the call as a whole,
+// not a MultiError entry.
+func FilterRaw(raw ds.RawInterface, b *Breaker) ds.RawInterface {
+	return rawBreaker{RawInterface: raw, b: b}
+}
+
+func (f rawBreaker) GetMulti(keys []*ds.Key, vals []ds.PropertyMap) error {
+	if f.b.consume("GetMulti") {
+		return f.b.err
+	}
+	return f.RawInterface.GetMulti(keys, vals)
+}
+
+func (f rawBreaker) PutMulti(keys []*ds.Key, vals []ds.PropertyMap) ([]*ds.Key, error) {
+	if f.b.consume("PutMulti") {
+		return nil, f.b.err
+	}
+	return f.RawInterface.PutMulti(keys, vals)
+}
+
+func (f rawBreaker) DeleteMulti(keys []*ds.Key) error {
+	if f.b.consume("DeleteMulti") {
+		return f.b.err
+	}
+	return f.RawInterface.DeleteMulti(keys)
+}
+
+func (f rawBreaker) Run(q *ds.Query, cb ds.RunCB) error {
+	if f.b.consume("Run") {
+		return f.b.err
+	}
+	return f.RawInterface.Run(q, cb)
+}
+
+func (f rawBreaker) Count(q *ds.Query) (int64, error) {
+	if f.b.consume("Count") {
+		return 0, f.b.err
+	}
+	return f.RawInterface.Count(q)
+}
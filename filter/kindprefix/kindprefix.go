@@ -0,0 +1,99 @@
+// Package kindprefix provides a datastore filter that prepends a fixed
+// prefix to every kind it sees on the way to the backend, and strips it
+// back off keys coming back, so that two tenants sharing one physical
+// datastore (e.g. one impl/memory store in tests) can't see or collide
+// with each other's entities despite application code using the same
+// logical kinds.
+package kindprefix
+
+import (
+	"context"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// kindPrefixFilter wraps a datastore.RawInterface, rewriting the kind of
+// every key (including ancestors) and query it sees to prefix + kind,
+// and stripping prefix back off the kind of every key it returns.
+type kindPrefixFilter struct {
+	ds.RawInterface
+	prefix string
+}
+
+// WithKindPrefix returns a context derived from ctx whose datastore
+// operations prepend prefix to every kind on the way to the backend,
+// and strip it back off the kind of every key returned, so application
+// code reads and writes its own logical kinds without ever seeing
+// prefix. An ancestor key has prefix applied to (and stripped from)
+// every kind in its chain, not just its leaf.
+//
+// This is meant for isolating tenants that share one physical datastore
+// (such as one impl/memory store across a test suite): two contexts
+// given different prefixes cannot see, query, or collide with each
+// other's entities.
+func WithKindPrefix(ctx context.Context, prefix string) context.Context {
+	return ds.AddRawFilters(ctx, func(_ context.Context, raw ds.RawInterface) ds.RawInterface {
+		return &kindPrefixFilter{RawInterface: raw, prefix: prefix}
+	})
+}
+
+// prefixKey returns a key identical to k, but with f.prefix prepended to
+// the kind of k and every key in its ancestor chain.
+func (f *kindPrefixFilter) prefixKey(k *ds.Key) *ds.Key {
+	if k == nil {
+		return nil
+	}
+	return ds.NewKey(k.AppID(), k.Namespace(), f.prefix+k.Kind(), k.StringID(), k.IntID(), f.prefixKey(k.Parent()))
+}
+
+// unprefixKey returns a key identical to k, but with f.prefix removed
+// from the kind of k and every key in its ancestor chain. A kind that
+// doesn't carry the prefix is left as-is.
+func (f *kindPrefixFilter) unprefixKey(k *ds.Key) *ds.Key {
+	if k == nil {
+		return nil
+	}
+	return ds.NewKey(k.AppID(), k.Namespace(), f.stripPrefix(k.Kind()), k.StringID(), k.IntID(), f.unprefixKey(k.Parent()))
+}
+
+func (f *kindPrefixFilter) stripPrefix(kind string) string {
+	if len(kind) >= len(f.prefix) && kind[:len(f.prefix)] == f.prefix {
+		return kind[len(f.prefix):]
+	}
+	return kind
+}
+
+func (f *kindPrefixFilter) prefixKeys(keys []*ds.Key) []*ds.Key {
+	out := make([]*ds.Key, len(keys))
+	for i, k := range keys {
+		out[i] = f.prefixKey(k)
+	}
+	return out
+}
+
+func (f *kindPrefixFilter) GetMulti(keys []*ds.Key, vals []ds.PropertyMap) error {
+	return f.RawInterface.GetMulti(f.prefixKeys(keys), vals)
+}
+
+func (f *kindPrefixFilter) PutMulti(keys []*ds.Key, vals []ds.PropertyMap) ([]*ds.Key, error) {
+	out, err := f.RawInterface.PutMulti(f.prefixKeys(keys), vals)
+	reported := make([]*ds.Key, len(out))
+	for i, k := range out {
+		reported[i] = f.unprefixKey(k)
+	}
+	return reported, err
+}
+
+func (f *kindPrefixFilter) DeleteMulti(keys []*ds.Key) error {
+	return f.RawInterface.DeleteMulti(f.prefixKeys(keys))
+}
+
+func (f *kindPrefixFilter) Run(q *ds.Query, cb ds.RunCB) error {
+	return f.RawInterface.Run(q.WithKind(f.prefix+q.Kind()), func(k *ds.Key, pm ds.PropertyMap) error {
+		return cb(f.unprefixKey(k), pm)
+	})
+}
+
+func (f *kindPrefixFilter) Count(q *ds.Query) (int64, error) {
+	return f.RawInterface.Count(q.WithKind(f.prefix + q.Kind()))
+}
@@ -0,0 +1,93 @@
+package kindprefix
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestPutGetRoundTripsThroughLogicalKind(t *testing.T) {
+	shared := memory.NewDatastore("app")
+	ctx := WithKindPrefix(ds.SetRaw(context.Background(), shared), "tenantA.")
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": ds.MkProperty(int64(1))}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	vals := []ds.PropertyMap{{}}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals); err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if got := vals[0]["Count"].(ds.Property).Value(); got != int64(1) {
+		t.Errorf("Count = %v, want 1", got)
+	}
+
+	seen := false
+	err := ds.Raw(ctx).Run(ds.NewQuery("Widget"), func(rk *ds.Key, pm ds.PropertyMap) error {
+		seen = true
+		if rk.Kind() != "Widget" {
+			t.Errorf("result key Kind = %q, want unprefixed %q", rk.Kind(), "Widget")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !seen {
+		t.Fatalf("Run saw no results")
+	}
+
+	// The physical store carries the prefixed kind, not the logical one.
+	if len(memory.GetTestable(ctx).AllKeys("Widget")) != 0 {
+		t.Errorf("physical store has entities under unprefixed kind %q", "Widget")
+	}
+	if len(memory.GetTestable(ctx).AllKeys("tenantA.Widget")) != 1 {
+		t.Errorf("physical store doesn't have the entity under the prefixed kind")
+	}
+}
+
+func TestTwoPrefixesOnOneStoreAreIsolated(t *testing.T) {
+	shared := memory.NewDatastore("app")
+	base := ds.SetRaw(context.Background(), shared)
+	ctxA := WithKindPrefix(base, "tenantA.")
+	ctxB := WithKindPrefix(base, "tenantB.")
+
+	k := ds.NewKey("app", "", "Widget", "shared-name", 0, nil)
+	if _, err := ds.Raw(ctxA).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Owner": ds.MkProperty("a")}}); err != nil {
+		t.Fatalf("PutMulti(A): %v", err)
+	}
+	if _, err := ds.Raw(ctxB).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Owner": ds.MkProperty("b")}}); err != nil {
+		t.Fatalf("PutMulti(B): %v", err)
+	}
+
+	var fromB []string
+	err := ds.Raw(ctxB).Run(ds.NewQuery("Widget"), func(rk *ds.Key, pm ds.PropertyMap) error {
+		fromB = append(fromB, pm["Owner"].(ds.Property).Value().(string))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run(B): %v", err)
+	}
+	if len(fromB) != 1 || fromB[0] != "b" {
+		t.Errorf("tenantB query saw %v, want only tenantB's own entity", fromB)
+	}
+}
+
+func TestAncestorChainIsPrefixedAndStripped(t *testing.T) {
+	shared := memory.NewDatastore("app")
+	ctx := WithKindPrefix(ds.SetRaw(context.Background(), shared), "tenantA.")
+
+	parent := ds.NewKey("app", "", "Group", "g1", 0, nil)
+	child := ds.NewKey("app", "", "Widget", "w1", 0, parent)
+
+	out, err := ds.Raw(ctx).PutMulti([]*ds.Key{child}, []ds.PropertyMap{{}})
+	if err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+	if out[0].Kind() != "Widget" || out[0].Parent().Kind() != "Group" {
+		t.Errorf("out[0] = %v, want an unprefixed kind chain", out[0])
+	}
+}
@@ -0,0 +1,128 @@
+// Package mcshard provides a memcache filter that transparently shards
+// values too large for a single memcache entry across multiple
+// sub-keys, reassembling them on Get.
+package mcshard
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	mc "github.com/luci/gae/service/memcache"
+)
+
+// headerMagic opens the fixed-size header (see encodeHeader) written as
+// the value of the original key for a sharded item, in place of the real
+// (oversized) value. Its presence distinguishes a sharded item from an
+// ordinary one stored under the same key.
+var headerMagic = [4]byte{'m', 'c', 's', 'h'}
+
+const headerLen = len(headerMagic) + 4 // magic + uint32 shard count
+
+func encodeHeader(numShards int) []byte {
+	b := make([]byte, headerLen)
+	copy(b, headerMagic[:])
+	binary.BigEndian.PutUint32(b[len(headerMagic):], uint32(numShards))
+	return b
+}
+
+func decodeHeader(b []byte) (numShards int, ok bool) {
+	if len(b) != headerLen {
+		return 0, false
+	}
+	if string(b[:len(headerMagic)]) != string(headerMagic[:]) {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint32(b[len(headerMagic):])), true
+}
+
+func shardKey(key string, i int) string {
+	return fmt.Sprintf("value-%s-%d", key, i)
+}
+
+// shardFilter wraps a memcache.RawInterface, splitting values over
+// maxValueSize bytes across shardKey sub-keys and reassembling them on
+// Get/GetMulti. A value at or under maxValueSize is stored and read
+// unchanged.
+type shardFilter struct {
+	mc.RawInterface
+	maxValueSize int
+}
+
+// FilterShard installs a filter into ctx that shards memcache values
+// larger than maxValueSize across N sub-keys named value-<key>-0 through
+// value-<key>-(N-1),
+// reassembling them transparently on Get. If any shard of a sharded
+// value is missing (e.g. evicted independently), the whole value is
+// reported as a cache miss rather than returned partially.
+func FilterShard(ctx context.Context, maxValueSize int) context.Context {
+	return mc.AddRawFilters(ctx, func(_ context.Context, raw mc.RawInterface) mc.RawInterface {
+		return &shardFilter{RawInterface: raw, maxValueSize: maxValueSize}
+	})
+}
+
+func (f *shardFilter) Get(key string) (*mc.Item, error) {
+	head, err := f.RawInterface.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	numShards, sharded := decodeHeader(head.Value())
+	if !sharded {
+		return head, nil
+	}
+
+	keys := make([]string, numShards)
+	for i := range keys {
+		keys[i] = shardKey(key, i)
+	}
+	shards, err := f.RawInterface.GetMulti(keys)
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, 0, numShards*f.maxValueSize)
+	for _, k := range keys {
+		it, ok := shards[k]
+		if !ok {
+			return nil, mc.ErrCacheMiss
+		}
+		value = append(value, it.Value()...)
+	}
+	return mc.NewItem(key).SetValue(value).SetExpiration(head.Expiration()), nil
+}
+
+func (f *shardFilter) GetMulti(keys []string) (map[string]*mc.Item, error) {
+	out := map[string]*mc.Item{}
+	for _, k := range keys {
+		it, err := f.Get(k)
+		if err != nil {
+			if mc.IsErrCacheMiss(err) {
+				continue
+			}
+			return nil, err
+		}
+		out[k] = it
+	}
+	return out, nil
+}
+
+func (f *shardFilter) SetMulti(items []*mc.Item) error {
+	var toSet []*mc.Item
+	for _, it := range items {
+		if len(it.Value()) <= f.maxValueSize {
+			toSet = append(toSet, it)
+			continue
+		}
+		value := it.Value()
+		var numShards int
+		for off := 0; off < len(value); off += f.maxValueSize {
+			end := off + f.maxValueSize
+			if end > len(value) {
+				end = len(value)
+			}
+			toSet = append(toSet, mc.NewItem(shardKey(it.Key(), numShards)).SetValue(value[off:end]).SetExpiration(it.Expiration()))
+			numShards++
+		}
+		toSet = append(toSet, mc.NewItem(it.Key()).SetValue(encodeHeader(numShards)).SetExpiration(it.Expiration()))
+	}
+	return f.RawInterface.SetMulti(toSet)
+}
@@ -0,0 +1,69 @@
+package mcshard
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	mc "github.com/luci/gae/service/memcache"
+)
+
+func setup(t *testing.T, maxValueSize int) context.Context {
+	t.Helper()
+	ctx := mc.SetRaw(context.Background(), memory.NewMemcache())
+	return FilterShard(ctx, maxValueSize)
+}
+
+func TestShardedValueRoundTrips(t *testing.T) {
+	ctx := setup(t, 4)
+
+	want := []byte("this value is definitely over four bytes")
+	if err := mc.Raw(ctx).SetMulti([]*mc.Item{mc.NewItem("big").SetValue(want)}); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+
+	got, err := mc.Raw(ctx).Get("big")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(got.Value(), want) {
+		t.Errorf("got %q, want %q", got.Value(), want)
+	}
+}
+
+func TestSmallValueStoredUnchanged(t *testing.T) {
+	ctx := setup(t, 4096)
+
+	want := []byte("tiny")
+	if err := mc.Raw(ctx).SetMulti([]*mc.Item{mc.NewItem("small").SetValue(want)}); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+
+	got, err := mc.Raw(ctx).Get("small")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(got.Value(), want) {
+		t.Errorf("got %q, want %q", got.Value(), want)
+	}
+}
+
+func TestMissingShardYieldsWholeValueMiss(t *testing.T) {
+	ctx := setup(t, 4)
+
+	want := []byte("this value is definitely over four bytes")
+	if err := mc.Raw(ctx).SetMulti([]*mc.Item{mc.NewItem("big").SetValue(want)}); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+
+	// DeleteMulti isn't shard-aware, so deleting the shard's own key
+	// (rather than "big") removes just that one shard.
+	if err := mc.Raw(ctx).DeleteMulti([]string{shardKey("big", 0)}); err != nil {
+		t.Fatalf("DeleteMulti shard: %v", err)
+	}
+
+	if _, err := mc.Raw(ctx).Get("big"); !mc.IsErrCacheMiss(err) {
+		t.Errorf("Get after losing a shard = %v, want ErrCacheMiss", err)
+	}
+}
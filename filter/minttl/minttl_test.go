@@ -0,0 +1,87 @@
+package minttl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luci/gae/impl/memory"
+	mc "github.com/luci/gae/service/memcache"
+)
+
+func setup(t *testing.T, cfg *Config) context.Context {
+	t.Helper()
+	return Filter(mc.SetRaw(context.Background(), memory.NewMemcache()), cfg)
+}
+
+func TestSetMultiRaisesShortTTLToMinimum(t *testing.T) {
+	ctx := setup(t, &Config{Min: 10 * time.Second})
+
+	if err := mc.Raw(ctx).SetMulti([]*mc.Item{
+		mc.NewItem("a").SetValue([]byte("v")).SetExpiration(time.Second),
+	}); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+
+	got, err := mc.Raw(ctx).GetMulti([]string{"a"})
+	if err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if exp := got["a"].Expiration(); exp != 10*time.Second {
+		t.Errorf("Expiration = %v, want 10s", exp)
+	}
+}
+
+func TestSetMultiLeavesLongTTLUnchanged(t *testing.T) {
+	ctx := setup(t, &Config{Min: time.Second})
+
+	if err := mc.Raw(ctx).SetMulti([]*mc.Item{
+		mc.NewItem("a").SetValue([]byte("v")).SetExpiration(time.Minute),
+	}); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+
+	got, err := mc.Raw(ctx).GetMulti([]string{"a"})
+	if err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if exp := got["a"].Expiration(); exp != time.Minute {
+		t.Errorf("Expiration = %v, want 1m", exp)
+	}
+}
+
+func TestSetMultiLeavesNoExpirationUnchangedByDefault(t *testing.T) {
+	ctx := setup(t, &Config{Min: time.Minute})
+
+	if err := mc.Raw(ctx).SetMulti([]*mc.Item{
+		mc.NewItem("a").SetValue([]byte("v")),
+	}); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+
+	got, err := mc.Raw(ctx).GetMulti([]string{"a"})
+	if err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if exp := got["a"].Expiration(); exp != 0 {
+		t.Errorf("Expiration = %v, want 0 (unaffected)", exp)
+	}
+}
+
+func TestSetMultiClampsNoExpirationWhenClampZeroSet(t *testing.T) {
+	ctx := setup(t, &Config{Min: time.Minute, ClampZero: true})
+
+	if err := mc.Raw(ctx).SetMulti([]*mc.Item{
+		mc.NewItem("a").SetValue([]byte("v")),
+	}); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+
+	got, err := mc.Raw(ctx).GetMulti([]string{"a"})
+	if err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if exp := got["a"].Expiration(); exp != time.Minute {
+		t.Errorf("Expiration = %v, want 1m (clamped)", exp)
+	}
+}
@@ -0,0 +1,118 @@
+// Package notify provides a datastore filter that invokes a callback
+// after each successful write, so code that wants to react to entity
+// changes (invalidate a cache, publish an event, ...) doesn't need to
+// instrument every write site itself.
+package notify
+
+import (
+	"context"
+	"sync"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// Op identifies the write operation a Func was notified about.
+type Op int
+
+const (
+	// Put marks a notification for a PutMulti.
+	Put Op = iota
+	// Delete marks a notification for a DeleteMulti.
+	Delete
+)
+
+// Func is called with the keys written or deleted by one successful
+// PutMulti or DeleteMulti call. keys is batched exactly as the
+// triggering call was: one invocation per call, not one per key.
+//
+// Outside a transaction, Func runs synchronously, right after the call
+// it's reporting on succeeds. Inside a transaction, every notification
+// from calls made during the transaction is held back and only
+// delivered, in the order the calls were made, after the transaction
+// commits; a rolled-back or retried attempt discards them.
+type Func func(ctx context.Context, op Op, keys []*ds.Key)
+
+type pendingKeyType struct{}
+
+var pendingKey pendingKeyType
+
+// notification is one deferred Func invocation, captured so it can be
+// replayed after a successful commit.
+type notification struct {
+	op   Op
+	keys []*ds.Key
+}
+
+// pending accumulates the notifications raised during one
+// RunInTransaction attempt, in call order.
+type pending struct {
+	mu    sync.Mutex
+	items []notification
+}
+
+func (p *pending) add(op Op, keys []*ds.Key) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.items = append(p.items, notification{op: op, keys: keys})
+}
+
+// WithNotify returns a context derived from ctx whose datastore installs
+// cb as described by Func.
+func WithNotify(ctx context.Context, cb Func) context.Context {
+	return ds.AddRawFilters(ctx, func(fctx context.Context, raw ds.RawInterface) ds.RawInterface {
+		p, _ := fctx.Value(pendingKey).(*pending)
+		return &notifyFilter{RawInterface: raw, ctx: fctx, cb: cb, pending: p}
+	})
+}
+
+// notifyFilter wraps a datastore.RawInterface, calling cb after each
+// successful PutMulti/DeleteMulti, or deferring it to commit time when
+// pending is non-nil (i.e. this call is happening inside a transaction
+// started by notifyFilter.RunInTransaction).
+type notifyFilter struct {
+	ds.RawInterface
+	ctx     context.Context
+	cb      Func
+	pending *pending
+}
+
+func (f *notifyFilter) notify(op Op, keys []*ds.Key) {
+	if f.pending != nil {
+		f.pending.add(op, keys)
+		return
+	}
+	f.cb(f.ctx, op, keys)
+}
+
+func (f *notifyFilter) PutMulti(keys []*ds.Key, vals []ds.PropertyMap) ([]*ds.Key, error) {
+	out, err := f.RawInterface.PutMulti(keys, vals)
+	if err == nil {
+		f.notify(Put, out)
+	}
+	return out, err
+}
+
+func (f *notifyFilter) DeleteMulti(keys []*ds.Key) error {
+	err := f.RawInterface.DeleteMulti(keys)
+	if err == nil {
+		f.notify(Delete, keys)
+	}
+	return err
+}
+
+func (f *notifyFilter) RunInTransaction(ctx context.Context, fn func(context.Context) error, opts *ds.TransactionOptions) error {
+	p := &pending{}
+	wrapped := func(txnCtx context.Context) error {
+		// A retried attempt starts over; notifications from an attempt
+		// that didn't commit don't carry into the next one.
+		p.items = nil
+		return fn(context.WithValue(txnCtx, pendingKey, p))
+	}
+	if err := f.RawInterface.RunInTransaction(ctx, wrapped, opts); err != nil {
+		return err
+	}
+	for _, n := range p.items {
+		f.cb(f.ctx, n.op, n.keys)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func setup(cb Func) context.Context {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	return WithNotify(ctx, cb)
+}
+
+func TestNotifyFiresForNonTransactionalWrites(t *testing.T) {
+	var got []notification
+	ctx := setup(func(_ context.Context, op Op, keys []*ds.Key) {
+		got = append(got, notification{op: op, keys: keys})
+	})
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+	if err := ds.Raw(ctx).DeleteMulti([]*ds.Key{k}); err != nil {
+		t.Fatalf("DeleteMulti: %v", err)
+	}
+
+	if len(got) != 2 || got[0].op != Put || got[1].op != Delete {
+		t.Fatalf("notifications = %+v, want one Put then one Delete", got)
+	}
+}
+
+func TestNotifyDefersUntilCommit(t *testing.T) {
+	var got []notification
+	ctx := setup(func(_ context.Context, op Op, keys []*ds.Key) {
+		got = append(got, notification{op: op, keys: keys})
+	})
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		if len(got) != 0 {
+			t.Fatalf("notification fired before commit: %+v", got)
+		}
+		_, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}})
+		return err
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+
+	if len(got) != 1 || got[0].op != Put {
+		t.Fatalf("notifications after commit = %+v, want one Put", got)
+	}
+}
+
+func TestNotifyDoesNotFireOnRollback(t *testing.T) {
+	var got []notification
+	ctx := setup(func(_ context.Context, op Op, keys []*ds.Key) {
+		got = append(got, notification{op: op, keys: keys})
+	})
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	wantErr := errors.New("boom")
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+			return err
+		}
+		return wantErr
+	}, nil)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("RunInTransaction err = %v, want %v", err, wantErr)
+	}
+	if len(got) != 0 {
+		t.Errorf("notifications fired despite rollback: %+v", got)
+	}
+}
@@ -0,0 +1,94 @@
+// Package oplog provides a datastore filter that records one Entry per
+// RawInterface call, including whatever operation tag (see
+// ds.WithOperationTag) was in effect on the ctx that call was made
+// with, so a Sink can correlate a slow or failing call back to the
+// higher-level business operation that caused it.
+package oplog
+
+import (
+	"context"
+	"time"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// Entry describes one completed RawInterface call.
+type Entry struct {
+	// Op is the RawInterface method name: "GetMulti", "PutMulti",
+	// "DeleteMulti", "Run", or "Count".
+	Op string
+
+	// Tag is the value installed by ds.WithOperationTag on the call's
+	// ctx, or "" if none was installed.
+	Tag string
+
+	// Duration is how long the call took.
+	Duration time.Duration
+
+	// Err is the error the call returned, if any.
+	Err error
+}
+
+// Sink receives one Entry per RawInterface call. It's invoked
+// synchronously, after the call it describes returns, so a slow Sink
+// delays whatever issued the call.
+type Sink func(Entry)
+
+// WithOpLog returns a context in which every RawInterface call is
+// reported to sink as an Entry, tagged with whatever ds.WithOperationTag
+// was in effect on the ctx passed to Raw(ctx) for that call.
+// RawInterface's methods take no ctx of their own (see ds.RawInterface),
+// so this is necessarily the ctx in effect when the filter chain was
+// last (re)built by Raw(ctx), which is every call's own ctx as long as
+// it's obtained the normal way via ds.Raw(ctx).Whatever(...).
+func WithOpLog(ctx context.Context, sink Sink) context.Context {
+	return ds.AddRawFilters(ctx, func(fctx context.Context, raw ds.RawInterface) ds.RawInterface {
+		return &opLogFilter{RawInterface: raw, ctx: fctx, sink: sink}
+	})
+}
+
+type opLogFilter struct {
+	ds.RawInterface
+	ctx  context.Context
+	sink Sink
+}
+
+func (f *opLogFilter) record(op string, start time.Time, err error) {
+	tag, _ := ds.OperationTag(f.ctx)
+	f.sink(Entry{Op: op, Tag: tag, Duration: time.Since(start), Err: err})
+}
+
+func (f *opLogFilter) GetMulti(keys []*ds.Key, vals []ds.PropertyMap) error {
+	start := time.Now()
+	err := f.RawInterface.GetMulti(keys, vals)
+	f.record("GetMulti", start, err)
+	return err
+}
+
+func (f *opLogFilter) PutMulti(keys []*ds.Key, vals []ds.PropertyMap) ([]*ds.Key, error) {
+	start := time.Now()
+	out, err := f.RawInterface.PutMulti(keys, vals)
+	f.record("PutMulti", start, err)
+	return out, err
+}
+
+func (f *opLogFilter) DeleteMulti(keys []*ds.Key) error {
+	start := time.Now()
+	err := f.RawInterface.DeleteMulti(keys)
+	f.record("DeleteMulti", start, err)
+	return err
+}
+
+func (f *opLogFilter) Run(q *ds.Query, cb ds.RunCB) error {
+	start := time.Now()
+	err := f.RawInterface.Run(q, cb)
+	f.record("Run", start, err)
+	return err
+}
+
+func (f *opLogFilter) Count(q *ds.Query) (int64, error) {
+	start := time.Now()
+	n, err := f.RawInterface.Count(q)
+	f.record("Count", start, err)
+	return n, err
+}
@@ -0,0 +1,73 @@
+package oplog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestOpLogIncludesTheOperationTag(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+
+	var entries []Entry
+	ctx = WithOpLog(ctx, func(e Entry) { entries = append(entries, e) })
+	ctx = ds.WithOperationTag(ctx, "ImportOrder")
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1: %+v", len(entries), entries)
+	}
+	if entries[0].Op != "PutMulti" {
+		t.Errorf("Op = %q, want PutMulti", entries[0].Op)
+	}
+	if entries[0].Tag != "ImportOrder" {
+		t.Errorf("Tag = %q, want ImportOrder", entries[0].Tag)
+	}
+}
+
+func TestOpLogTagDefaultsEmptyWithoutWithOperationTag(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+
+	var entries []Entry
+	ctx = WithOpLog(ctx, func(e Entry) { entries = append(entries, e) })
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Tag != "" {
+		t.Fatalf("entries = %+v, want one entry with an empty Tag", entries)
+	}
+}
+
+func TestOpLogTagVariesPerCall(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+
+	var entries []Entry
+	ctx = WithOpLog(ctx, func(e Entry) { entries = append(entries, e) })
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	ctxA := ds.WithOperationTag(ctx, "A")
+	ctxB := ds.WithOperationTag(ctx, "B")
+
+	if _, err := ds.Raw(ctxA).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti (A): %v", err)
+	}
+	if err := ds.Raw(ctxB).DeleteMulti([]*ds.Key{k}); err != nil {
+		t.Fatalf("DeleteMulti (B): %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[0].Tag != "A" || entries[1].Tag != "B" {
+		t.Errorf("tags = %q, %q, want A, B", entries[0].Tag, entries[1].Tag)
+	}
+}
@@ -0,0 +1,128 @@
+// Package querycache provides a datastore filter that caches the
+// ordered result keys of expensive, rarely-changing queries in
+// memcache, serving a cache hit as a GetMulti instead of re-running the
+// query.
+package querycache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	ds "github.com/luci/gae/service/datastore"
+	mc "github.com/luci/gae/service/memcache"
+)
+
+type cacheableKeyType struct{}
+
+var cacheableKey cacheableKeyType
+
+// WithCacheableQueries returns a context in which Run (and anything
+// built on it, such as GetAllT) caches its ordered result keys in
+// memcache for ttl, keyed by a hash of the query. A second identical
+// query made before ttl elapses is served as a GetMulti against the
+// cached keys instead of re-running the query. Invalidation is purely
+// TTL-based: a write that should invalidate a cached query must either
+// let the TTL expire or evict the key itself (e.g. via memcache.Raw(ctx)
+// .DeleteMulti).
+func WithCacheableQueries(ctx context.Context, ttl time.Duration) context.Context {
+	return ds.AddRawFilters(ctx, func(fctx context.Context, raw ds.RawInterface) ds.RawInterface {
+		return &queryCacheFilter{RawInterface: raw, ctx: fctx, ttl: ttl}
+	})
+}
+
+// queryCacheFilter wraps a datastore.RawInterface, caching Run's ordered
+// result keys in memcache under queryCacheKey(q).
+type queryCacheFilter struct {
+	ds.RawInterface
+	ctx context.Context
+	ttl time.Duration
+}
+
+// tok is the serializable form of one link in a Key's ancestor chain.
+type tok struct {
+	Kind     string
+	StringID string
+	IntID    int64
+}
+
+// cachedQuery is what's actually stored in memcache: the query's
+// AppID/Namespace (shared by every result key) plus each result's
+// ancestor-chain of toks, root first.
+type cachedQuery struct {
+	AppID     string
+	Namespace string
+	Keys      [][]tok
+}
+
+func queryCacheKey(q *ds.Query) string {
+	sum := sha256.Sum256([]byte(q.DebugString()))
+	return "querycache:" + hex.EncodeToString(sum[:])
+}
+
+func keyToks(k *ds.Key) []tok {
+	var toks []tok
+	for cur := k; cur != nil; cur = cur.Parent() {
+		toks = append(toks, tok{Kind: cur.Kind(), StringID: cur.StringID(), IntID: cur.IntID()})
+	}
+	for i, j := 0, len(toks)-1; i < j; i, j = i+1, j-1 {
+		toks[i], toks[j] = toks[j], toks[i]
+	}
+	return toks
+}
+
+func toksKey(appID, namespace string, toks []tok) *ds.Key {
+	var k *ds.Key
+	for _, t := range toks {
+		k = ds.NewKey(appID, namespace, t.Kind, t.StringID, t.IntID, k)
+	}
+	return k
+}
+
+func (f *queryCacheFilter) Run(q *ds.Query, cb ds.RunCB) error {
+	cacheKey := queryCacheKey(q)
+
+	if item, err := mc.Raw(f.ctx).Get(cacheKey); err == nil {
+		var cached cachedQuery
+		if err := json.Unmarshal(item.Value(), &cached); err == nil {
+			keys := make([]*ds.Key, len(cached.Keys))
+			for i, toks := range cached.Keys {
+				keys[i] = toksKey(cached.AppID, cached.Namespace, toks)
+			}
+			vals := make([]ds.PropertyMap, len(keys))
+			for i := range vals {
+				vals[i] = ds.PropertyMap{}
+			}
+			if err := f.RawInterface.GetMulti(keys, vals); err != nil {
+				return err
+			}
+			for i, k := range keys {
+				if err := cb(k, vals[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	cached := cachedQuery{}
+	err := f.RawInterface.Run(q, func(k *ds.Key, pm ds.PropertyMap) error {
+		if cached.AppID == "" {
+			cached.AppID, cached.Namespace = k.AppID(), k.Namespace()
+		}
+		cached.Keys = append(cached.Keys, keyToks(k))
+		return cb(k, pm)
+	})
+	if err != nil {
+		return err
+	}
+
+	if b, jerr := json.Marshal(cached); jerr == nil {
+		// Best-effort: a memcache write failure shouldn't fail the query
+		// that just succeeded against the real backend.
+		_ = mc.Raw(f.ctx).SetMulti([]*mc.Item{mc.NewItem(cacheKey).SetValue(b).SetExpiration(f.ttl)})
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package querycache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+	mc "github.com/luci/gae/service/memcache"
+)
+
+type qcWidget struct {
+	Count int64
+}
+
+func setup() context.Context {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ctx = mc.SetRaw(ctx, memory.NewMemcache())
+	return WithCacheableQueries(ctx, time.Minute)
+}
+
+func seed(ctx context.Context, t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		k := ds.NewKey("app", "", "Widget", "", int64(i+1), nil)
+		if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": int64(i)}}); err != nil {
+			t.Fatalf("seed PutMulti: %v", err)
+		}
+	}
+}
+
+func runAll(ctx context.Context, q *ds.Query) ([]*qcWidget, error) {
+	return ds.GetAllT[qcWidget](ctx, q)
+}
+
+func TestSecondIdenticalQueryHitsCache(t *testing.T) {
+	ctx := setup()
+	seed(ctx, t, 3)
+
+	q := ds.NewQuery("Widget")
+	first, err := runAll(ctx, q)
+	if err != nil {
+		t.Fatalf("first Run: %v", err)
+	}
+	if len(first) != 3 {
+		t.Fatalf("first Run returned %d results, want 3", len(first))
+	}
+
+	// Mutate the backend without touching the cache: a cache hit should
+	// keep serving the stale key list (TTL-based invalidation only), but
+	// a changed property on a still-extant key is picked up via GetMulti.
+	k := ds.NewKey("app", "", "Widget", "", int64(1), nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": int64(99)}}); err != nil {
+		t.Fatalf("mutate PutMulti: %v", err)
+	}
+
+	second, err := runAll(ctx, q)
+	if err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if len(second) != 3 {
+		t.Fatalf("second (cached) Run returned %d results, want 3 from the cached key list", len(second))
+	}
+	if second[0].Count != 99 {
+		t.Errorf("second Run's first result Count = %d, want 99 (GetMulti should reflect the current value)", second[0].Count)
+	}
+
+	if _, err := mc.Raw(ctx).Get(queryCacheKey(q)); err != nil {
+		t.Errorf("Get(queryCacheKey): %v, want the query result list to be cached", err)
+	}
+}
+
+func TestDifferentQueriesGetDifferentCacheKeys(t *testing.T) {
+	ctx := setup()
+	seed(ctx, t, 1)
+
+	q1 := ds.NewQuery("Widget")
+	q2 := ds.NewQuery("Widget").Filter("Count", ds.Equal, int64(0))
+
+	if queryCacheKey(q1) == queryCacheKey(q2) {
+		t.Errorf("queryCacheKey(q1) == queryCacheKey(q2), want distinct keys for distinct queries")
+	}
+}
@@ -0,0 +1,157 @@
+// Package shardedkeys provides a datastore filter implementing a
+// sharded-entity scheme: writes to a configured kind are spread across N
+// physical shard keys instead of a single logical key (to avoid a
+// single entity group becoming a write hotspot), and reads merge the
+// shards back together.
+package shardedkeys
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// shardSuffixSep separates a logical named key's StringID from its
+// shard index in the physical key actually written, e.g.
+// "my-counter~shard3".
+const shardSuffixSep = "~shard"
+
+// shardedKeysFilter wraps a datastore.RawInterface, rewriting named keys
+// of kind to a randomly chosen physical shard key on PutMulti, and
+// merging shards back into one logical result per Run, summing their
+// numeric properties.
+type shardedKeysFilter struct {
+	ds.RawInterface
+	kind       string
+	shardCount int
+}
+
+// FilterShardedKeys installs a filter into ctx that, for named keys of
+// kind, writes to one of shardCount physical shard keys chosen at
+// random (instead of the logical key as given) on PutMulti, and merges
+// the shards back into one result per logical key when kind is queried
+// via Run, summing each shard's numeric properties. Keys of other kinds,
+// and incomplete (auto-allocated) keys of kind, pass through unchanged:
+// this scheme only applies to explicitly named keys.
+//
+// GetMulti is not overridden: a direct Get by the logical key won't find
+// anything, since the entity is actually stored under a physical shard
+// key. Use a Run query (or KeysOnly query plus Get, once you have the
+// physical key) to read a sharded entity.
+func FilterShardedKeys(ctx context.Context, kind string, shardCount int) context.Context {
+	return ds.AddRawFilters(ctx, func(_ context.Context, raw ds.RawInterface) ds.RawInterface {
+		return &shardedKeysFilter{RawInterface: raw, kind: kind, shardCount: shardCount}
+	})
+}
+
+func shardKey(k *ds.Key, shard int) *ds.Key {
+	if k.Kind() == "" || k.StringID() == "" {
+		return k
+	}
+	return ds.NewKey(k.AppID(), k.Namespace(), k.Kind(), k.StringID()+shardSuffixSep+strconv.Itoa(shard), 0, k.Parent())
+}
+
+func logicalStringID(stringID string) string {
+	if i := strings.Index(stringID, shardSuffixSep); i >= 0 {
+		return stringID[:i]
+	}
+	return stringID
+}
+
+func (f *shardedKeysFilter) PutMulti(keys []*ds.Key, vals []ds.PropertyMap) ([]*ds.Key, error) {
+	physical := make([]*ds.Key, len(keys))
+	for i, k := range keys {
+		if k.Kind() != f.kind {
+			physical[i] = k
+			continue
+		}
+		physical[i] = shardKey(k, rand.Intn(f.shardCount))
+	}
+
+	out, err := f.RawInterface.PutMulti(physical, vals)
+	// Report the logical keys the caller passed in, not the physical
+	// shard keys actually written, so a sharded write looks like any
+	// other PutMulti from the caller's side.
+	reported := make([]*ds.Key, len(out))
+	for i, k := range out {
+		if k == nil || keys[i].Kind() != f.kind {
+			reported[i] = k
+			continue
+		}
+		reported[i] = keys[i]
+	}
+	return reported, err
+}
+
+func (f *shardedKeysFilter) Run(q *ds.Query, cb ds.RunCB) error {
+	if q.Kind() != f.kind {
+		return f.RawInterface.Run(q, cb)
+	}
+
+	type mergedEntity struct {
+		key *ds.Key
+		pm  ds.PropertyMap
+	}
+	merged := map[string]*mergedEntity{}
+	var order []string
+
+	err := f.RawInterface.Run(q, func(k *ds.Key, pm ds.PropertyMap) error {
+		logicalKey := ds.NewKey(k.AppID(), k.Namespace(), k.Kind(), logicalStringID(k.StringID()), k.IntID(), k.Parent())
+		enc := logicalKey.String()
+
+		m, ok := merged[enc]
+		if !ok {
+			cp := make(ds.PropertyMap, len(pm))
+			for p, v := range pm {
+				cp[p] = v
+			}
+			merged[enc] = &mergedEntity{key: logicalKey, pm: cp}
+			order = append(order, enc)
+			return nil
+		}
+		for p, v := range pm {
+			if existing, ok := m.pm[p]; ok {
+				if a, aok := numericValue(existing); aok {
+					if b, bok := numericValue(v); bok {
+						m.pm[p] = sameRepr(existing, a+b)
+						continue
+					}
+				}
+			}
+			m.pm[p] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, enc := range order {
+		m := merged[enc]
+		if err := cb(m.key, m.pm); err != nil {
+			if err == ds.Stop {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func numericValue(v interface{}) (int64, bool) {
+	if p, ok := v.(ds.Property); ok {
+		v = p.Value()
+	}
+	n, ok := v.(int64)
+	return n, ok
+}
+
+func sameRepr(existing interface{}, n int64) interface{} {
+	if _, ok := existing.(ds.Property); ok {
+		return ds.MkProperty(n)
+	}
+	return n
+}
@@ -0,0 +1,106 @@
+package softdelete
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func setup() context.Context {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	return FilterSoftDelete(ctx, "tombstone")
+}
+
+func putWidget(t *testing.T, ctx context.Context, id string) *ds.Key {
+	t.Helper()
+	k := ds.NewKey("app", "", "Widget", id, 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+	return k
+}
+
+func TestDeleteMultiTombstonesInsteadOfRemoving(t *testing.T) {
+	ctx := setup()
+	k := putWidget(t, ctx, "a")
+
+	if err := ds.Raw(ctx).DeleteMulti([]*ds.Key{k}); err != nil {
+		t.Fatalf("DeleteMulti: %v", err)
+	}
+
+	err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, []ds.PropertyMap{{}})
+	me, ok := err.(ds.MultiError)
+	if !ok || me[0] != ds.ErrNoSuchEntity {
+		t.Fatalf("GetMulti after delete = %v, want MultiError{ErrNoSuchEntity}", err)
+	}
+
+	if err := ds.Raw(WithDeleted(ctx)).GetMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Errorf("GetMulti with WithDeleted = %v, want nil (entity should still exist)", err)
+	}
+}
+
+func TestGetMultiHidesTombstonedEntityBatchedWithAMissingKey(t *testing.T) {
+	ctx := setup()
+	deletedKey := putWidget(t, ctx, "a")
+	if err := ds.Raw(ctx).DeleteMulti([]*ds.Key{deletedKey}); err != nil {
+		t.Fatalf("DeleteMulti: %v", err)
+	}
+	missingKey := ds.NewKey("app", "", "Widget", "never-written", 0, nil)
+
+	vals := []ds.PropertyMap{{}, {}}
+	err := ds.Raw(ctx).GetMulti([]*ds.Key{deletedKey, missingKey}, vals)
+	me, ok := err.(ds.MultiError)
+	if !ok {
+		t.Fatalf("GetMulti = %v (%T), want a MultiError", err, err)
+	}
+	if me[0] != ds.ErrNoSuchEntity {
+		t.Errorf("me[0] = %v, want ErrNoSuchEntity (tombstoned entity must stay hidden)", me[0])
+	}
+	if me[1] != ds.ErrNoSuchEntity {
+		t.Errorf("me[1] = %v, want ErrNoSuchEntity (never-written key)", me[1])
+	}
+}
+
+func TestRunExcludesTombstonedEntities(t *testing.T) {
+	ctx := setup()
+	putWidget(t, ctx, "a")
+	k2 := putWidget(t, ctx, "b")
+	if err := ds.Raw(ctx).DeleteMulti([]*ds.Key{k2}); err != nil {
+		t.Fatalf("DeleteMulti: %v", err)
+	}
+
+	var seen []string
+	err := ds.Raw(ctx).Run(ds.NewQuery("Widget"), func(k *ds.Key, pm ds.PropertyMap) error {
+		seen = append(seen, k.StringID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(seen) != 1 || seen[0] != "a" {
+		t.Errorf("Run saw %v, want only [a]", seen)
+	}
+}
+
+func TestRunWithDeletedIncludesTombstonedEntities(t *testing.T) {
+	ctx := setup()
+	putWidget(t, ctx, "a")
+	k2 := putWidget(t, ctx, "b")
+	if err := ds.Raw(ctx).DeleteMulti([]*ds.Key{k2}); err != nil {
+		t.Fatalf("DeleteMulti: %v", err)
+	}
+
+	var seen []string
+	err := ds.Raw(WithDeleted(ctx)).Run(ds.NewQuery("Widget"), func(k *ds.Key, pm ds.PropertyMap) error {
+		seen = append(seen, k.StringID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(seen) != 2 {
+		t.Errorf("Run with WithDeleted saw %v, want both [a b]", seen)
+	}
+}
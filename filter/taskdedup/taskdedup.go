@@ -0,0 +1,77 @@
+// Package taskdedup provides a task queue filter that deduplicates
+// AddMulti calls by content hash, so enqueueing the same task twice
+// (e.g. after a caller retries following an ambiguous failure) only
+// ever results in one task running.
+package taskdedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	tq "github.com/luci/gae/service/taskqueue"
+)
+
+// taskDedupFilter gives a task with no explicit Name a content-derived
+// one, so that AddMulti-ing logically identical content twice is a
+// no-op instead of enqueueing a duplicate.
+type taskDedupFilter struct {
+	tq.RawInterface
+}
+
+// FilterDedup installs a filter into ctx that computes a name for any
+// task passed to AddMulti without one, hashing its Queue, Tag, and
+// Payload, so that AddMulti-ing the same content twice only ever
+// enqueues it once: the backend's ErrTaskAlreadyExists for the
+// resulting name collision is treated as success, since from the
+// caller's perspective the content is already queued.
+//
+// Only tasks with Name == "" are affected; a caller-supplied Name is
+// never overridden, so explicit dedup keys continue to work exactly as
+// before.
+func FilterDedup(ctx context.Context) context.Context {
+	return tq.AddRawFilters(ctx, func(_ context.Context, raw tq.RawInterface) tq.RawInterface {
+		return taskDedupFilter{RawInterface: raw}
+	})
+}
+
+func dedupName(t *tq.Task) string {
+	h := sha256.New()
+	h.Write([]byte(t.Queue))
+	h.Write([]byte{0})
+	h.Write([]byte(t.Tag))
+	h.Write([]byte{0})
+	h.Write(t.Payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (f taskDedupFilter) AddMulti(tasks []*tq.Task) ([]*tq.Task, error) {
+	named := make([]*tq.Task, len(tasks))
+	for i, task := range tasks {
+		if task.Name != "" {
+			named[i] = task
+			continue
+		}
+		cp := *task
+		cp.Name = dedupName(task)
+		named[i] = &cp
+	}
+
+	out, err := f.RawInterface.AddMulti(named)
+	if err == nil {
+		return out, nil
+	}
+	me, ok := err.(tq.MultiError)
+	if !ok {
+		return out, err
+	}
+	for i, e := range me {
+		if e == tq.ErrTaskAlreadyExists {
+			me[i] = nil
+		}
+	}
+	if !me.Any() {
+		return out, nil
+	}
+	return out, me
+}
@@ -0,0 +1,57 @@
+package taskdedup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	tq "github.com/luci/gae/service/taskqueue"
+)
+
+func setup() context.Context {
+	ctx := tq.SetRaw(context.Background(), memory.NewTaskQueue())
+	return FilterDedup(ctx)
+}
+
+func TestAddMultiWithoutNameDedupsByContent(t *testing.T) {
+	ctx := setup()
+	t1 := &tq.Task{Queue: "default", Payload: []byte("hello"), Tag: "greeting"}
+	t2 := &tq.Task{Queue: "default", Payload: []byte("hello"), Tag: "greeting"}
+
+	out1, err := tq.Raw(ctx).AddMulti([]*tq.Task{t1})
+	if err != nil {
+		t.Fatalf("AddMulti #1: %v", err)
+	}
+	out2, err := tq.Raw(ctx).AddMulti([]*tq.Task{t2})
+	if err != nil {
+		t.Fatalf("AddMulti #2 (duplicate content): %v", err)
+	}
+	if out1[0].Name != out2[0].Name {
+		t.Errorf("dedup names differ: %q vs %q, want identical content to get the same name", out1[0].Name, out2[0].Name)
+	}
+}
+
+func TestAddMultiWithDifferentContentGetsDifferentNames(t *testing.T) {
+	ctx := setup()
+	out, err := tq.Raw(ctx).AddMulti([]*tq.Task{
+		{Queue: "default", Payload: []byte("a")},
+		{Queue: "default", Payload: []byte("b")},
+	})
+	if err != nil {
+		t.Fatalf("AddMulti: %v", err)
+	}
+	if out[0].Name == out[1].Name {
+		t.Errorf("distinct content got the same name %q", out[0].Name)
+	}
+}
+
+func TestAddMultiRespectsExplicitName(t *testing.T) {
+	ctx := setup()
+	out, err := tq.Raw(ctx).AddMulti([]*tq.Task{{Name: "explicit", Queue: "default", Payload: []byte("a")}})
+	if err != nil {
+		t.Fatalf("AddMulti: %v", err)
+	}
+	if out[0].Name != "explicit" {
+		t.Errorf("Name = %q, want the caller-supplied name preserved", out[0].Name)
+	}
+}
@@ -0,0 +1,100 @@
+package ttl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luci/gae/common/clock/testclock"
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+var epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func setup(t *testing.T) (context.Context, *testclock.TestClock, *Config) {
+	t.Helper()
+	clk := testclock.New(epoch)
+	cfg := &Config{Property: "Expiry", Clock: clk}
+	ctx := FilterTTL(ds.SetRaw(context.Background(), memory.NewDatastore("app")), cfg)
+	return ctx, clk, cfg
+}
+
+func putWidget(t *testing.T, ctx context.Context, id string, expiry time.Time) *ds.Key {
+	t.Helper()
+	k := ds.NewKey("app", "", "Widget", id, 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Expiry": expiry}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+	return k
+}
+
+func TestRunHidesExpiredEntities(t *testing.T) {
+	ctx, _, _ := setup(t)
+	putWidget(t, ctx, "fresh", epoch.Add(time.Hour))
+	putWidget(t, ctx, "stale", epoch.Add(-time.Hour))
+
+	var seen []string
+	err := ds.Raw(ctx).Run(ds.NewQuery("Widget"), func(k *ds.Key, pm ds.PropertyMap) error {
+		seen = append(seen, k.StringID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(seen) != 1 || seen[0] != "fresh" {
+		t.Errorf("Run saw %v, want only [fresh]", seen)
+	}
+}
+
+func TestRunWithExpiredIncludesExpiredEntities(t *testing.T) {
+	ctx, _, _ := setup(t)
+	putWidget(t, ctx, "fresh", epoch.Add(time.Hour))
+	putWidget(t, ctx, "stale", epoch.Add(-time.Hour))
+
+	var seen []string
+	err := ds.Raw(WithExpired(ctx)).Run(ds.NewQuery("Widget"), func(k *ds.Key, pm ds.PropertyMap) error {
+		seen = append(seen, k.StringID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(seen) != 2 {
+		t.Errorf("Run with WithExpired saw %v, want both [fresh stale]", seen)
+	}
+}
+
+func TestCollectExpiredDeletesOnlyExpiredEntities(t *testing.T) {
+	ctx, _, cfg := setup(t)
+	fresh := putWidget(t, ctx, "fresh", epoch.Add(time.Hour))
+	stale := putWidget(t, ctx, "stale", epoch.Add(-time.Hour))
+
+	n, err := CollectExpired(ctx, cfg, "Widget", 0)
+	if err != nil {
+		t.Fatalf("CollectExpired: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("CollectExpired deleted %d entities, want 1", n)
+	}
+
+	if err := ds.Raw(WithExpired(ctx)).GetMulti([]*ds.Key{stale}, []ds.PropertyMap{{}}); err == nil {
+		t.Errorf("GetMulti(stale) = nil error, want ErrNoSuchEntity after collection")
+	}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{fresh}, []ds.PropertyMap{{}}); err != nil {
+		t.Errorf("GetMulti(fresh) = %v, want nil (should survive collection)", err)
+	}
+}
+
+func TestCollectExpiredIsNoopWhenNothingExpired(t *testing.T) {
+	ctx, _, cfg := setup(t)
+	putWidget(t, ctx, "fresh", epoch.Add(time.Hour))
+
+	n, err := CollectExpired(ctx, cfg, "Widget", 0)
+	if err != nil {
+		t.Fatalf("CollectExpired: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("CollectExpired deleted %d entities, want 0", n)
+	}
+}
@@ -0,0 +1,164 @@
+// Package txndefer provides a datastore filter that lets transaction
+// bodies register best-effort callbacks (cache invalidation, metrics,
+// ...) to run after a successful commit, outside the transaction.
+package txndefer
+
+import (
+	"context"
+	"sync"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type deferStateKeyType struct{}
+
+var deferStateKey deferStateKeyType
+
+// deferState accumulates the callbacks registered during one
+// RunInTransaction attempt, in registration order.
+type deferState struct {
+	cbs         []func(context.Context)
+	parallelCBs []func(context.Context)
+	errCBs      []func(context.Context) error
+}
+
+// Defer registers cb to run, with the non-transactional context captured
+// when RunInTransaction was called (not the ctx active inside the
+// transaction body, and so not carrying any context.WithValue set during
+// it — see DeferWithValues if a callback needs a value computed inside
+// the transaction), after the enclosing transaction commits
+// successfully. Callbacks run in reverse registration order, after
+// commit, outside the transaction; a rolled-back or retried attempt
+// discards them. Defer panics if ctx isn't inside a transaction started
+// through FilterRDS.
+func Defer(ctx context.Context, cb func(context.Context)) {
+	st, ok := ctx.Value(deferStateKey).(*deferState)
+	if !ok {
+		panic("txndefer: Defer called outside a FilterRDS-wrapped transaction")
+	}
+	st.cbs = append(st.cbs, cb)
+}
+
+// DeferWithValues is like Defer, but additionally captures ctx's values
+// for each of keys (as of the DeferWithValues call, i.e. including
+// anything set inside the transaction body up to that point) and
+// reapplies them on top of the non-transactional context before invoking
+// cb, so values computed mid-transaction aren't lost the way they are
+// with a plain Defer.
+func DeferWithValues(ctx context.Context, cb func(context.Context), keys ...interface{}) {
+	captured := make([]interface{}, len(keys))
+	for i, k := range keys {
+		captured[i] = ctx.Value(k)
+	}
+	Defer(ctx, func(base context.Context) {
+		for i, k := range keys {
+			if captured[i] != nil {
+				base = context.WithValue(base, k, captured[i])
+			}
+		}
+		cb(base)
+	})
+}
+
+// DeferParallel is like Defer, but cb is collected separately and, after
+// commit, run concurrently with every other parallel-deferred callback
+// instead of sequentially. Ordered Defer callbacks still run first, in
+// their usual reverse-registration order; the parallel callbacks then
+// all run at once with no ordering guarantee among themselves. A panic
+// in one parallel callback is recovered so it can't prevent the others
+// from running.
+//
+// This package has no dependency on a common/sync/parallel-style helper
+// (none exists in this repo), so the fan-out is a plain unbounded
+// sync.WaitGroup rather than a bounded worker pool.
+func DeferParallel(ctx context.Context, cb func(context.Context)) {
+	st, ok := ctx.Value(deferStateKey).(*deferState)
+	if !ok {
+		panic("txndefer: DeferParallel called outside a FilterRDS-wrapped transaction")
+	}
+	st.parallelCBs = append(st.parallelCBs, cb)
+}
+
+// DeferE is like Defer, but cb can report an error. Errors are swallowed
+// unless the transaction's backend was wrapped with
+// FilterRDSWithErrorHandler, in which case every non-nil error is passed
+// to the handler after commit. DeferE callbacks run, in registration
+// order, after all ordered Defer and DeferParallel callbacks.
+func DeferE(ctx context.Context, cb func(context.Context) error) {
+	st, ok := ctx.Value(deferStateKey).(*deferState)
+	if !ok {
+		panic("txndefer: DeferE called outside a FilterRDS-wrapped transaction")
+	}
+	st.errCBs = append(st.errCBs, cb)
+}
+
+// ErrorHandler receives each non-nil error returned by a DeferE callback
+// after the transaction that deferred it has committed.
+type ErrorHandler func(context.Context, error)
+
+func execCBs(ctx context.Context, cbs []func(context.Context)) {
+	for i := len(cbs) - 1; i >= 0; i-- {
+		cbs[i](ctx)
+	}
+}
+
+// execParallelCBs runs cbs concurrently, waiting for all of them to
+// finish. A panic in one is recovered and discarded so it can't abort
+// the others; callbacks wanting to report failure should use DeferE
+// instead.
+func execParallelCBs(ctx context.Context, cbs []func(context.Context)) {
+	var wg sync.WaitGroup
+	wg.Add(len(cbs))
+	for _, cb := range cbs {
+		cb := cb
+		go func() {
+			defer wg.Done()
+			defer func() { recover() }()
+			cb(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// rdsDefer wraps a datastore.RawInterface, installing a deferState into
+// the context each RunInTransaction attempt's body runs with, and
+// running its accumulated callbacks (see Defer) after a successful
+// commit.
+type rdsDefer struct {
+	ds.RawInterface
+	onErr ErrorHandler
+}
+
+// FilterRDS wraps raw with a filter that makes
+// Defer/DeferWithValues/DeferParallel/DeferE available to code running
+// inside RunInTransaction. DeferE errors are swallowed; use
+// FilterRDSWithErrorHandler to observe them.
+func FilterRDS(raw ds.RawInterface) ds.RawInterface {
+	return &rdsDefer{RawInterface: raw}
+}
+
+// FilterRDSWithErrorHandler is like FilterRDS, but passes every non-nil
+// error returned by a DeferE callback to handler, after commit.
+func FilterRDSWithErrorHandler(raw ds.RawInterface, handler ErrorHandler) ds.RawInterface {
+	return &rdsDefer{RawInterface: raw, onErr: handler}
+}
+
+func (f *rdsDefer) RunInTransaction(ctx context.Context, fn func(context.Context) error, opts *ds.TransactionOptions) error {
+	st := &deferState{}
+	wrapped := func(txnCtx context.Context) error {
+		// a retried attempt's callbacks don't carry over
+		st.cbs, st.parallelCBs, st.errCBs = nil, nil, nil
+		return fn(context.WithValue(txnCtx, deferStateKey, st))
+	}
+	if err := f.RawInterface.RunInTransaction(ctx, wrapped, opts); err != nil {
+		return err
+	}
+	execCBs(ctx, st.cbs)
+	execParallelCBs(ctx, st.parallelCBs)
+	for _, cb := range st.errCBs {
+		if err := cb(ctx); err != nil && f.onErr != nil {
+			f.onErr(ctx, err)
+		}
+	}
+	return nil
+}
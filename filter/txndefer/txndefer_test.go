@@ -0,0 +1,200 @@
+package txndefer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type requestIDKeyType struct{}
+
+var requestIDKey requestIDKeyType
+
+func setup() context.Context {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	return ds.SetRaw(ctx, FilterRDS(ds.Raw(ctx)))
+}
+
+func TestDeferRunsInReverseOrderAfterCommit(t *testing.T) {
+	ctx := setup()
+
+	var order []int
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		Defer(ctx, func(context.Context) { order = append(order, 1) })
+		Defer(ctx, func(context.Context) { order = append(order, 2) })
+		Defer(ctx, func(context.Context) { order = append(order, 3) })
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+
+	if len(order) != 3 || order[0] != 3 || order[1] != 2 || order[2] != 1 {
+		t.Errorf("callback order = %v, want [3 2 1]", order)
+	}
+}
+
+func TestDeferDoesNotRunOnRollback(t *testing.T) {
+	ctx := setup()
+
+	ran := false
+	wantErr := errors.New("boom")
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		Defer(ctx, func(context.Context) { ran = true })
+		return wantErr
+	}, nil)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("RunInTransaction err = %v, want %v", err, wantErr)
+	}
+	if ran {
+		t.Error("deferred callback ran despite the transaction failing")
+	}
+}
+
+func TestDeferCallbackLosesValuesSetInsideTransaction(t *testing.T) {
+	ctx := setup()
+
+	var seen interface{}
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		ctx = context.WithValue(ctx, requestIDKey, "abc123")
+		Defer(ctx, func(cbCtx context.Context) { seen = cbCtx.Value(requestIDKey) })
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+
+	if seen != nil {
+		t.Errorf("Defer callback saw value %v, want nil (documented value-loss behavior)", seen)
+	}
+}
+
+func TestDeferParallelRunsConcurrentlyAfterOrderedCallbacks(t *testing.T) {
+	ctx := setup()
+
+	const n = 8
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(n)
+	var mu sync.Mutex
+	running := 0
+	maxRunning := 0
+	var sequentialRan bool
+
+	// RunInTransaction runs the parallel Defer callbacks synchronously
+	// before returning, so closing start has to happen concurrently with
+	// the call rather than after it, or this would deadlock waiting on
+	// itself.
+	go close(start)
+
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		Defer(ctx, func(context.Context) { sequentialRan = true })
+		for i := 0; i < n; i++ {
+			DeferParallel(ctx, func(context.Context) {
+				<-start
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				wg.Done()
+				wg.Wait()
+				mu.Lock()
+				running--
+				mu.Unlock()
+			})
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+
+	if !sequentialRan {
+		t.Error("ordered Defer callback did not run")
+	}
+	if maxRunning < 2 {
+		t.Errorf("maxRunning = %d, want parallel callbacks to overlap", maxRunning)
+	}
+}
+
+func TestDeferParallelPanicDoesNotBlockOthers(t *testing.T) {
+	ctx := setup()
+
+	var ran [3]bool
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		DeferParallel(ctx, func(context.Context) { ran[0] = true })
+		DeferParallel(ctx, func(context.Context) { panic("boom") })
+		DeferParallel(ctx, func(context.Context) { ran[2] = true })
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+
+	if !ran[0] || !ran[2] {
+		t.Errorf("ran = %v, want the non-panicking callbacks to still run", ran)
+	}
+}
+
+func TestDeferEErrorReachesHandler(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	var gotErr error
+	ctx = ds.SetRaw(ctx, FilterRDSWithErrorHandler(ds.Raw(ctx), func(_ context.Context, err error) {
+		gotErr = err
+	}))
+
+	wantErr := errors.New("cache invalidation failed")
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		DeferE(ctx, func(context.Context) error { return wantErr })
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+
+	if !errors.Is(gotErr, wantErr) {
+		t.Errorf("handler saw err = %v, want %v", gotErr, wantErr)
+	}
+}
+
+func TestDeferENilErrorHandlerSwallowsError(t *testing.T) {
+	ctx := setup()
+
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		DeferE(ctx, func(context.Context) error { return errors.New("ignored") })
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+}
+
+func TestDeferWithValuesPreservesSelectedKeys(t *testing.T) {
+	ctx := setup()
+
+	var seenID, seenOther interface{}
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		ctx = context.WithValue(ctx, requestIDKey, "abc123")
+		DeferWithValues(ctx, func(cbCtx context.Context) {
+			seenID = cbCtx.Value(requestIDKey)
+			seenOther = cbCtx.Value("unrequested")
+		}, requestIDKey)
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+
+	if seenID != "abc123" {
+		t.Errorf("DeferWithValues callback saw requestIDKey = %v, want abc123", seenID)
+	}
+	if seenOther != nil {
+		t.Errorf("DeferWithValues callback saw unrequested key = %v, want nil", seenOther)
+	}
+}
@@ -0,0 +1,100 @@
+package cloud
+
+import (
+	"context"
+	"time"
+
+	"github.com/luci/gae/common/clock"
+)
+
+// CertFetcher fetches the public certificates (keyID -> PEM-encoded
+// certificate) currently used to verify tokens signed by a Google
+// service account, typically from the x509 metadata endpoint.
+type CertFetcher interface {
+	FetchCertificates(ctx context.Context) (map[string]string, error)
+}
+
+const (
+	certsCacheKey         = "certs"
+	certsSuccessTTL       = time.Hour
+	initialCertBackoff    = time.Second
+	maxCertBackoff        = time.Minute
+	certBackoffMultiplier = 2
+)
+
+// certsCacheEntry is the sentinel PublicCertificates stores in its
+// process cache: it carries the last-known-good certs (if any), the most
+// recent fetch error (if the last attempt failed), and validUntil, the
+// time before which the entry should be served as-is rather than
+// refetched. On success validUntil is certsSuccessTTL out; on failure
+// it's a backoff that grows on each consecutive failure, up to
+// maxCertBackoff.
+type certsCacheEntry struct {
+	certs      map[string]string
+	err        error
+	validUntil time.Time
+	backoff    time.Duration
+}
+
+func (gsp *GoogleServiceProvider) clock() clock.Clock {
+	if gsp.Clock != nil {
+		return gsp.Clock
+	}
+	return clock.SystemClock{}
+}
+
+// PublicCertificates returns the currently cached public certificates,
+// fetching them via Certs if there's no cached entry or the cached entry
+// has expired. If a fetch fails, the error is cached with an
+// exponentially increasing backoff (capped at maxCertBackoff) during
+// which further calls are served the last-known-good certs, if any, or
+// else the cached error, instead of hammering the endpoint again.
+func (gsp *GoogleServiceProvider) PublicCertificates(ctx context.Context) (map[string]string, error) {
+	now := gsp.clock().Now()
+
+	cache := gsp.localCache()
+	if v, ok := cache.Get(certsCacheKey); ok {
+		entry := v.(*certsCacheEntry)
+		if now.Before(entry.validUntil) {
+			if entry.certs != nil {
+				return entry.certs, nil
+			}
+			return nil, entry.err
+		}
+		return gsp.refetchCertificates(ctx, cache, entry)
+	}
+
+	return gsp.refetchCertificates(ctx, cache, nil)
+}
+
+func (gsp *GoogleServiceProvider) refetchCertificates(ctx context.Context, cache ProcessCacheGetOrCreator, prev *certsCacheEntry) (map[string]string, error) {
+	certs, err := gsp.Certs.FetchCertificates(ctx)
+	now := gsp.clock().Now()
+
+	if err != nil {
+		backoff := initialCertBackoff
+		if prev != nil && prev.backoff > 0 {
+			backoff = prev.backoff * certBackoffMultiplier
+			if backoff > maxCertBackoff {
+				backoff = maxCertBackoff
+			}
+		}
+		var lastGood map[string]string
+		if prev != nil {
+			lastGood = prev.certs
+		}
+		cache.Put(certsCacheKey, &certsCacheEntry{
+			certs:      lastGood,
+			err:        err,
+			validUntil: now.Add(backoff),
+			backoff:    backoff,
+		})
+		if lastGood != nil {
+			return lastGood, nil
+		}
+		return nil, err
+	}
+
+	cache.Put(certsCacheKey, &certsCacheEntry{certs: certs, validUntil: now.Add(certsSuccessTTL)})
+	return certs, nil
+}
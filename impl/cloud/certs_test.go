@@ -0,0 +1,117 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luci/gae/common/clock/testclock"
+)
+
+type fakeCertFetcher struct {
+	mu      sync.Mutex
+	calls   int
+	failing bool
+	certs   map[string]string
+}
+
+func (f *fakeCertFetcher) FetchCertificates(ctx context.Context) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.failing {
+		return nil, errors.New("x509 endpoint unavailable")
+	}
+	return f.certs, nil
+}
+
+func (f *fakeCertFetcher) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func TestPublicCertificatesBackoffAndRecovery(t *testing.T) {
+	clk := testclock.New(time.Unix(0, 0))
+	fetcher := &fakeCertFetcher{certs: map[string]string{"k1": "pem1"}}
+	gsp := &GoogleServiceProvider{Certs: fetcher, Clock: clk}
+
+	certs, err := gsp.PublicCertificates(context.Background())
+	if err != nil || certs["k1"] != "pem1" {
+		t.Fatalf("initial fetch: certs=%v err=%v", certs, err)
+	}
+
+	fetcher.mu.Lock()
+	fetcher.failing = true
+	fetcher.mu.Unlock()
+
+	// The successful fetch is still within its TTL, so calls shouldn't
+	// reach the (now failing) endpoint yet.
+	for i := 0; i < 3; i++ {
+		certs, err := gsp.PublicCertificates(context.Background())
+		if err != nil || certs["k1"] != "pem1" {
+			t.Fatalf("call %d before TTL expiry: certs=%v err=%v", i, certs, err)
+		}
+	}
+	if fetcher.callCount() != 1 {
+		t.Errorf("fetcher called %d times before TTL expiry, want 1", fetcher.callCount())
+	}
+
+	// Once the TTL expires, the first refetch happens and fails; it
+	// should still serve the last-known-good certs.
+	clk.Add(certsSuccessTTL + time.Second)
+	certs, err = gsp.PublicCertificates(context.Background())
+	if err != nil || certs["k1"] != "pem1" {
+		t.Fatalf("first failed refetch: certs=%v err=%v", certs, err)
+	}
+	if fetcher.callCount() != 2 {
+		t.Fatalf("fetcher called %d times, want 2", fetcher.callCount())
+	}
+
+	// Repeated calls within the (short) backoff window must not call the
+	// fetcher again.
+	for i := 0; i < 5; i++ {
+		if _, err := gsp.PublicCertificates(context.Background()); err != nil {
+			t.Fatalf("call %d during backoff: %v", i, err)
+		}
+	}
+	if fetcher.callCount() != 2 {
+		t.Errorf("fetcher called %d times during backoff window, want 2", fetcher.callCount())
+	}
+
+	// Advancing past the backoff triggers one more failed attempt, and
+	// the backoff grows.
+	clk.Add(initialCertBackoff + time.Millisecond)
+	if _, err := gsp.PublicCertificates(context.Background()); err != nil {
+		t.Fatalf("retry after backoff: %v", err)
+	}
+	if fetcher.callCount() != 3 {
+		t.Errorf("fetcher called %d times after backoff elapsed, want 3", fetcher.callCount())
+	}
+
+	// Recovery: once the endpoint comes back and the grown backoff
+	// elapses, the next retry should succeed and clear the error.
+	fetcher.mu.Lock()
+	fetcher.failing = false
+	fetcher.mu.Unlock()
+
+	clk.Add(maxCertBackoff)
+	certs, err = gsp.PublicCertificates(context.Background())
+	if err != nil || certs["k1"] != "pem1" {
+		t.Fatalf("after recovery: certs=%v err=%v", certs, err)
+	}
+	if fetcher.callCount() != 4 {
+		t.Errorf("fetcher called %d times after recovery, want 4", fetcher.callCount())
+	}
+}
+
+func TestPublicCertificatesFailsClosedWithNoPriorSuccess(t *testing.T) {
+	fetcher := &fakeCertFetcher{failing: true}
+	gsp := &GoogleServiceProvider{Certs: fetcher}
+
+	if _, err := gsp.PublicCertificates(context.Background()); err == nil {
+		t.Fatalf("expected an error when there is no last-known-good cert set")
+	}
+}
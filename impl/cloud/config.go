@@ -0,0 +1,122 @@
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	gclogging "cloud.google.com/go/logging"
+	ds "github.com/luci/gae/service/datastore"
+	mc "github.com/luci/gae/service/memcache"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+// Config wires the cloud service backends into a context.Context. Each
+// service field, if populated, is installed by Use; otherwise (or if the
+// matching Disable flag is set) a panicking dummy is installed instead,
+// so that a handler touching a service it isn't configured for — or was
+// deliberately fenced off from — fails loudly rather than silently
+// reaching a live backend.
+type Config struct {
+	ProjectID string
+
+	// Datastore and Memcache are the already-constructed backends to
+	// install. Leaving one nil has the same effect as setting the
+	// matching Disable flag.
+	Datastore ds.RawInterface
+	Memcache  mc.RawInterface
+
+	// DisableDatastore and DisableMemcache force the panicking dummy to
+	// be installed even if the matching field above is populated. This
+	// lets a specific request path guarantee it never touches a service,
+	// regardless of what the caller configured.
+	DisableDatastore bool
+	DisableMemcache  bool
+
+	// LogSink, if set, receives every log entry written via Log during a
+	// request that's at or above MinCloudSeverity. See Handle.
+	LogSink CloudLogSink
+
+	// MinCloudSeverity suppresses, for cost, forwarding entries below it
+	// to LogSink; LogSeverityTracker still observes them, so
+	// CurrentLogSeverity remains accurate regardless of this setting.
+	MinCloudSeverity gclogging.Severity
+
+	// TraceSampler, if set, is consulted by Handle to decide whether an
+	// incoming request (that doesn't already carry a forced-sampled
+	// X-Cloud-Trace-Context header) gets a trace span at all. Unsampled
+	// requests skip StartTrace/EndTrace entirely. Nil means never sample
+	// on our own initiative.
+	TraceSampler func(*http.Request) bool
+
+	// RequestLogger and DebugLogger are the (optionally buffered)
+	// logging clients Flush ensures are delivered before a short-lived
+	// process exits. Either may be left nil.
+	RequestLogger Flusher
+	DebugLogger   Flusher
+
+	// ServiceName and VersionName identify this deployment (an App
+	// Engine module/version or equivalent) for the default LogResource.
+	ServiceName string
+	VersionName string
+
+	// LogResource overrides the MonitoredResource attached to every
+	// entry Log sends to LogSink. If nil, a gae_app resource is derived
+	// from ProjectID/ServiceName/VersionName.
+	LogResource *mrpb.MonitoredResource
+
+	// LogLabels are merged onto every entry Log sends to LogSink, in
+	// addition to LogResource's own labels.
+	LogLabels map[string]string
+
+	// RequiredInfoFields overrides which deployment-identity fields
+	// Validate requires to be populated. Nil means
+	// DefaultRequiredInfoFields.
+	RequiredInfoFields []InfoField
+
+	// DeadlineMargin overrides DefaultDeadlineMargin for
+	// WithRequestDeadline/Handle.
+	DeadlineMargin time.Duration
+}
+
+// Flusher is implemented by logging clients (such as
+// *cloud.google.com/go/logging.Logger) that buffer entries and need an
+// explicit Flush before process exit to guarantee delivery.
+type Flusher interface {
+	Flush() error
+}
+
+// Flush flushes c.RequestLogger and c.DebugLogger, if set, so that a
+// short-lived process (e.g. a cron task) can guarantee their buffered
+// entries are delivered before it exits. Both are flushed even if one
+// errors; Flush returns the first error encountered, if any.
+func (c *Config) Flush(ctx context.Context) error {
+	var firstErr error
+	for _, l := range []Flusher{c.RequestLogger, c.DebugLogger} {
+		if l == nil {
+			continue
+		}
+		if err := l.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Use installs this Config's services into ctx and returns the derived
+// context.
+func (c *Config) Use(ctx context.Context) context.Context {
+	if c.DisableDatastore || c.Datastore == nil {
+		ctx = ds.SetRaw(ctx, ds.Dummy("datastore: disabled by impl/cloud.Config"))
+	} else {
+		ctx = ds.SetRaw(ctx, c.Datastore)
+	}
+
+	if c.DisableMemcache || c.Memcache == nil {
+		ctx = mc.SetRaw(ctx, mc.Dummy("memcache: disabled by impl/cloud.Config"))
+	} else {
+		ctx = mc.SetRaw(ctx, c.Memcache)
+	}
+
+	return ctx
+}
@@ -0,0 +1,66 @@
+package cloud
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+	mc "github.com/luci/gae/service/memcache"
+)
+
+func assertPanics(t *testing.T, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic, got none")
+		}
+	}()
+	fn()
+}
+
+func TestConfigDisableDatastore(t *testing.T) {
+	cfg := &Config{
+		ProjectID:        "proj",
+		Datastore:        memory.NewDatastore("proj"),
+		Memcache:         memory.NewMemcache(),
+		DisableDatastore: true,
+	}
+	ctx := cfg.Use(context.Background())
+
+	assertPanics(t, func() {
+		k := ds.NewKey("proj", "", "Widget", "a", 0, nil)
+		ds.Raw(ctx).GetMulti([]*ds.Key{k}, []ds.PropertyMap{{}})
+	})
+
+	if _, err := mc.Raw(ctx).GetMulti([]string{"a"}); err != nil {
+		t.Errorf("memcache should remain usable, got error: %v", err)
+	}
+}
+
+func TestConfigDisableMemcache(t *testing.T) {
+	cfg := &Config{
+		ProjectID:       "proj",
+		Datastore:       memory.NewDatastore("proj"),
+		Memcache:        memory.NewMemcache(),
+		DisableMemcache: true,
+	}
+	ctx := cfg.Use(context.Background())
+
+	assertPanics(t, func() {
+		mc.Raw(ctx).GetMulti([]string{"a"})
+	})
+}
+
+func TestConfigUnconfiguredServiceIsDummy(t *testing.T) {
+	cfg := &Config{ProjectID: "proj"}
+	ctx := cfg.Use(context.Background())
+
+	assertPanics(t, func() {
+		k := ds.NewKey("proj", "", "Widget", "a", 0, nil)
+		ds.Raw(ctx).GetMulti([]*ds.Key{k}, []ds.PropertyMap{{}})
+	})
+	assertPanics(t, func() {
+		mc.Raw(ctx).GetMulti([]string{"a"})
+	})
+}
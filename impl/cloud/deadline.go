@@ -0,0 +1,68 @@
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultDeadlineMargin is how far before the incoming request's
+// deadline WithRequestDeadline/Handle cuts off the derived
+// context.Context, reserving time for the response to actually be
+// written once our own logic stops doing work.
+const DefaultDeadlineMargin = 500 * time.Millisecond
+
+func (c *Config) deadlineMargin() time.Duration {
+	if c.DeadlineMargin > 0 {
+		return c.DeadlineMargin
+	}
+	return DefaultDeadlineMargin
+}
+
+// requestTimeoutHeader mirrors the legacy first-generation App Engine
+// runtime's X-AppEngine-TimeoutMs header, the remaining request budget
+// in milliseconds as seen by the frontend that dispatched the request.
+// Note this package talks to Cloud Datastore/Memcache directly rather
+// than through the classic runtime, so nothing sets this header for us
+// in production; WithRequestDeadline/Handle degrade to a no-op deadline
+// when it's absent, which callers not fronted by that runtime can rely
+// on.
+const requestTimeoutHeader = "X-AppEngine-TimeoutMs"
+
+// parseRequestTimeout reads header (as found on an *http.Request) and
+// reports the remaining request budget it encodes, if any.
+func parseRequestTimeout(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || ms < 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// WithRequestDeadline returns a context derived from ctx with a deadline
+// set c.deadlineMargin() before r's remaining request budget runs out,
+// so that any RPC client reading ctx's deadline (datastore/memcache
+// clients built on top of grpc, in particular) can bail out of further
+// work while there's
+// still time left to respond. If r carries no recognizable deadline
+// (requestTimeoutHeader missing or unparseable — true of every request
+// in this package's own test suite, and of any deployment not fronted by
+// the classic App Engine runtime), ctx is returned unchanged and ok is
+// false.
+//
+// The caller must arrange for the returned CancelFunc to run (Handle
+// does this with a defer); a context.WithDeadline that's never canceled
+// leaks its internal timer until the deadline passes on its own.
+func WithRequestDeadline(ctx context.Context, c *Config, r *http.Request) (context.Context, context.CancelFunc, bool) {
+	remaining, ok := parseRequestTimeout(r.Header.Get(requestTimeoutHeader))
+	if !ok {
+		return ctx, func() {}, false
+	}
+	deadline := time.Now().Add(remaining - c.deadlineMargin())
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	return ctx, cancel, true
+}
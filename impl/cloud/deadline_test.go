@@ -0,0 +1,81 @@
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithRequestDeadlineSetsDeadlineFromHeader(t *testing.T) {
+	cfg := &Config{DeadlineMargin: 200 * time.Millisecond}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(requestTimeoutHeader, "1000")
+
+	before := time.Now()
+	ctx, cancel, ok := WithRequestDeadline(context.Background(), cfg, r)
+	defer cancel()
+	if !ok {
+		t.Fatalf("ok = false, want true")
+	}
+
+	dl, has := ctx.Deadline()
+	if !has {
+		t.Fatalf("returned context has no deadline")
+	}
+
+	want := before.Add(800 * time.Millisecond)
+	if d := dl.Sub(want); d < -50*time.Millisecond || d > 50*time.Millisecond {
+		t.Errorf("deadline = %v, want close to %v (remaining 1000ms - 200ms margin)", dl, want)
+	}
+}
+
+func TestWithRequestDeadlineDefaultsMargin(t *testing.T) {
+	cfg := &Config{}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(requestTimeoutHeader, "1000")
+
+	before := time.Now()
+	ctx, cancel, ok := WithRequestDeadline(context.Background(), cfg, r)
+	defer cancel()
+	if !ok {
+		t.Fatalf("ok = false, want true")
+	}
+
+	dl, _ := ctx.Deadline()
+	want := before.Add(1000*time.Millisecond - DefaultDeadlineMargin)
+	if d := dl.Sub(want); d < -50*time.Millisecond || d > 50*time.Millisecond {
+		t.Errorf("deadline = %v, want close to %v", dl, want)
+	}
+}
+
+func TestWithRequestDeadlineNoHeaderIsNoop(t *testing.T) {
+	cfg := &Config{}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	ctx, cancel, ok := WithRequestDeadline(context.Background(), cfg, r)
+	defer cancel()
+	if ok {
+		t.Fatalf("ok = true, want false without %s set", requestTimeoutHeader)
+	}
+	if _, has := ctx.Deadline(); has {
+		t.Errorf("expected no deadline on the returned context")
+	}
+}
+
+func TestHandleInstallsRequestDeadline(t *testing.T) {
+	cfg := &Config{DeadlineMargin: 0}
+	var hasDeadline bool
+	handler := cfg.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline = r.Context().Deadline()
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(requestTimeoutHeader, "5000")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !hasDeadline {
+		t.Errorf("expected Handle to install a deadline from %s", requestTimeoutHeader)
+	}
+}
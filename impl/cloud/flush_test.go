@@ -0,0 +1,50 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeFlusher struct {
+	flushed bool
+	err     error
+}
+
+func (f *fakeFlusher) Flush() error {
+	f.flushed = true
+	return f.err
+}
+
+func TestFlushFlushesBothLoggers(t *testing.T) {
+	req := &fakeFlusher{}
+	dbg := &fakeFlusher{}
+	cfg := &Config{RequestLogger: req, DebugLogger: dbg}
+
+	if err := cfg.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if !req.flushed || !dbg.flushed {
+		t.Errorf("RequestLogger.flushed=%v DebugLogger.flushed=%v, want both true", req.flushed, dbg.flushed)
+	}
+}
+
+func TestFlushHandlesNilLoggers(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush with nil loggers: %v", err)
+	}
+}
+
+func TestFlushFlushesBothEvenIfOneErrors(t *testing.T) {
+	req := &fakeFlusher{err: errors.New("request logger failed")}
+	dbg := &fakeFlusher{}
+	cfg := &Config{RequestLogger: req, DebugLogger: dbg}
+
+	if err := cfg.Flush(context.Background()); err == nil {
+		t.Fatalf("expected Flush to return the RequestLogger error")
+	}
+	if !dbg.flushed {
+		t.Errorf("DebugLogger should still be flushed when RequestLogger errors")
+	}
+}
@@ -0,0 +1,47 @@
+package cloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gclogging "cloud.google.com/go/logging"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+func TestLogEntriesGetDefaultGAEResource(t *testing.T) {
+	sink := &recordingLogSink{}
+	cfg := &Config{LogSink: sink, ProjectID: "proj", ServiceName: "default", VersionName: "v1"}
+
+	handler := cfg.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Log(r.Context(), gclogging.Info, "hi")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(sink.entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(sink.entries))
+	}
+	res := sink.entries[0].Resource
+	if res.Type != "gae_app" || res.Labels["project_id"] != "proj" || res.Labels["module_id"] != "default" || res.Labels["version_id"] != "v1" {
+		t.Errorf("unexpected default resource: %+v", res)
+	}
+}
+
+func TestLogEntriesUseCustomResourceAndLabels(t *testing.T) {
+	sink := &recordingLogSink{}
+	custom := &mrpb.MonitoredResource{Type: "generic_task", Labels: map[string]string{"job": "reaper"}}
+	cfg := &Config{LogSink: sink, LogResource: custom, LogLabels: map[string]string{"team": "storage"}}
+
+	handler := cfg.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Log(r.Context(), gclogging.Info, "hi")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	entry := sink.entries[0]
+	if entry.Resource != custom {
+		t.Errorf("Resource = %+v, want the configured custom resource", entry.Resource)
+	}
+	if entry.Labels["team"] != "storage" {
+		t.Errorf("Labels = %v, want team=storage", entry.Labels)
+	}
+}
@@ -0,0 +1,141 @@
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	gclogging "cloud.google.com/go/logging"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+// CloudLogSink sends a log entry to its backing store (e.g. Stackdriver).
+// Config.LogSink, if set, is consulted by Log for every entry at or
+// above Config.MinCloudSeverity.
+type CloudLogSink interface {
+	Log(entry gclogging.Entry)
+}
+
+// defaultLogResource derives a gae_app MonitoredResource from c's
+// ProjectID/ServiceName/VersionName, used when c.LogResource is unset.
+func (c *Config) defaultLogResource() *mrpb.MonitoredResource {
+	return &mrpb.MonitoredResource{
+		Type: "gae_app",
+		Labels: map[string]string{
+			"project_id": c.ProjectID,
+			"module_id":  c.ServiceName,
+			"version_id": c.VersionName,
+		},
+	}
+}
+
+func (c *Config) logResource() *mrpb.MonitoredResource {
+	if c.LogResource != nil {
+		return c.LogResource
+	}
+	return c.defaultLogResource()
+}
+
+// LogSeverityTracker observes the severity of every log entry written
+// during a request (via Log) and remembers the highest one seen, so that
+// request-level code can react to it after the fact (e.g. force-flush a
+// profiler on errors). It observes every entry Log is called with,
+// regardless of Config.MinCloudSeverity, so the aggregate severity stays
+// accurate even when low-severity entries are dropped before reaching
+// CloudLogSink.
+type LogSeverityTracker struct {
+	mu  sync.Mutex
+	max gclogging.Severity
+}
+
+func newLogSeverityTracker() *LogSeverityTracker {
+	return &LogSeverityTracker{max: gclogging.Default}
+}
+
+func (t *LogSeverityTracker) observe(s gclogging.Severity) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s > t.max {
+		t.max = s
+	}
+}
+
+func (t *LogSeverityTracker) current() gclogging.Severity {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.max
+}
+
+// requestLogState is the per-request logging state Handle installs into
+// the request context.
+type requestLogState struct {
+	tracker     *LogSeverityTracker
+	sink        CloudLogSink
+	minSeverity gclogging.Severity
+	resource    *mrpb.MonitoredResource
+	labels      map[string]string
+}
+
+type logStateKeyType struct{}
+
+var logStateKey logStateKeyType
+
+// CurrentLogSeverity returns the highest severity logged so far, via Log,
+// during the request ctx belongs to. It returns logging.Default if
+// nothing has been logged yet, or if ctx wasn't derived from one Handle
+// set up.
+func CurrentLogSeverity(ctx context.Context) gclogging.Severity {
+	st, ok := ctx.Value(logStateKey).(*requestLogState)
+	if !ok {
+		return gclogging.Default
+	}
+	return st.tracker.current()
+}
+
+// Log records a log entry at the given severity for the request ctx
+// belongs to. It always updates the installed LogSeverityTracker (if
+// any), and forwards the entry to Config.LogSink only if severity is at
+// or above Config.MinCloudSeverity, so that low-severity logging can be
+// suppressed for cost without losing aggregate-severity accuracy.
+func Log(ctx context.Context, severity gclogging.Severity, payload interface{}) {
+	st, ok := ctx.Value(logStateKey).(*requestLogState)
+	if !ok {
+		return
+	}
+	st.tracker.observe(severity)
+	if st.sink != nil && severity >= st.minSeverity {
+		st.sink.Log(gclogging.Entry{
+			Severity: severity,
+			Payload:  payload,
+			Resource: st.resource,
+			Labels:   st.labels,
+		})
+	}
+}
+
+// Handle wraps next with the per-request context setup impl/cloud's
+// services rely on: a LogSeverityTracker, filtering needed for Log to
+// honor c.MinCloudSeverity, and (for sampled requests) a trace span.
+func (c *Config) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st := &requestLogState{
+			tracker:     newLogSeverityTracker(),
+			sink:        c.LogSink,
+			minSeverity: c.MinCloudSeverity,
+			resource:    c.logResource(),
+			labels:      c.LogLabels,
+		}
+		ctx := context.WithValue(r.Context(), logStateKey, st)
+
+		traceID, forceSampled := parseCloudTraceContext(r.Header.Get("X-Cloud-Trace-Context"))
+		if c.sample(r, forceSampled) {
+			ctx = c.StartTrace(ctx, traceID)
+			defer c.EndTrace(ctx)
+		}
+
+		ctx, cancel, _ := WithRequestDeadline(ctx, c, r)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,65 @@
+package cloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gclogging "cloud.google.com/go/logging"
+)
+
+func TestCurrentLogSeverityReflectsMax(t *testing.T) {
+	cfg := &Config{}
+
+	var got gclogging.Severity
+	handler := cfg.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		Log(ctx, gclogging.Info, "starting")
+		Log(ctx, gclogging.Error, "something went wrong")
+		Log(ctx, gclogging.Debug, "retrying")
+		got = CurrentLogSeverity(ctx)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got != gclogging.Error {
+		t.Errorf("CurrentLogSeverity = %v, want %v", got, gclogging.Error)
+	}
+}
+
+func TestCurrentLogSeverityDefaultsWithoutHandle(t *testing.T) {
+	if got := CurrentLogSeverity(httptest.NewRequest(http.MethodGet, "/", nil).Context()); got != gclogging.Default {
+		t.Errorf("CurrentLogSeverity outside Handle = %v, want %v", got, gclogging.Default)
+	}
+}
+
+type recordingLogSink struct {
+	entries []gclogging.Entry
+}
+
+func (s *recordingLogSink) Log(entry gclogging.Entry) {
+	s.entries = append(s.entries, entry)
+}
+
+func TestMinCloudSeverityFiltersSinkButNotTracker(t *testing.T) {
+	sink := &recordingLogSink{}
+	cfg := &Config{LogSink: sink, MinCloudSeverity: gclogging.Warning}
+
+	var got gclogging.Severity
+	handler := cfg.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		Log(ctx, gclogging.Debug, "ignored for cost")
+		Log(ctx, gclogging.Info, "also ignored")
+		Log(ctx, gclogging.Error, "sent")
+		got = CurrentLogSeverity(ctx)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(sink.entries) != 1 || sink.entries[0].Severity != gclogging.Error {
+		t.Errorf("sink entries = %v, want [Error]", sink.entries)
+	}
+	if got != gclogging.Error {
+		t.Errorf("CurrentLogSeverity = %v, want %v (tracker must see entries the sink dropped)", got, gclogging.Error)
+	}
+}
@@ -0,0 +1,149 @@
+// Package cloud wires the Cloud Datastore/Memcache/Logging backends into
+// a context.Context, mirroring impl/memory's Use() pattern but backed by
+// real GCP services.
+package cloud
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// ProcessCache is a process-wide, in-memory cache shared across requests
+// (e.g. for OAuth2 tokens or service-account certificates). Get/Put are
+// the baseline; GetOrCreate is optional single-flight support that
+// implementations may provide to avoid cache stampedes.
+type ProcessCache interface {
+	Get(key string) (interface{}, bool)
+	Put(key string, value interface{})
+}
+
+// ProcessCacheGetOrCreator is implemented by ProcessCache backends that
+// support single-flight population: concurrent GetOrCreate calls for the
+// same key block on one call to fn rather than all invoking it.
+type ProcessCacheGetOrCreator interface {
+	ProcessCache
+	GetOrCreate(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error)
+}
+
+// GetOrCreate looks up key in cache, calling fn to populate it on a miss.
+// If cache implements ProcessCacheGetOrCreator, its single-flight
+// GetOrCreate is used so that concurrent misses for the same key only
+// invoke fn once. Otherwise this falls back to a plain Get/Put shim,
+// which does not protect against stampedes.
+func GetOrCreate(ctx context.Context, cache ProcessCache, key string, fn func() (interface{}, error)) (interface{}, error) {
+	if sf, ok := cache.(ProcessCacheGetOrCreator); ok {
+		return sf.GetOrCreate(ctx, key, fn)
+	}
+	if v, ok := cache.Get(key); ok {
+		return v, nil
+	}
+	v, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	cache.Put(key, v)
+	return v, nil
+}
+
+// lruProcessCache is a fixed-capacity, single-flight ProcessCache backed
+// by an LRU eviction policy.
+type lruProcessCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	inflight map[string]*inflightCall
+}
+
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+type inflightCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// NewLRUProcessCache returns a ProcessCacheGetOrCreator backed by an LRU
+// of the given capacity (number of entries).
+func NewLRUProcessCache(capacity int) ProcessCacheGetOrCreator {
+	return &lruProcessCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    map[string]*list.Element{},
+		inflight: map[string]*inflightCall{},
+	}
+}
+
+func (c *lruProcessCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+func (c *lruProcessCache) Put(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.putLocked(key, value)
+}
+
+func (c *lruProcessCache) putLocked(key string, value interface{}) {
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruEntry).value = value
+		c.ll.MoveToFront(el)
+		return
+	}
+	el := c.ll.PushFront(&lruEntry{key: key, value: value})
+	c.items[key] = el
+	if c.capacity > 0 {
+		for c.ll.Len() > c.capacity {
+			oldest := c.ll.Back()
+			if oldest == nil {
+				break
+			}
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+}
+
+// GetOrCreate returns the cached value for key, or calls fn to populate
+// it. Concurrent callers for the same key share a single call to fn.
+func (c *lruProcessCache) GetOrCreate(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		v := el.Value.(*lruEntry).value
+		c.mu.Unlock()
+		return v, nil
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+
+	call := &inflightCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.value, call.err = fn()
+	close(call.done)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		c.putLocked(key, call.value)
+	}
+	c.mu.Unlock()
+
+	return call.value, call.err
+}
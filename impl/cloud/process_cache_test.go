@@ -0,0 +1,50 @@
+package cloud
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLRUProcessCacheGetOrCreateSingleFlight(t *testing.T) {
+	cache := NewLRUProcessCache(16)
+
+	var calls int32
+	fn := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "value", nil
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			v, err := cache.GetOrCreate(context.Background(), "k", fn)
+			if err != nil || v != "value" {
+				t.Errorf("GetOrCreate() = %v, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fn called %d times, want 1", got)
+	}
+}
+
+func TestLRUProcessCacheEviction(t *testing.T) {
+	cache := NewLRUProcessCache(2)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Put("c", 3) // evicts "a"
+
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("expected \"a\" to be evicted")
+	}
+	if v, ok := cache.Get("b"); !ok || v != 2 {
+		t.Errorf("Get(b) = %v, %v, want 2, true", v, ok)
+	}
+}
@@ -0,0 +1,110 @@
+package cloud
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luci/gae/common/clock"
+)
+
+// TokenFetcher mints a fresh OAuth2 access token for the given scopes,
+// e.g. by calling the metadata server or a service account key flow.
+type TokenFetcher interface {
+	FetchAccessToken(ctx context.Context, scopes []string) (token string, expiry time.Time, err error)
+}
+
+type cachedToken struct {
+	token  string
+	expiry time.Time
+}
+
+// GoogleServiceProvider mints and caches OAuth2 access tokens obtained
+// from Fetcher, keyed by their normalized scope set. Tokens are always
+// cached in-process; setting ShareTokens additionally consults and
+// populates SharedCache so that multiple instances of a multi-instance
+// deployment can reuse one instance's token within its validity window
+// instead of each minting its own.
+//
+// ShareTokens defaults to false: storing credentials in a cache shared
+// across processes (e.g. memcache) is a deliberate security trade-off
+// that callers must opt into explicitly.
+type GoogleServiceProvider struct {
+	Fetcher TokenFetcher
+	Certs   CertFetcher
+
+	SharedCache ProcessCache
+	ShareTokens bool
+
+	// Clock sources the time PublicCertificates uses to drive its fetch
+	// backoff. Nil means the real wall clock; tests can set a
+	// testclock.TestClock to assert retry frequency without sleeping.
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	local ProcessCacheGetOrCreator
+}
+
+// scopeKey normalizes scopes (order-independent) into a cache key.
+func scopeKey(scopes []string) string {
+	cp := append([]string(nil), scopes...)
+	sort.Strings(cp)
+	return strings.Join(cp, " ")
+}
+
+func (gsp *GoogleServiceProvider) localCache() ProcessCacheGetOrCreator {
+	gsp.mu.Lock()
+	defer gsp.mu.Unlock()
+	if gsp.local == nil {
+		gsp.local = NewLRUProcessCache(64)
+	}
+	return gsp.local
+}
+
+// AccessToken returns a valid OAuth2 access token for scopes, minting and
+// caching one via Fetcher if neither the in-process cache nor (if
+// enabled) SharedCache already has a live one.
+func (gsp *GoogleServiceProvider) AccessToken(ctx context.Context, scopes ...string) (string, error) {
+	key := scopeKey(scopes)
+	v, err := gsp.localCache().GetOrCreate(ctx, key, func() (interface{}, error) {
+		if gsp.ShareTokens && gsp.SharedCache != nil {
+			if v, ok := gsp.SharedCache.Get(key); ok {
+				if tok, ok := v.(cachedToken); ok && time.Now().Before(tok.expiry) {
+					return tok, nil
+				}
+			}
+		}
+
+		token, expiry, err := gsp.Fetcher.FetchAccessToken(ctx, scopes)
+		if err != nil {
+			return nil, err
+		}
+		tok := cachedToken{token: token, expiry: expiry}
+		if gsp.ShareTokens && gsp.SharedCache != nil {
+			gsp.SharedCache.Put(key, tok)
+		}
+		return tok, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(cachedToken).token, nil
+}
+
+// Warmup proactively fetches and caches an access token for scopes and
+// the public certificates, so that the first real request against gsp
+// doesn't pay their latency. It's intended to be called from a startup
+// or warmup handler (e.g. App Engine's /_ah/warmup).
+func (gsp *GoogleServiceProvider) Warmup(ctx context.Context, scopes ...string) error {
+	if _, err := gsp.AccessToken(ctx, scopes...); err != nil {
+		return err
+	}
+	if gsp.Certs != nil {
+		if _, err := gsp.PublicCertificates(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cloud
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeTokenFetcher struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeTokenFetcher) FetchAccessToken(ctx context.Context, scopes []string) (string, time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return "tok", time.Now().Add(time.Hour), nil
+}
+
+// mapProcessCache is a plain, non-single-flight ProcessCache backed by a
+// map, standing in for a real memcache-backed shared cache in tests.
+type mapProcessCache struct {
+	mu sync.Mutex
+	m  map[string]interface{}
+}
+
+func newMapProcessCache() *mapProcessCache { return &mapProcessCache{m: map[string]interface{}{}} }
+
+func (c *mapProcessCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.m[key]
+	return v, ok
+}
+
+func (c *mapProcessCache) Put(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[key] = value
+}
+
+func TestGoogleServiceProviderSharesTokenAcrossInstances(t *testing.T) {
+	fetcher := &fakeTokenFetcher{}
+	shared := newMapProcessCache()
+
+	first := &GoogleServiceProvider{Fetcher: fetcher, SharedCache: shared, ShareTokens: true}
+	tok1, err := first.AccessToken(context.Background(), "scope-a", "scope-b")
+	if err != nil {
+		t.Fatalf("first.AccessToken: %v", err)
+	}
+
+	second := &GoogleServiceProvider{Fetcher: fetcher, SharedCache: shared, ShareTokens: true}
+	tok2, err := second.AccessToken(context.Background(), "scope-b", "scope-a") // different order, same set
+	if err != nil {
+		t.Fatalf("second.AccessToken: %v", err)
+	}
+
+	if tok1 != tok2 {
+		t.Errorf("tokens differ: %q vs %q", tok1, tok2)
+	}
+	if fetcher.calls != 1 {
+		t.Errorf("Fetcher called %d times, want 1 (second provider should reuse the shared token)", fetcher.calls)
+	}
+}
+
+func TestGoogleServiceProviderWithoutSharingMintsPerInstance(t *testing.T) {
+	fetcher := &fakeTokenFetcher{}
+	shared := newMapProcessCache()
+
+	first := &GoogleServiceProvider{Fetcher: fetcher, SharedCache: shared} // ShareTokens left false
+	if _, err := first.AccessToken(context.Background(), "scope-a"); err != nil {
+		t.Fatalf("first.AccessToken: %v", err)
+	}
+
+	second := &GoogleServiceProvider{Fetcher: fetcher, SharedCache: shared}
+	if _, err := second.AccessToken(context.Background(), "scope-a"); err != nil {
+		t.Fatalf("second.AccessToken: %v", err)
+	}
+
+	if fetcher.calls != 2 {
+		t.Errorf("Fetcher called %d times, want 2 (sharing is opt-in)", fetcher.calls)
+	}
+}
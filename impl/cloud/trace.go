@@ -0,0 +1,75 @@
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Trace represents the tracing span Handle installs into a sampled
+// request's context.
+type Trace struct {
+	TraceID string
+}
+
+type traceKeyType struct{}
+
+var traceKey traceKeyType
+
+// TraceFromContext returns the Trace installed in ctx by Handle, and
+// whether the request was sampled (and so has one).
+func TraceFromContext(ctx context.Context) (*Trace, bool) {
+	tr, ok := ctx.Value(traceKey).(*Trace)
+	return tr, ok
+}
+
+// StartTrace begins a trace span for traceID, returning the ctx it's
+// installed in. Callers must call EndTrace on the returned ctx once the
+// span is done. Starting and ending a span is the expensive part of
+// tracing a request; Handle only does it for sampled requests.
+func (c *Config) StartTrace(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceKey, &Trace{TraceID: traceID})
+}
+
+// EndTrace marks the end of the trace span installed in ctx by
+// StartTrace, if any. A Trace records only its trace ID, so EndTrace
+// exports nothing to a tracing backend.
+func (c *Config) EndTrace(ctx context.Context) {
+	// No span timing is recorded, so there is nothing to flush.
+}
+
+// parseCloudTraceContext extracts the trace ID and sampled bit from an
+// incoming X-Cloud-Trace-Context header, per the format
+// "TRACE_ID/SPAN_ID;o=OPTIONS" where bit 0x1 of OPTIONS means the
+// upstream caller wants this request sampled regardless of our own
+// TraceSampler.
+func parseCloudTraceContext(header string) (traceID string, forceSampled bool) {
+	if header == "" {
+		return "", false
+	}
+	traceID = header
+	if i := strings.IndexByte(header, '/'); i >= 0 {
+		traceID = header[:i]
+		rest := header[i+1:]
+		if j := strings.Index(rest, ";o="); j >= 0 {
+			if n, err := strconv.Atoi(rest[j+3:]); err == nil && n&1 == 1 {
+				forceSampled = true
+			}
+		}
+	}
+	return traceID, forceSampled
+}
+
+// sample decides whether r should be traced: the incoming
+// X-Cloud-Trace-Context sampled bit forces sampling; otherwise
+// c.TraceSampler is consulted, if set, and defaults to not sampling.
+func (c *Config) sample(r *http.Request, forceSampled bool) bool {
+	if forceSampled {
+		return true
+	}
+	if c.TraceSampler != nil {
+		return c.TraceSampler(r)
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package cloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleTracesOnlySampledRequests(t *testing.T) {
+	cfg := &Config{TraceSampler: func(r *http.Request) bool { return r.URL.Path == "/sampled" }}
+
+	var sampledPresent, unsampledPresent bool
+	handler := cfg.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := TraceFromContext(r.Context())
+		if r.URL.Path == "/sampled" {
+			sampledPresent = ok
+		} else {
+			unsampledPresent = ok
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/sampled", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other", nil))
+
+	if !sampledPresent {
+		t.Errorf("expected a Trace for the sampled request")
+	}
+	if unsampledPresent {
+		t.Errorf("did not expect a Trace for the unsampled request")
+	}
+}
+
+func TestHandleHonorsForcedSampling(t *testing.T) {
+	cfg := &Config{} // no TraceSampler: would never sample on its own
+
+	var present bool
+	handler := cfg.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, present = TraceFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !present {
+		t.Errorf("expected the forced-sampled header to install a Trace")
+	}
+}
+
+func TestParseCloudTraceContext(t *testing.T) {
+	cases := []struct {
+		header  string
+		traceID string
+		forced  bool
+	}{
+		{"", "", false},
+		{"105445aa7843bc8bf206b12000100000/1;o=1", "105445aa7843bc8bf206b12000100000", true},
+		{"105445aa7843bc8bf206b12000100000/1;o=0", "105445aa7843bc8bf206b12000100000", false},
+		{"105445aa7843bc8bf206b12000100000", "105445aa7843bc8bf206b12000100000", false},
+	}
+	for _, c := range cases {
+		id, forced := parseCloudTraceContext(c.header)
+		if id != c.traceID || forced != c.forced {
+			t.Errorf("parseCloudTraceContext(%q) = (%q, %v), want (%q, %v)", c.header, id, forced, c.traceID, c.forced)
+		}
+	}
+}
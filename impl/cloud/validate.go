@@ -0,0 +1,66 @@
+package cloud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InfoField names one of Config's deployment-identity fields (the ones
+// used to derive the default gae_app LogResource, and that a handler
+// calling into the App Engine info service would expect to be set).
+type InfoField string
+
+const (
+	InfoFieldProjectID   InfoField = "ProjectID"
+	InfoFieldServiceName InfoField = "ServiceName"
+	InfoFieldVersionName InfoField = "VersionName"
+)
+
+// DefaultRequiredInfoFields is used by Validate when
+// Config.RequiredInfoFields is nil.
+var DefaultRequiredInfoFields = []InfoField{
+	InfoFieldProjectID,
+	InfoFieldServiceName,
+	InfoFieldVersionName,
+}
+
+func (c *Config) infoFieldValue(f InfoField) string {
+	switch f {
+	case InfoFieldProjectID:
+		return c.ProjectID
+	case InfoFieldServiceName:
+		return c.ServiceName
+	case InfoFieldVersionName:
+		return c.VersionName
+	default:
+		return ""
+	}
+}
+
+func (c *Config) requiredInfoFields() []InfoField {
+	if c.RequiredInfoFields != nil {
+		return c.RequiredInfoFields
+	}
+	return DefaultRequiredInfoFields
+}
+
+// Validate checks that every field in c.RequiredInfoFields (or
+// DefaultRequiredInfoFields, if unset) is populated, returning a
+// descriptive error listing whichever are empty. Call it at startup so a
+// misconfigured deployment fails loudly instead of handlers hitting a
+// not-implemented error the first time they call into the info service
+// mid-request.
+func (c *Config) Validate() error {
+	var missing []string
+	for _, f := range c.requiredInfoFields() {
+		if c.infoFieldValue(f) == "" {
+			missing = append(missing, string(f))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("impl/cloud: Config missing required info field(s): %s", strings.Join(missing, ", "))
+}
@@ -0,0 +1,34 @@
+package cloud
+
+import "testing"
+
+func TestValidateFullyPopulatedConfig(t *testing.T) {
+	cfg := &Config{ProjectID: "proj", ServiceName: "default", VersionName: "v1"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate = %v, want nil", err)
+	}
+}
+
+func TestValidatePartiallyPopulatedConfig(t *testing.T) {
+	cfg := &Config{ProjectID: "proj"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate = nil, want an error listing the missing fields")
+	}
+	const want = "impl/cloud: Config missing required info field(s): ServiceName, VersionName"
+	if err.Error() != want {
+		t.Errorf("Validate error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestValidateCustomRequiredFields(t *testing.T) {
+	cfg := &Config{RequiredInfoFields: []InfoField{InfoFieldProjectID}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Validate = nil, want an error for missing ProjectID")
+	}
+
+	cfg.ProjectID = "proj"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate = %v, want nil once the custom required field is set", err)
+	}
+}
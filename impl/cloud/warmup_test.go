@@ -0,0 +1,36 @@
+package cloud
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWarmupPopulatesBothCaches(t *testing.T) {
+	tokens := &fakeTokenFetcher{}
+	certs := &fakeCertFetcher{certs: map[string]string{"k1": "pem1"}}
+	gsp := &GoogleServiceProvider{Fetcher: tokens, Certs: certs}
+
+	if err := gsp.Warmup(context.Background(), "scope-a"); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if tokens.calls != 1 {
+		t.Errorf("token fetcher called %d times, want 1", tokens.calls)
+	}
+	if certs.callCount() != 1 {
+		t.Errorf("cert fetcher called %d times, want 1", certs.callCount())
+	}
+
+	// A real request afterwards should hit the warmed caches, not refetch.
+	if _, err := gsp.AccessToken(context.Background(), "scope-a"); err != nil {
+		t.Fatalf("AccessToken: %v", err)
+	}
+	if _, err := gsp.PublicCertificates(context.Background()); err != nil {
+		t.Fatalf("PublicCertificates: %v", err)
+	}
+	if tokens.calls != 1 {
+		t.Errorf("token fetcher called %d times after warmup, want 1 (should be cached)", tokens.calls)
+	}
+	if certs.callCount() != 1 {
+		t.Errorf("cert fetcher called %d times after warmup, want 1 (should be cached)", certs.callCount())
+	}
+}
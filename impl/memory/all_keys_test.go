@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestAllKeys(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+
+	keys := []*ds.Key{
+		ds.NewKey("app", "", "Widget", "b", 0, nil),
+		ds.NewKey("app", "", "Widget", "a", 0, nil),
+		ds.NewKey("app", "", "Gadget", "z", 0, nil),
+	}
+	if _, err := raw.PutMulti(keys, []ds.PropertyMap{{}, {}, {}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	all := GetTestable(ctx).AllKeys("")
+	if len(all) != 3 {
+		t.Fatalf("AllKeys(\"\") = %d keys, want 3", len(all))
+	}
+
+	widgets := GetTestable(ctx).AllKeys("Widget")
+	if len(widgets) != 2 || widgets[0].StringID() != "a" || widgets[1].StringID() != "b" {
+		t.Fatalf("AllKeys(\"Widget\") = %v, want [a b] in order", widgets)
+	}
+
+	if err := raw.DeleteMulti([]*ds.Key{keys[0]}); err != nil {
+		t.Fatalf("DeleteMulti: %v", err)
+	}
+	widgets = GetTestable(ctx).AllKeys("Widget")
+	if len(widgets) != 1 || widgets[0].StringID() != "a" {
+		t.Fatalf("AllKeys(\"Widget\") after delete = %v, want [a]", widgets)
+	}
+}
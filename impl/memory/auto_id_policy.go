@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// AutoIDPolicy selects how the in-memory datastore assigns an ID to an
+// incomplete key passed to PutMulti. See Testable.AutoIDPolicy.
+type AutoIDPolicy int
+
+const (
+	// AutoIDPolicySequential assigns IDs 1, 2, 3, ... per kind, in the
+	// order incomplete keys of that kind are first Put. This is the
+	// default, and matches this package's historical behavior, but not
+	// production Cloud Datastore, which scatters IDs across the key
+	// space to spread load; code that assumes monotonically increasing
+	// IDs can pass tests under this policy and still break in
+	// production.
+	AutoIDPolicySequential AutoIDPolicy = iota
+
+	// AutoIDPolicyScattered assigns large, non-sequential IDs, as
+	// production does, so that tests exercising it catch assumptions
+	// about ID ordering or density that AutoIDPolicySequential would
+	// let slide.
+	AutoIDPolicyScattered
+)
+
+// scatteredIDFloor is the low end of the range AutoIDPolicyScattered draws
+// from, kept well above any ID AutoIDPolicySequential could plausibly
+// assign in a test, so switching policies can't accidentally collide with
+// IDs a test already recorded.
+const scatteredIDFloor = int64(1) << 32
+
+// scatteredIDCeil is the high end (exclusive) of the range
+// AutoIDPolicyScattered draws from; it stays under 1<<63 so every
+// generated ID is a valid, positive, complete Key ID.
+const scatteredIDCeil = int64(1) << 62
+
+// scatteredID returns a random ID in [scatteredIDFloor, scatteredIDCeil),
+// deliberately far from the low, dense range AutoIDPolicySequential uses.
+func scatteredID() int64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	span := uint64(scatteredIDCeil - scatteredIDFloor)
+	return scatteredIDFloor + int64(binary.BigEndian.Uint64(b[:])%span)
+}
+
+// allocateID returns the next ID to assign to an incomplete key of k's
+// kind, namespace and parent, per d's AutoIDPolicy. The caller must hold
+// d.mu.
+func (d *dsData) allocateID(k *ds.Key) int64 {
+	if d.autoIDPolicy != AutoIDPolicyScattered {
+		d.nextID[k.Kind()]++
+		return d.nextID[k.Kind()]
+	}
+
+	kindMap := d.byKind[k.Kind()]
+	for {
+		id := scatteredID()
+		cand := ds.NewKey(d.appID, k.Namespace(), k.Kind(), "", id, k.Parent())
+		if _, exists := kindMap[encodeKey(cand)]; !exists {
+			return id
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestAutoIDPolicySequentialIsMonotonic(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+
+	keys := []*ds.Key{
+		ds.NewKey("app", "", "Widget", "", 0, nil),
+		ds.NewKey("app", "", "Widget", "", 0, nil),
+		ds.NewKey("app", "", "Widget", "", 0, nil),
+	}
+	out, err := raw.PutMulti(keys, []ds.PropertyMap{{}, {}, {}})
+	if err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+	for i, k := range out {
+		if want := int64(i + 1); k.IntID() != want {
+			t.Errorf("out[%d].IntID() = %d, want %d", i, k.IntID(), want)
+		}
+	}
+}
+
+func TestAutoIDPolicyScatteredYieldsNonSequentialNonCollidingIDs(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+	GetTestable(ctx).AutoIDPolicy(AutoIDPolicyScattered)
+
+	const n = 20
+	keys := make([]*ds.Key, n)
+	vals := make([]ds.PropertyMap, n)
+	for i := range keys {
+		keys[i] = ds.NewKey("app", "", "Widget", "", 0, nil)
+		vals[i] = ds.PropertyMap{}
+	}
+	out, err := raw.PutMulti(keys, vals)
+	if err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	seen := map[int64]bool{}
+	sequential := true
+	for i, k := range out {
+		if k.Incomplete() {
+			t.Fatalf("out[%d] is still incomplete", i)
+		}
+		if k.IntID() < scatteredIDFloor || k.IntID() >= scatteredIDCeil {
+			t.Errorf("out[%d].IntID() = %d, want a value in [%d, %d)", i, k.IntID(), scatteredIDFloor, scatteredIDCeil)
+		}
+		if seen[k.IntID()] {
+			t.Errorf("out[%d].IntID() = %d, collides with an earlier key", i, k.IntID())
+		}
+		seen[k.IntID()] = true
+		if i > 0 && k.IntID() != out[i-1].IntID()+1 {
+			sequential = false
+		}
+	}
+	if sequential {
+		t.Errorf("IDs %v were sequential; want them scattered", out)
+	}
+}
+
+func TestAutoIDPolicyDefaultsToSequential(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+
+	k := ds.NewKey("app", "", "Widget", "", 0, nil)
+	out, err := raw.PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}})
+	if err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+	if out[0].IntID() != 1 {
+		t.Errorf("IntID() = %d, want 1 (default AutoIDPolicySequential)", out[0].IntID())
+	}
+}
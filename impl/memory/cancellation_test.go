@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestCancelledContextBeforeCall(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = Use(ctx, "app")
+	cancel()
+	raw := ds.Raw(ctx)
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := raw.PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("PutMulti after cancel: got %v, want context.Canceled", err)
+	}
+	if err := raw.GetMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetMulti after cancel: got %v, want context.Canceled", err)
+	}
+	if err := raw.DeleteMulti([]*ds.Key{k}); !errors.Is(err, context.Canceled) {
+		t.Errorf("DeleteMulti after cancel: got %v, want context.Canceled", err)
+	}
+	if err := raw.Run(ds.NewQuery("Widget"), func(*ds.Key, ds.PropertyMap) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run after cancel: got %v, want context.Canceled", err)
+	}
+	if _, err := raw.Count(ds.NewQuery("Widget")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Count after cancel: got %v, want context.Canceled", err)
+	}
+}
+
+func TestCancelledContextDuringRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = Use(ctx, "app")
+	raw := ds.Raw(ctx)
+
+	keys := make([]*ds.Key, 0, cancellationCheckInterval*3)
+	vals := make([]ds.PropertyMap, 0, cancellationCheckInterval*3)
+	for i := 0; i < cancellationCheckInterval*3; i++ {
+		keys = append(keys, ds.NewKey("app", "", "Widget", "", int64(i+1), nil))
+		vals = append(vals, ds.PropertyMap{})
+	}
+	if _, err := raw.PutMulti(keys, vals); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	seen := 0
+	err := raw.Run(ds.NewQuery("Widget"), func(*ds.Key, ds.PropertyMap) error {
+		seen++
+		if seen == cancellationCheckInterval {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run during cancellation: got %v, want context.Canceled", err)
+	}
+	if seen >= len(keys) {
+		t.Errorf("Run visited all %d entries despite cancellation partway through", len(keys))
+	}
+}
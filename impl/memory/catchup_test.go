@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestCatchUpIndexes(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+	testable := GetTestable(ctx)
+	testable.Consistent(false)
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := raw.PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	seen := countResults(t, raw, ds.NewQuery("Widget"))
+	if seen != 0 {
+		t.Fatalf("before CatchUpIndexes, non-ancestor query saw %d results, want 0", seen)
+	}
+
+	testable.CatchUpIndexes()
+
+	seen = countResults(t, raw, ds.NewQuery("Widget"))
+	if seen != 1 {
+		t.Fatalf("after CatchUpIndexes, non-ancestor query saw %d results, want 1", seen)
+	}
+}
+
+func countResults(t *testing.T, raw ds.RawInterface, q *ds.Query) int {
+	t.Helper()
+	n := 0
+	if err := raw.Run(q, func(*ds.Key, ds.PropertyMap) error {
+		n++
+		return nil
+	}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return n
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/luci/gae/common/clock"
+	ds "github.com/luci/gae/service/datastore"
+	mc "github.com/luci/gae/service/memcache"
+)
+
+// Use installs fresh, independent in-memory datastore and memcache
+// RawInterface implementations into ctx, scoped to appID. It is the
+// usual entry point for unit tests.
+func Use(ctx context.Context, appID string) context.Context {
+	return UseWithClock(ctx, appID, clock.SystemClock{})
+}
+
+// UseWithClock is like Use, but sources the installed datastore's
+// time-dependent features (see Testable.RateLimit) from clk instead of
+// the real wall clock.
+func UseWithClock(ctx context.Context, appID string, clk clock.Clock) context.Context {
+	ctx = ds.SetRaw(ctx, NewDatastoreWithClock(appID, clk))
+	ctx = mc.SetRaw(ctx, NewMemcache())
+	return ctx
+}
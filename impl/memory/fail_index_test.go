@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestFailIndexMakesRunAndCountFailForThatKind(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	GetTestable(ctx).FailIndex("Widget", true)
+
+	err := ds.Raw(ctx).Run(ds.NewQuery("Widget"), func(*ds.Key, ds.PropertyMap) error { return nil })
+	if !ds.IsIndexNotReady(err) {
+		t.Fatalf("Run err = %v, want ds.ErrIndexNotReady", err)
+	}
+
+	if _, err := ds.Raw(ctx).Count(ds.NewQuery("Widget")); !ds.IsIndexNotReady(err) {
+		t.Fatalf("Count err = %v, want ds.ErrIndexNotReady", err)
+	}
+
+	// A different kind is unaffected.
+	if err := ds.Raw(ctx).Run(ds.NewQuery("Gadget"), func(*ds.Key, ds.PropertyMap) error { return nil }); err != nil {
+		t.Fatalf("Run(Gadget): %v", err)
+	}
+}
+
+func TestFailIndexCanBeCleared(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	GetTestable(ctx).FailIndex("Widget", true)
+	GetTestable(ctx).FailIndex("Widget", false)
+
+	if err := ds.Raw(ctx).Run(ds.NewQuery("Widget"), func(*ds.Key, ds.PropertyMap) error { return nil }); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
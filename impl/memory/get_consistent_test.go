@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestGetConsistentStrongSeesJustWrittenEntityEventualMayNot(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), NewDatastore("app"))
+	GetTestable(ctx).Consistent(false)
+
+	k := ds.NewKey("app", "", "Widget", "gizmo", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Name": ds.MkProperty("gizmo")}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	strongVals := []ds.PropertyMap{{}}
+	if err := ds.GetConsistent(ctx, []*ds.Key{k}, strongVals, true); err != nil {
+		t.Fatalf("GetConsistent(strong): %v", err)
+	}
+
+	eventualVals := []ds.PropertyMap{{}}
+	err := ds.GetConsistent(ctx, []*ds.Key{k}, eventualVals, false)
+	me, ok := err.(ds.MultiError)
+	if !ok || len(me) != 1 || !errors.Is(me[0], ds.ErrNoSuchEntity) {
+		t.Fatalf("GetConsistent(eventual) err = %v, want a MultiError[ErrNoSuchEntity] while the write is still pending", err)
+	}
+
+	GetTestable(ctx).CatchUpIndexes()
+	eventualVals = []ds.PropertyMap{{}}
+	if err := ds.GetConsistent(ctx, []*ds.Key{k}, eventualVals, false); err != nil {
+		t.Fatalf("GetConsistent(eventual) after CatchUpIndexes: %v", err)
+	}
+}
+
+func TestGetConsistentPlainGetMultiAlwaysStrong(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), NewDatastore("app"))
+	GetTestable(ctx).Consistent(false)
+
+	k := ds.NewKey("app", "", "Widget", "gizmo", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Name": ds.MkProperty("gizmo")}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Errorf("plain GetMulti = %v, want it to remain strongly consistent even while Consistent(false) is set", err)
+	}
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestKindAndPropertyMetaQueries(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+
+	keys := []*ds.Key{
+		ds.NewKey("app", "", "Widget", "w1", 0, nil),
+		ds.NewKey("app", "", "Gadget", "g1", 0, nil),
+	}
+	vals := []ds.PropertyMap{
+		{"Name": "widget", "Count": int64(1)},
+		{"Owner": "alice"},
+	}
+	if _, err := raw.PutMulti(keys, vals); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	var kinds []string
+	if err := raw.Run(ds.NewQuery("__kind__"), func(k *ds.Key, _ ds.PropertyMap) error {
+		kinds = append(kinds, k.StringID())
+		return nil
+	}); err != nil {
+		t.Fatalf("Run(__kind__): %v", err)
+	}
+	if want := []string{"Gadget", "Widget"}; !equalStrings(kinds, want) {
+		t.Errorf("kinds = %v, want %v", kinds, want)
+	}
+
+	props := map[string][]string{}
+	if err := raw.Run(ds.NewQuery("__property__"), func(k *ds.Key, _ ds.PropertyMap) error {
+		props[k.Parent().StringID()] = append(props[k.Parent().StringID()], k.StringID())
+		return nil
+	}); err != nil {
+		t.Fatalf("Run(__property__): %v", err)
+	}
+	if want := []string{"Count", "Name"}; !equalStrings(props["Widget"], want) {
+		t.Errorf("Widget properties = %v, want %v", props["Widget"], want)
+	}
+	if want := []string{"Owner"}; !equalStrings(props["Gadget"], want) {
+		t.Errorf("Gadget properties = %v, want %v", props["Gadget"], want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestKindStatsOnSeededDataset(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), NewDatastore("app"))
+
+	pms := []ds.PropertyMap{
+		{"Name": ds.MkProperty("alice"), "Age": ds.MkProperty(int64(30))},
+		{"Name": ds.MkProperty("bob"), "Age": ds.MkProperty(int64(40))},
+		{"Name": ds.MkProperty("carol")},
+	}
+	keys := make([]*ds.Key, len(pms))
+	for i := range pms {
+		keys[i] = ds.NewKey("app", "", "Person", "", 0, nil)
+	}
+	if _, err := ds.Raw(ctx).PutMulti(keys, pms); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	stats, err := ds.KindStats(ctx, "Person", 0)
+	if err != nil {
+		t.Fatalf("KindStats: %v", err)
+	}
+
+	if stats.Count != 3 {
+		t.Errorf("Count = %d, want 3", stats.Count)
+	}
+	if stats.AvgSize <= 0 || stats.MaxSize <= 0 {
+		t.Errorf("AvgSize = %v, MaxSize = %v, want both > 0", stats.AvgSize, stats.MaxSize)
+	}
+	if got := stats.PropertyFrequency["Name"]; got != 1 {
+		t.Errorf("PropertyFrequency[Name] = %v, want 1 (present on every entity)", got)
+	}
+	if got := stats.PropertyFrequency["Age"]; got < 0.6 || got > 0.7 {
+		t.Errorf("PropertyFrequency[Age] = %v, want ~2/3", got)
+	}
+}
+
+func TestKindStatsEmptyKind(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), NewDatastore("app"))
+
+	stats, err := ds.KindStats(ctx, "Nonexistent", 0)
+	if err != nil {
+		t.Fatalf("KindStats: %v", err)
+	}
+	if stats.Count != 0 || stats.AvgSize != 0 || stats.MaxSize != 0 {
+		t.Errorf("stats = %+v, want all zero for an empty kind", stats)
+	}
+}
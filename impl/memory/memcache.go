@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"sync"
+
+	mc "github.com/luci/gae/service/memcache"
+)
+
+// memcacheImpl is the in-memory mc.RawInterface implementation. It
+// tracks Hits/Misses/Items/Bytes so that tests of cache-hit-ratio logic
+// have something real to assert against.
+type memcacheImpl struct {
+	mu    sync.Mutex
+	items map[string]*mc.Item
+	stats mc.Statistics
+}
+
+// NewMemcache returns a fresh, empty in-memory memcache.RawInterface.
+// Each call returns independent state.
+func NewMemcache() mc.RawInterface {
+	return &memcacheImpl{items: map[string]*mc.Item{}}
+}
+
+// MemcacheTestable is implemented by the in-memory memcache and exposes
+// test-only hooks not part of the production service interface.
+type MemcacheTestable interface {
+	// ResetStats zeroes the Hits/Misses/Items/Bytes counters, without
+	// touching stored items, so each test can assert from a clean slate.
+	ResetStats()
+}
+
+func (m *memcacheImpl) ResetStats() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = mc.Statistics{}
+}
+
+func (m *memcacheImpl) Get(key string) (*mc.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.items[key]
+	if !ok {
+		m.stats.Misses++
+		return nil, mc.ErrCacheMiss
+	}
+	m.stats.Hits++
+	return it, nil
+}
+
+func (m *memcacheImpl) GetMulti(keys []string) (map[string]*mc.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := map[string]*mc.Item{}
+	for _, k := range keys {
+		if it, ok := m.items[k]; ok {
+			out[k] = it
+			m.stats.Hits++
+		} else {
+			m.stats.Misses++
+		}
+	}
+	return out, nil
+}
+
+// Add implements mc.Adder, storing item only if its key is not already
+// present.
+func (m *memcacheImpl) Add(item *mc.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.items[item.Key()]; ok {
+		return mc.ErrNotStored
+	}
+	m.items[item.Key()] = item
+	return nil
+}
+
+func (m *memcacheImpl) SetMulti(items []*mc.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, it := range items {
+		m.items[it.Key()] = it
+	}
+	return nil
+}
+
+func (m *memcacheImpl) DeleteMulti(keys []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range keys {
+		delete(m.items, k)
+	}
+	return nil
+}
+
+func (m *memcacheImpl) Stats() (*mc.Statistics, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.Items = uint64(len(m.items))
+	var bytes uint64
+	for _, it := range m.items {
+		bytes += uint64(len(it.Value()))
+	}
+	stats.Bytes = bytes
+	return &stats, nil
+}
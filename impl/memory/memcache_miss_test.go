@@ -0,0 +1,16 @@
+package memory
+
+import (
+	"testing"
+
+	mc "github.com/luci/gae/service/memcache"
+)
+
+func TestMemcacheGetMissIsRecognized(t *testing.T) {
+	raw := NewMemcache()
+
+	_, err := raw.Get("missing")
+	if !mc.IsErrCacheMiss(err) {
+		t.Errorf("IsErrCacheMiss(%v) = false, want true", err)
+	}
+}
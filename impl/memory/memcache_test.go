@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"testing"
+
+	mc "github.com/luci/gae/service/memcache"
+)
+
+func TestMemcacheStats(t *testing.T) {
+	raw := NewMemcache()
+
+	if err := raw.SetMulti([]*mc.Item{
+		mc.NewItem("a").SetValue([]byte("hello")),
+		mc.NewItem("b").SetValue([]byte("world")),
+	}); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+
+	if _, err := raw.GetMulti([]string{"a", "missing"}); err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+
+	stats, err := raw.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want Hits=1 Misses=1", stats)
+	}
+	if stats.Items != 2 {
+		t.Errorf("stats.Items = %d, want 2", stats.Items)
+	}
+	if stats.Bytes != uint64(len("hello")+len("world")) {
+		t.Errorf("stats.Bytes = %d, want %d", stats.Bytes, len("hello")+len("world"))
+	}
+
+	raw.(MemcacheTestable).ResetStats()
+	stats, _ = raw.Stats()
+	if stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("stats after reset = %+v, want zero Hits/Misses", stats)
+	}
+	if stats.Items != 2 {
+		t.Errorf("ResetStats should not drop stored items; Items = %d, want 2", stats.Items)
+	}
+}
@@ -0,0 +1,602 @@
+// Package memory provides in-process implementations of the service
+// interfaces (service/datastore, service/memcache, ...) backed by plain
+// Go maps. It is intended for unit tests: it never touches the network
+// and its state is scoped to the value returned by its constructors.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/luci/gae/common/clock"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// defaultMaxTransactionAttempts is how many times RunInTransaction
+// retries a body that fails with ds.ErrConcurrentTransaction when the
+// caller's TransactionOptions don't specify a cap.
+const defaultMaxTransactionAttempts = 3
+
+// ErrWriteRateExceeded is returned by PutMulti for an item whose entity
+// group was written to more recently than Testable.RateLimit's
+// writesPerSecPerGroup allows, simulating production's per-entity-group
+// write throughput guideline.
+var ErrWriteRateExceeded = errors.New("datastore: write rate exceeded for entity group")
+
+type dsEntry struct {
+	key *Key
+	pm  ds.PropertyMap
+}
+
+// Key is an alias kept local to this file for readability; it is the
+// same type as datastore.Key.
+type Key = ds.Key
+
+// dsData holds the state shared by every datastoreImpl bound to it. It
+// is split out from datastoreImpl so that each call to ds.Raw(ctx) can
+// produce a lightweight wrapper bound to that call's own ctx (see
+// BindCtx) without copying or losing access to the underlying store.
+type dsData struct {
+	mu         sync.RWMutex
+	appID      string
+	byKind     map[string]map[string]dsEntry // kind -> encoded key -> entry
+	nextID     map[string]int64              // kind -> next auto ID
+	consistent bool                          // true unless Testable.Consistent(false) was called
+	pending    map[string]map[string]bool    // kind -> encoded key -> not yet visible to non-ancestor queries
+
+	clock clock.Clock
+
+	writesPerSecPerGroup float64              // 0 disables rate limiting; set by Testable.RateLimit
+	lastWrite            map[string]time.Time // encoded entity group root key -> time of its last accepted write
+
+	autoIDPolicy AutoIDPolicy // how incomplete keys are assigned IDs; set by Testable.AutoIDPolicy
+
+	notReadyKinds map[string]bool // kind -> Run/Count should fail with ds.ErrIndexNotReady; set by Testable.FailIndex
+
+	compositeIndexes []ds.CompositeIndex // set by Testable.AddIndexes; returned by Testable.Indexes
+
+	hotRoot        *Key       // entity group root simulating contention; nil disables. Set by Testable.HotKey
+	hotProbability float64    // fraction of matching transaction writes that fail with ds.ErrConcurrentTransaction
+	hotRand        *rand.Rand // source HotKey's probability draws come from; seedable via Testable.SeedHotKeyRand
+}
+
+// datastoreImpl is the in-memory ds.RawInterface implementation. It is a
+// thin, ctx-bound handle onto a shared *dsData; BindCtx produces a new
+// handle sharing the same data with a different ctx.
+type datastoreImpl struct {
+	data *dsData
+	ctx  context.Context
+}
+
+// NewDatastore returns a fresh, empty in-memory datastore.RawInterface
+// for the given appID. Each call returns independent state.
+func NewDatastore(appID string) ds.RawInterface {
+	return NewDatastoreWithClock(appID, clock.SystemClock{})
+}
+
+// NewDatastoreWithClock is like NewDatastore, but sources the time used
+// by features such as the write-rate limiter (see Testable.RateLimit)
+// from clk instead of the real wall clock, so tests can control it with
+// a testclock.TestClock.
+func NewDatastoreWithClock(appID string, clk clock.Clock) ds.RawInterface {
+	return &datastoreImpl{
+		data: &dsData{
+			appID:         appID,
+			byKind:        map[string]map[string]dsEntry{},
+			nextID:        map[string]int64{},
+			consistent:    true,
+			pending:       map[string]map[string]bool{},
+			clock:         clk,
+			lastWrite:     map[string]time.Time{},
+			notReadyKinds: map[string]bool{},
+			hotRand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		},
+		ctx: context.Background(),
+	}
+}
+
+// BindCtx returns a datastoreImpl sharing this one's underlying data but
+// scoped to ctx, so that its methods honor ctx's cancellation. Called by
+// ds.Raw for every RawInterface it hands out; see ds.CtxBinder.
+func (d *datastoreImpl) BindCtx(ctx context.Context) ds.RawInterface {
+	return &datastoreImpl{data: d.data, ctx: ctx}
+}
+
+// lock acquires d.data.mu for writing and returns a matching unlock
+// func, unless d.ctx is already inside a RunInTransaction call on this
+// store: RunInTransaction holds the write lock for the whole transaction
+// body, so a transaction body calling PutMulti/DeleteMulti (themselves
+// calling lock) must not lock again, or it would deadlock against
+// itself. Skipping the lock there is safe since the outer
+// RunInTransaction lock already excludes every other caller.
+func (d *datastoreImpl) lock() func() {
+	if ds.InTransaction(d.ctx) {
+		return func() {}
+	}
+	d.data.mu.Lock()
+	return d.data.mu.Unlock
+}
+
+// rlock is lock's read-locking counterpart.
+func (d *datastoreImpl) rlock() func() {
+	if ds.InTransaction(d.ctx) {
+		return func() {}
+	}
+	d.data.mu.RLock()
+	return d.data.mu.RUnlock
+}
+
+// encodeKey returns a string uniquely identifying k within this
+// datastoreImpl, suitable as a map key. Unlike Key.String() (a
+// human-readable, non-parseable display form), this includes the
+// namespace so that entities in different namespaces with otherwise
+// identical kind/ID chains never collide.
+func encodeKey(k *Key) string { return k.Namespace() + "\x00" + k.String() }
+
+func (d *datastoreImpl) pendingKindMap(kind string) map[string]bool {
+	m := d.data.pending[kind]
+	if m == nil {
+		m = map[string]bool{}
+		d.data.pending[kind] = m
+	}
+	return m
+}
+
+func (d *datastoreImpl) kindMap(kind string) map[string]dsEntry {
+	m := d.data.byKind[kind]
+	if m == nil {
+		m = map[string]dsEntry{}
+		d.data.byKind[kind] = m
+	}
+	return m
+}
+
+// cancellationCheckInterval is how many entries Run examines between
+// ctx.Err() checks, so that cancelling a long-running query is noticed
+// promptly without paying the overhead of checking on every entry.
+const cancellationCheckInterval = 100
+
+func (d *datastoreImpl) GetMulti(keys []*Key, vals []ds.PropertyMap) error {
+	return d.getMulti(keys, vals, true)
+}
+
+// GetMultiConsistent implements ds.ConsistentGetter. With strong=false it
+// additionally treats an entity held back from non-ancestor queries by
+// Testable.Consistent(false) as not found, simulating an eventually
+// consistent Get the way Run already simulates an eventually consistent
+// query; plain GetMulti (via the ds.RawInterface method, always
+// strong=true) keeps its production-matching guarantee of always being
+// strongly consistent.
+func (d *datastoreImpl) GetMultiConsistent(keys []*Key, vals []ds.PropertyMap, strong bool) error {
+	return d.getMulti(keys, vals, strong)
+}
+
+func (d *datastoreImpl) getMulti(keys []*Key, vals []ds.PropertyMap, strong bool) error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+
+	defer d.rlock()()
+
+	me := make(ds.MultiError, len(keys))
+	any := false
+	for i, k := range keys {
+		enc := encodeKey(k)
+		e, ok := d.data.byKind[k.Kind()][enc]
+		if !ok || (!strong && !d.data.consistent && d.data.pending[k.Kind()][enc]) {
+			me[i] = ds.ErrNoSuchEntity
+			any = true
+			continue
+		}
+		for p := range vals[i] {
+			delete(vals[i], p)
+		}
+		for p, v := range e.pm {
+			vals[i][p] = v
+		}
+	}
+	if any {
+		return me
+	}
+	return nil
+}
+
+// hotContentionCheck simulates another client concurrently writing to the
+// entity group configured by Testable.HotKey: if keys includes one
+// rooted there and d.ctx is inside a RunInTransaction call, it rolls
+// hotProbability and returns ds.ErrConcurrentTransaction if it hits,
+// exactly as production datastore would reject the commit. It rolls once
+// per call rather than once per key, since contention is a property of
+// the commit, not of any individual entity in it.
+func (d *datastoreImpl) hotContentionCheck(keys []*Key) error {
+	unlock := d.rlock()
+	hotRoot, prob := d.data.hotRoot, d.data.hotProbability
+	inTxn := ds.InTransaction(d.ctx)
+	unlock()
+
+	if hotRoot == nil || !inTxn {
+		return nil
+	}
+	for _, k := range keys {
+		if k.Root().Equal(hotRoot) {
+			if d.data.hotRand.Float64() < prob {
+				return ds.ErrConcurrentTransaction
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func (d *datastoreImpl) PutMulti(keys []*Key, vals []ds.PropertyMap) ([]*Key, error) {
+	if err := d.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := d.hotContentionCheck(keys); err != nil {
+		return nil, err
+	}
+
+	defer d.lock()()
+
+	out := make([]*Key, len(keys))
+	me := make(ds.MultiError, len(keys))
+	anyErr := false
+	for i, k := range keys {
+		if k.Kind() == "" {
+			me[i] = fmt.Errorf("datastore: key at index %d has an empty Kind", i)
+			anyErr = true
+			continue
+		}
+		if k.Incomplete() {
+			k = ds.NewKey(d.data.appID, k.Namespace(), k.Kind(), "", d.data.allocateID(k), k.Parent())
+		}
+		if d.data.writesPerSecPerGroup > 0 {
+			group := encodeKey(k.Root())
+			now := d.data.clock.Now()
+			interval := time.Duration(float64(time.Second) / d.data.writesPerSecPerGroup)
+			if last, ok := d.data.lastWrite[group]; ok && now.Sub(last) < interval {
+				me[i] = ErrWriteRateExceeded
+				anyErr = true
+				continue
+			}
+			d.data.lastWrite[group] = now
+		}
+		cp := make(ds.PropertyMap, len(vals[i]))
+		for p, v := range vals[i] {
+			cp[p] = v
+		}
+		enc := encodeKey(k)
+		d.kindMap(k.Kind())[enc] = dsEntry{key: k, pm: cp}
+		if !d.data.consistent {
+			d.pendingKindMap(k.Kind())[enc] = true
+		}
+		out[i] = k
+	}
+	addPendingMutations(d.ctx, len(keys))
+	if anyErr {
+		return out, me
+	}
+	return out, nil
+}
+
+func (d *datastoreImpl) DeleteMulti(keys []*Key) error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+	if err := d.hotContentionCheck(keys); err != nil {
+		return err
+	}
+
+	defer d.lock()()
+
+	for _, k := range keys {
+		delete(d.data.byKind[k.Kind()], encodeKey(k))
+	}
+	addPendingMutations(d.ctx, len(keys))
+	return nil
+}
+
+// Pseudo-kinds production Cloud Datastore exposes for schema
+// introspection. See runNamespaceMetaQuery, runKindMetaQuery and
+// runPropertyMetaQuery.
+const (
+	metaNamespaceKind = "__namespace__"
+	metaKindKind      = "__kind__"
+	metaPropertyKind  = "__property__"
+)
+
+func (d *datastoreImpl) Run(q *ds.Query, cb ds.RunCB) error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+
+	unlock := d.rlock()
+	notReady := d.data.notReadyKinds[q.Kind()]
+	unlock()
+	if notReady {
+		return ds.ErrIndexNotReady
+	}
+
+	switch q.Kind() {
+	case metaNamespaceKind:
+		return d.runNamespaceMetaQuery(q, cb)
+	case metaKindKind:
+		return d.runKindMetaQuery(q, cb)
+	case metaPropertyKind:
+		return d.runPropertyMetaQuery(q, cb)
+	}
+
+	unlock = d.rlock()
+	anc := q.GetAncestor()
+	entries := make([]dsEntry, 0, len(d.data.byKind[q.Kind()]))
+	for enc, e := range d.data.byKind[q.Kind()] {
+		if e.key.Namespace() != q.GetNamespace() {
+			continue
+		}
+		if anc != nil {
+			if !isDescendant(e.key, anc) {
+				continue
+			}
+		} else if d.data.pending[q.Kind()][enc] {
+			// Ancestor queries are always strongly consistent in
+			// production; only non-ancestor queries are held back until
+			// CatchUpIndexes simulates the index catching up.
+			continue
+		}
+		entries = append(entries, e)
+	}
+	unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return encodeKey(entries[i].key) < encodeKey(entries[j].key) })
+
+	limit, hasLimit := q.GetLimit()
+	n := int32(0)
+	for i, e := range entries {
+		if hasLimit && n >= limit {
+			break
+		}
+		if i%cancellationCheckInterval == 0 {
+			if err := d.ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if err := cb(e.key, e.pm); err != nil {
+			if err == ds.Stop {
+				return nil
+			}
+			return err
+		}
+		n++
+	}
+	return nil
+}
+
+// Count reports the number of entities q matches by running it and
+// counting results; it does not short-circuit on limit in any way q.Run
+// wouldn't already.
+func (d *datastoreImpl) Count(q *ds.Query) (int64, error) {
+	if err := d.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err := d.Run(q, func(*ds.Key, ds.PropertyMap) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// RunInTransaction simulates a datastore transaction by simply running f
+// under a lock that serializes it with respect to other transactions on
+// this datastoreImpl, retrying it while it returns ds.ErrConcurrentTransaction.
+//
+// Since this implementation retries internally, it is one of the
+// backends for which ds.WithTransactionRetryObserver actually fires.
+func (d *datastoreImpl) RunInTransaction(ctx context.Context, f func(context.Context) error, opts *ds.TransactionOptions) error {
+	maxAttempts := defaultMaxTransactionAttempts
+	if opts != nil && opts.Attempts > 0 {
+		maxAttempts = opts.Attempts
+	}
+
+	counter := &txnMutationCounter{}
+	txnCtx := context.WithValue(ds.MarkInTransaction(ctx), txnMutationCounterKey, counter)
+	obs := ds.GetTransactionRetryObserver(ctx)
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		counter.n = 0
+		lastErr = func() error {
+			d.data.mu.Lock()
+			defer d.data.mu.Unlock()
+			return f(txnCtx)
+		}()
+		if lastErr == nil {
+			return nil
+		}
+		if !errors.Is(lastErr, ds.ErrConcurrentTransaction) {
+			return lastErr
+		}
+		if obs != nil {
+			obs(attempt, lastErr)
+		}
+	}
+	return lastErr
+}
+
+type txnMutationCounterKeyType struct{}
+
+var txnMutationCounterKey txnMutationCounterKeyType
+
+// txnMutationCounter tracks how many entities PutMulti/DeleteMulti calls
+// have mutated so far in one RunInTransaction attempt. This
+// implementation applies a transaction's writes immediately rather than
+// buffering them until commit (see RunInTransaction), so there is
+// nothing to literally count as "buffered"; counting every mutating call
+// as it happens still gives ds.PendingMutations' callers the same
+// "should I commit and start a new transaction" signal.
+type txnMutationCounter struct {
+	n int
+}
+
+// addPendingMutations adds n to ctx's txnMutationCounter, if ctx is
+// inside a RunInTransaction call on this package; it's a no-op
+// otherwise.
+func addPendingMutations(ctx context.Context, n int) {
+	if counter, ok := ctx.Value(txnMutationCounterKey).(*txnMutationCounter); ok {
+		counter.n += n
+	}
+}
+
+// PendingMutations implements ds.MutationCounter.
+func (d *datastoreImpl) PendingMutations(ctx context.Context) (int, bool) {
+	counter, ok := ctx.Value(txnMutationCounterKey).(*txnMutationCounter)
+	if !ok {
+		return 0, false
+	}
+	return counter.n, true
+}
+
+// runNamespaceMetaQuery implements querying the special __namespace__
+// kind: it enumerates every distinct namespace with at least one entity
+// in this datastoreImpl, matching production's key shape (the default
+// namespace is IntID 1; named namespaces are StringID <name>).
+func (d *datastoreImpl) runNamespaceMetaQuery(q *ds.Query, cb ds.RunCB) error {
+	unlock := d.rlock()
+	seen := map[string]bool{}
+	for _, m := range d.data.byKind {
+		for _, e := range m {
+			seen[e.key.Namespace()] = true
+		}
+	}
+	unlock()
+
+	namespaces := make([]string, 0, len(seen))
+	for ns := range seen {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	limit, hasLimit := q.GetLimit()
+	n := int32(0)
+	for _, ns := range namespaces {
+		if hasLimit && n >= limit {
+			break
+		}
+		var k *ds.Key
+		if ns == "" {
+			k = ds.NewKey(d.data.appID, ns, metaNamespaceKind, "", 1, nil)
+		} else {
+			k = ds.NewKey(d.data.appID, ns, metaNamespaceKind, ns, 0, nil)
+		}
+		if err := cb(k, ds.PropertyMap{}); err != nil {
+			if err == ds.Stop {
+				return nil
+			}
+			return err
+		}
+		n++
+	}
+	return nil
+}
+
+// runKindMetaQuery implements querying the special __kind__ kind: it
+// enumerates every kind with at least one entity in q's namespace,
+// matching production's key shape (StringID is the kind name).
+func (d *datastoreImpl) runKindMetaQuery(q *ds.Query, cb ds.RunCB) error {
+	unlock := d.rlock()
+	kinds := map[string]bool{}
+	for kind, m := range d.data.byKind {
+		for _, e := range m {
+			if e.key.Namespace() == q.GetNamespace() {
+				kinds[kind] = true
+				break
+			}
+		}
+	}
+	unlock()
+
+	for _, kind := range sortedBoolSetKeys(kinds) {
+		k := ds.NewKey(d.data.appID, q.GetNamespace(), metaKindKind, kind, 0, nil)
+		if err := cb(k, ds.PropertyMap{}); err != nil {
+			if err == ds.Stop {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// runPropertyMetaQuery implements querying the special __property__
+// kind: for each kind with at least one entity in q's namespace, it
+// enumerates the distinct (non-meta) property names used by any of that
+// kind's entities, as a key parented under the matching __kind__ key,
+// matching production's representation.
+func (d *datastoreImpl) runPropertyMetaQuery(q *ds.Query, cb ds.RunCB) error {
+	unlock := d.rlock()
+	props := map[string]map[string]bool{} // kind -> property name -> seen
+	for kind, m := range d.data.byKind {
+		for _, e := range m {
+			if e.key.Namespace() != q.GetNamespace() {
+				continue
+			}
+			set := props[kind]
+			if set == nil {
+				set = map[string]bool{}
+				props[kind] = set
+			}
+			for p := range e.pm {
+				if !ds.IsMetaKey(p) {
+					set[p] = true
+				}
+			}
+		}
+	}
+	unlock()
+
+	propKinds := make([]string, 0, len(props))
+	for kind := range props {
+		propKinds = append(propKinds, kind)
+	}
+	sort.Strings(propKinds)
+
+	for _, kind := range propKinds {
+		kindKey := ds.NewKey(d.data.appID, q.GetNamespace(), metaKindKind, kind, 0, nil)
+		for _, name := range sortedBoolSetKeys(props[kind]) {
+			k := ds.NewKey(d.data.appID, q.GetNamespace(), metaPropertyKind, name, 0, kindKey)
+			if err := cb(k, ds.PropertyMap{}); err != nil {
+				if err == ds.Stop {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortedBoolSetKeys returns the keys of a string set (map[string]bool) in
+// sorted order.
+func sortedBoolSetKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func isDescendant(k, anc *Key) bool {
+	for cur := k; cur != nil; cur = cur.Parent() {
+		if cur.Equal(anc) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestPutMultiPartialSuccess(t *testing.T) {
+	raw := NewDatastore("app")
+
+	keys := []*ds.Key{
+		ds.NewKey("app", "", "Widget", "good-1", 0, nil),
+		ds.NewKey("app", "", "", "bad", 0, nil), // empty Kind: rejected
+		ds.NewKey("app", "", "Widget", "good-2", 0, nil),
+	}
+	vals := []ds.PropertyMap{{"V": int64(1)}, {"V": int64(2)}, {"V": int64(3)}}
+
+	outKeys, err := raw.PutMulti(keys, vals)
+	me, ok := err.(ds.MultiError)
+	if !ok {
+		t.Fatalf("expected MultiError, got %T: %v", err, err)
+	}
+	if me[0] != nil || me[2] != nil {
+		t.Errorf("expected good entities to succeed, got %v / %v", me[0], me[2])
+	}
+	if me[1] == nil {
+		t.Errorf("expected bad entity to fail")
+	}
+
+	got := []ds.PropertyMap{{}, {}}
+	if err := raw.GetMulti([]*ds.Key{outKeys[0], outKeys[2]}, got); err != nil {
+		t.Fatalf("good entities should have persisted: %v", err)
+	}
+	if got[0]["V"] != int64(1) || got[1]["V"] != int64(3) {
+		t.Errorf("unexpected persisted values: %#v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestNamespaceMetaQuery(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+
+	keys := []*ds.Key{
+		ds.NewKey("app", "", "Widget", "a", 0, nil),
+		ds.NewKey("app", "ns-a", "Widget", "b", 0, nil),
+		ds.NewKey("app", "ns-b", "Widget", "c", 0, nil),
+	}
+	vals := []ds.PropertyMap{{}, {}, {}}
+	if _, err := raw.PutMulti(keys, vals); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	var got []string
+	err := raw.Run(ds.NewQuery("__namespace__"), func(k *ds.Key, _ ds.PropertyMap) error {
+		if k.StringID() != "" {
+			got = append(got, k.StringID())
+		} else {
+			got = append(got, "<default>")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run(__namespace__): %v", err)
+	}
+
+	want := []string{"<default>", "ns-a", "ns-b"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
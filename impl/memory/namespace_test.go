@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestQueryIsNamespaceScoped(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+
+	keyA := ds.NewKey("app", "ns-a", "Widget", "shared-id", 0, nil)
+	keyB := ds.NewKey("app", "ns-b", "Widget", "shared-id", 0, nil)
+	if _, err := raw.PutMulti([]*ds.Key{keyA, keyB}, []ds.PropertyMap{{"V": "a"}, {"V": "b"}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	nA := countResults(t, raw, ds.NewQuery("Widget").Namespace("ns-a"))
+	nB := countResults(t, raw, ds.NewQuery("Widget").Namespace("ns-b"))
+	nDefault := countResults(t, raw, ds.NewQuery("Widget"))
+
+	if nA != 1 || nB != 1 || nDefault != 0 {
+		t.Errorf("namespace-scoped counts = %d, %d, %d, want 1, 1, 0", nA, nB, nDefault)
+	}
+
+	got := []ds.PropertyMap{{}}
+	if err := raw.GetMulti([]*ds.Key{keyA}, got); err != nil || got[0]["V"] != "a" {
+		t.Errorf("GetMulti(keyA) = %v, %v, want V=a", got, err)
+	}
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luci/gae/common/clock/testclock"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestRateLimit(t *testing.T) {
+	clk := testclock.New(time.Unix(0, 0))
+	ctx := UseWithClock(context.Background(), "app", clk)
+	raw := ds.Raw(ctx)
+	GetTestable(ctx).RateLimit(1) // 1 write/sec/group
+
+	parent := ds.NewKey("app", "", "Group", "g1", 0, nil)
+	a := ds.NewKey("app", "", "Widget", "a", 0, parent)
+	b := ds.NewKey("app", "", "Widget", "b", 0, parent)
+	other := ds.NewKey("app", "", "Widget", "c", 0, ds.NewKey("app", "", "Group", "g2", 0, nil))
+
+	if _, err := raw.PutMulti([]*ds.Key{a}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+
+	// A second write to the same group immediately after should be
+	// rejected...
+	_, err := raw.PutMulti([]*ds.Key{b}, []ds.PropertyMap{{}})
+	me, ok := err.(ds.MultiError)
+	if !ok || !errors.Is(me[0], ErrWriteRateExceeded) {
+		t.Fatalf("second write to same group: got %v, want ErrWriteRateExceeded", err)
+	}
+
+	// ...but a write to a different group should succeed.
+	if _, err := raw.PutMulti([]*ds.Key{other}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("write to other group: %v", err)
+	}
+
+	// Once enough time passes, the original group accepts writes again.
+	clk.Add(time.Second)
+	if _, err := raw.PutMulti([]*ds.Key{b}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("write after waiting: %v", err)
+	}
+}
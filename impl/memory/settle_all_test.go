@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestSettleAllMakesWritesFullyVisible(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+	testable := GetTestable(ctx)
+	testable.Consistent(false)
+	testable.RateLimit(1)
+
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := raw.PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	if seen := countResults(t, raw, ds.NewQuery("Widget")); seen != 0 {
+		t.Fatalf("before SettleAll, non-ancestor query saw %d results, want 0", seen)
+	}
+
+	testable.SettleAll()
+
+	if seen := countResults(t, raw, ds.NewQuery("Widget")); seen != 1 {
+		t.Fatalf("after SettleAll, non-ancestor query saw %d results, want 1", seen)
+	}
+
+	if _, err := raw.PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti to the same group right after SettleAll should not be rate-limited: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/luci/gae/common/clock"
+	tq "github.com/luci/gae/service/taskqueue"
+)
+
+// queuedTask is one task held by taskqueueImpl, plus the bookkeeping
+// needed to implement AddMulti's idempotent-Name semantics and pull-queue
+// leasing.
+type queuedTask struct {
+	task        *tq.Task
+	deleted     bool
+	leasedUntil time.Time
+}
+
+func (qt *queuedTask) leased(now time.Time) bool { return qt.leasedUntil.After(now) }
+
+// taskQueueState is the tasks belonging to a single named queue.
+type taskQueueState struct {
+	names []string // insertion order, for deterministic iteration
+	tasks map[string]*queuedTask
+}
+
+// taskqueueImpl is the in-memory tq.RawInterface implementation.
+type taskqueueImpl struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	queues map[string]*taskQueueState
+}
+
+// NewTaskQueue returns a fresh, empty in-memory taskqueue.RawInterface
+// backed by the real wall clock. Each call returns independent state.
+func NewTaskQueue() tq.RawInterface {
+	return NewTaskQueueWithClock(clock.SystemClock{})
+}
+
+// NewTaskQueueWithClock is like NewTaskQueue, but lets a test drive
+// ETA/lease expiry via a testclock.TestClock instead of real time.
+func NewTaskQueueWithClock(clk clock.Clock) tq.RawInterface {
+	return &taskqueueImpl{clock: clk, queues: map[string]*taskQueueState{}}
+}
+
+func randomTaskName() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func (t *taskqueueImpl) queue(name string) *taskQueueState {
+	q, ok := t.queues[name]
+	if !ok {
+		q = &taskQueueState{tasks: map[string]*queuedTask{}}
+		t.queues[name] = q
+	}
+	return q
+}
+
+func (t *taskqueueImpl) AddMulti(tasks []*tq.Task) ([]*tq.Task, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]*tq.Task, len(tasks))
+	me := make(tq.MultiError, len(tasks))
+	for i, task := range tasks {
+		cp := *task
+		q := t.queue(cp.Queue)
+		if cp.Name == "" {
+			cp.Name = randomTaskName()
+		} else if existing, ok := q.tasks[cp.Name]; ok && !existing.deleted {
+			me[i] = tq.ErrTaskAlreadyExists
+			out[i] = existing.task
+			continue
+		}
+		if _, ok := q.tasks[cp.Name]; !ok {
+			q.names = append(q.names, cp.Name)
+		}
+		q.tasks[cp.Name] = &queuedTask{task: &cp}
+		out[i] = &cp
+	}
+	return out, me
+}
+
+// Lease implements tq.RawInterface.
+func (t *taskqueueImpl) Lease(queue string, maxTasks int, leaseTime time.Duration) ([]*tq.Task, error) {
+	return t.lease(queue, "", maxTasks, leaseTime)
+}
+
+// LeaseByTag implements tq.RawInterface.
+func (t *taskqueueImpl) LeaseByTag(queue, tag string, maxTasks int, leaseTime time.Duration) ([]*tq.Task, error) {
+	return t.lease(queue, tag, maxTasks, leaseTime)
+}
+
+func (t *taskqueueImpl) lease(queueName, tag string, maxTasks int, leaseTime time.Duration) ([]*tq.Task, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	q, ok := t.queues[queueName]
+	if !ok {
+		return nil, nil
+	}
+
+	now := t.clock.Now()
+	var leased []*tq.Task
+	for _, name := range q.names {
+		if len(leased) >= maxTasks {
+			break
+		}
+		qt := q.tasks[name]
+		if qt.deleted || qt.task.ETA.After(now) || qt.leased(now) {
+			continue
+		}
+		if tag != "" && qt.task.Tag != tag {
+			continue
+		}
+		qt.leasedUntil = now.Add(leaseTime)
+		leased = append(leased, qt.task)
+	}
+	return leased, nil
+}
+
+// ModifyLease implements tq.RawInterface.
+func (t *taskqueueImpl) ModifyLease(task *tq.Task, leaseTime time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	q, ok := t.queues[task.Queue]
+	if !ok {
+		return tq.ErrUnknownTask
+	}
+	qt, ok := q.tasks[task.Name]
+	now := t.clock.Now()
+	if !ok || qt.deleted || !qt.leased(now) {
+		return tq.ErrUnknownTask
+	}
+	qt.leasedUntil = now.Add(leaseTime)
+	return nil
+}
+
+// QueueStats implements tq.RawInterface.
+func (t *taskqueueImpl) QueueStats(queueNames []string) ([]tq.QueueStats, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	out := make([]tq.QueueStats, len(queueNames))
+	for i, name := range queueNames {
+		q, ok := t.queues[name]
+		if !ok {
+			continue
+		}
+		var stats tq.QueueStats
+		for _, taskName := range q.names {
+			qt := q.tasks[taskName]
+			if qt.deleted {
+				continue
+			}
+			stats.Tasks++
+			if qt.leased(now) {
+				stats.Leased++
+			}
+			if stats.OldestETA.IsZero() || qt.task.ETA.Before(stats.OldestETA) {
+				stats.OldestETA = qt.task.ETA
+			}
+		}
+		out[i] = stats
+	}
+	return out, nil
+}
+
+func (t *taskqueueImpl) DeleteMulti(tasks []*tq.Task) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	me := make(tq.MultiError, len(tasks))
+	for i, task := range tasks {
+		q, ok := t.queues[task.Queue]
+		if !ok {
+			me[i] = tq.ErrUnknownTask
+			continue
+		}
+		existing, ok := q.tasks[task.Name]
+		if !ok || existing.deleted {
+			me[i] = tq.ErrUnknownTask
+			continue
+		}
+		existing.deleted = true
+	}
+	if me.Any() {
+		return me
+	}
+	return nil
+}
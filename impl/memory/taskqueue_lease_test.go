@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/luci/gae/common/clock/testclock"
+	tq "github.com/luci/gae/service/taskqueue"
+)
+
+func addOK(t *testing.T, raw tq.RawInterface, tasks ...*tq.Task) []*tq.Task {
+	t.Helper()
+	out, err := raw.AddMulti(tasks)
+	if err != nil {
+		if me, ok := err.(tq.MultiError); !ok || me.Any() {
+			t.Fatalf("AddMulti: %v", err)
+		}
+	}
+	return out
+}
+
+func TestLeaseClaimsEligibleTasksInOrder(t *testing.T) {
+	clk := testclock.New(time.Unix(1000, 0))
+	raw := NewTaskQueueWithClock(clk)
+	addOK(t, raw, &tq.Task{Name: "a", Queue: "pull"}, &tq.Task{Name: "b", Queue: "pull"})
+
+	leased, err := raw.Lease("pull", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("Lease: %v", err)
+	}
+	if len(leased) != 2 || leased[0].Name != "a" || leased[1].Name != "b" {
+		t.Fatalf("Lease = %v, want [a b] in insertion order", leased)
+	}
+}
+
+func TestLeaseExcludesAlreadyLeasedTasks(t *testing.T) {
+	clk := testclock.New(time.Unix(1000, 0))
+	raw := NewTaskQueueWithClock(clk)
+	addOK(t, raw, &tq.Task{Name: "a", Queue: "pull"})
+
+	if _, err := raw.Lease("pull", 10, time.Minute); err != nil {
+		t.Fatalf("first Lease: %v", err)
+	}
+	leased, err := raw.Lease("pull", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("second Lease: %v", err)
+	}
+	if len(leased) != 0 {
+		t.Errorf("second Lease = %v, want none (still leased)", leased)
+	}
+}
+
+func TestLeaseReclaimsTaskAfterLeaseExpires(t *testing.T) {
+	clk := testclock.New(time.Unix(1000, 0))
+	raw := NewTaskQueueWithClock(clk)
+	addOK(t, raw, &tq.Task{Name: "a", Queue: "pull"})
+
+	if _, err := raw.Lease("pull", 10, time.Minute); err != nil {
+		t.Fatalf("first Lease: %v", err)
+	}
+	clk.Add(2 * time.Minute)
+
+	leased, err := raw.Lease("pull", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("second Lease: %v", err)
+	}
+	if len(leased) != 1 || leased[0].Name != "a" {
+		t.Fatalf("second Lease = %v, want [a] after expiry", leased)
+	}
+}
+
+func TestLeaseByTagOnlyClaimsMatchingTag(t *testing.T) {
+	clk := testclock.New(time.Unix(1000, 0))
+	raw := NewTaskQueueWithClock(clk)
+	addOK(t, raw,
+		&tq.Task{Name: "a", Queue: "pull", Tag: "urgent"},
+		&tq.Task{Name: "b", Queue: "pull", Tag: "normal"},
+	)
+
+	leased, err := raw.LeaseByTag("pull", "urgent", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("LeaseByTag: %v", err)
+	}
+	if len(leased) != 1 || leased[0].Name != "a" {
+		t.Fatalf("LeaseByTag = %v, want [a]", leased)
+	}
+}
+
+func TestLeaseSkipsFutureETA(t *testing.T) {
+	clk := testclock.New(time.Unix(1000, 0))
+	raw := NewTaskQueueWithClock(clk)
+	addOK(t, raw, &tq.Task{Name: "a", Queue: "pull", ETA: clk.Now().Add(time.Hour)})
+
+	leased, err := raw.Lease("pull", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("Lease: %v", err)
+	}
+	if len(leased) != 0 {
+		t.Errorf("Lease = %v, want none before ETA", leased)
+	}
+}
+
+func TestModifyLeaseZeroReleaseAllowsImmediateReLease(t *testing.T) {
+	clk := testclock.New(time.Unix(1000, 0))
+	raw := NewTaskQueueWithClock(clk)
+	addOK(t, raw, &tq.Task{Name: "a", Queue: "pull"})
+
+	leased, err := raw.Lease("pull", 10, time.Minute)
+	if err != nil || len(leased) != 1 {
+		t.Fatalf("Lease: leased=%v err=%v", leased, err)
+	}
+
+	if err := raw.ModifyLease(leased[0], 0); err != nil {
+		t.Fatalf("ModifyLease(release): %v", err)
+	}
+
+	reLeased, err := raw.Lease("pull", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("re-Lease: %v", err)
+	}
+	if len(reLeased) != 1 || reLeased[0].Name != "a" {
+		t.Fatalf("re-Lease = %v, want [a] immediately after release", reLeased)
+	}
+}
+
+func TestModifyLeaseOnUnleasedTaskFails(t *testing.T) {
+	clk := testclock.New(time.Unix(1000, 0))
+	raw := NewTaskQueueWithClock(clk)
+	addOK(t, raw, &tq.Task{Name: "a", Queue: "pull"})
+
+	err := raw.ModifyLease(&tq.Task{Name: "a", Queue: "pull"}, time.Minute)
+	if err != tq.ErrUnknownTask {
+		t.Fatalf("ModifyLease(not leased) = %v, want ErrUnknownTask", err)
+	}
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/luci/gae/common/clock/testclock"
+	tq "github.com/luci/gae/service/taskqueue"
+)
+
+func TestQueueStatsReflectsAddedLeasedAndDeletedTasks(t *testing.T) {
+	clk := testclock.New(time.Unix(1000, 0))
+	raw := NewTaskQueueWithClock(clk)
+
+	eta1 := clk.Now().Add(time.Minute)
+	eta2 := clk.Now().Add(2 * time.Minute)
+	addOK(t, raw, &tq.Task{Name: "a", Queue: "pull", ETA: eta1}, &tq.Task{Name: "b", Queue: "pull", ETA: eta2})
+
+	stats, err := raw.QueueStats([]string{"pull"})
+	if err != nil {
+		t.Fatalf("QueueStats: %v", err)
+	}
+	if stats[0].Tasks != 2 {
+		t.Errorf("Tasks = %d, want 2", stats[0].Tasks)
+	}
+	if !stats[0].OldestETA.Equal(eta1) {
+		t.Errorf("OldestETA = %v, want %v", stats[0].OldestETA, eta1)
+	}
+	if stats[0].Leased != 0 {
+		t.Errorf("Leased = %d, want 0", stats[0].Leased)
+	}
+
+	clk.Add(time.Minute) // advance past eta1 so the task is eligible to lease
+
+	if _, err := raw.Lease("pull", 1, time.Minute); err != nil {
+		t.Fatalf("Lease: %v", err)
+	}
+	stats, err = raw.QueueStats([]string{"pull"})
+	if err != nil {
+		t.Fatalf("QueueStats after lease: %v", err)
+	}
+	if stats[0].Leased != 1 {
+		t.Errorf("Leased = %d, want 1", stats[0].Leased)
+	}
+
+	if err := raw.DeleteMulti([]*tq.Task{{Name: "a", Queue: "pull"}}); err != nil {
+		t.Fatalf("DeleteMulti: %v", err)
+	}
+	stats, err = raw.QueueStats([]string{"pull"})
+	if err != nil {
+		t.Fatalf("QueueStats after delete: %v", err)
+	}
+	if stats[0].Tasks != 1 {
+		t.Errorf("Tasks after delete = %d, want 1", stats[0].Tasks)
+	}
+}
+
+func TestQueueStatsSupportsMultipleQueueNames(t *testing.T) {
+	clk := testclock.New(time.Unix(1000, 0))
+	raw := NewTaskQueueWithClock(clk)
+	addOK(t, raw, &tq.Task{Name: "a", Queue: "q1"})
+	addOK(t, raw, &tq.Task{Name: "b", Queue: "q2"}, &tq.Task{Name: "c", Queue: "q2"})
+
+	stats, err := raw.QueueStats([]string{"q1", "q2", "q3"})
+	if err != nil {
+		t.Fatalf("QueueStats: %v", err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("got %d entries, want 3", len(stats))
+	}
+	if stats[0].Tasks != 1 || stats[1].Tasks != 2 || stats[2].Tasks != 0 {
+		t.Errorf("Tasks = [%d %d %d], want [1 2 0]", stats[0].Tasks, stats[1].Tasks, stats[2].Tasks)
+	}
+}
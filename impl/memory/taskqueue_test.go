@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"testing"
+
+	tq "github.com/luci/gae/service/taskqueue"
+)
+
+func TestAddMultiAssignsNameWhenEmpty(t *testing.T) {
+	raw := NewTaskQueue()
+	out, err := raw.AddMulti([]*tq.Task{{Queue: "default", Payload: []byte("x")}})
+	if err != nil {
+		if me, ok := err.(tq.MultiError); !ok || me.Any() {
+			t.Fatalf("AddMulti: %v", err)
+		}
+	}
+	if out[0].Name == "" {
+		t.Errorf("AddMulti left Name empty, want an assigned name")
+	}
+}
+
+func TestAddMultiRejectsDuplicateName(t *testing.T) {
+	raw := NewTaskQueue()
+	task := &tq.Task{Name: "t1", Queue: "default", Payload: []byte("x")}
+	if _, err := raw.AddMulti([]*tq.Task{task}); err != nil {
+		if me, ok := err.(tq.MultiError); !ok || me.Any() {
+			t.Fatalf("first AddMulti: %v", err)
+		}
+	}
+
+	_, err := raw.AddMulti([]*tq.Task{{Name: "t1", Queue: "default", Payload: []byte("y")}})
+	me, ok := err.(tq.MultiError)
+	if !ok || len(me) != 1 || me[0] != tq.ErrTaskAlreadyExists {
+		t.Fatalf("second AddMulti = %v, want a MultiError[ErrTaskAlreadyExists]", err)
+	}
+}
+
+func TestDeleteMultiThenAddMultiCanReuseName(t *testing.T) {
+	raw := NewTaskQueue()
+	task := &tq.Task{Name: "t1", Queue: "default", Payload: []byte("x")}
+	if _, err := raw.AddMulti([]*tq.Task{task}); err != nil {
+		if me, ok := err.(tq.MultiError); !ok || me.Any() {
+			t.Fatalf("AddMulti: %v", err)
+		}
+	}
+	if err := raw.DeleteMulti([]*tq.Task{task}); err != nil {
+		if me, ok := err.(tq.MultiError); !ok || me.Any() {
+			t.Fatalf("DeleteMulti: %v", err)
+		}
+	}
+
+	_, err := raw.AddMulti([]*tq.Task{{Name: "t1", Queue: "default", Payload: []byte("z")}})
+	if err != nil {
+		if me, ok := err.(tq.MultiError); !ok || me.Any() {
+			t.Fatalf("re-AddMulti after delete: %v", err)
+		}
+	}
+}
+
+func TestDeleteMultiUnknownTaskReportsError(t *testing.T) {
+	raw := NewTaskQueue()
+	err := raw.DeleteMulti([]*tq.Task{{Name: "nope", Queue: "default"}})
+	me, ok := err.(tq.MultiError)
+	if !ok || len(me) != 1 || me[0] != tq.ErrUnknownTask {
+		t.Fatalf("DeleteMulti = %v, want a MultiError[ErrUnknownTask]", err)
+	}
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// Testable exposes in-memory-only hooks for manipulating and inspecting
+// the datastore state directly, bypassing the production RawInterface.
+// It is deliberately not part of ds.RawInterface so that it can't leak
+// into code paths meant to also run against a real backend.
+type Testable interface {
+	// DeleteUnderAncestor removes every entity whose key has parent as an
+	// ancestor (per ds.Key.Equal on some element of its Parent chain),
+	// returning the number of entities removed.
+	DeleteUnderAncestor(parent *ds.Key) int
+
+	// Consistent toggles eventual-consistency simulation. With consistent
+	// set to false, writes are immediately visible to Gets and ancestor
+	// queries (as in production) but held back from non-ancestor queries
+	// until CatchUpIndexes is called. The default is true (immediately
+	// consistent), matching production's default within a request.
+	Consistent(consistent bool)
+
+	// CatchUpIndexes makes every write made so far visible to
+	// non-ancestor queries, simulating production's index catching up.
+	CatchUpIndexes()
+
+	// RateLimit enforces that writes to any single entity group happen no
+	// more often than writesPerSecPerGroup times per second, failing
+	// PutMulti with ErrWriteRateExceeded for items that arrive too soon
+	// after the previous write to their group. Pass 0 (the default) to
+	// disable the limit. Timing is read from the clock.Clock the
+	// datastore was constructed with (see NewDatastoreWithClock), so
+	// tests can drive it with a testclock.TestClock instead of real time.
+	RateLimit(writesPerSecPerGroup float64)
+
+	// AllKeys returns every key currently in the store, in canonical
+	// sorted order (see encodeKey), optionally restricted to kind. Unlike
+	// a KeysOnly Run, it reads the store directly and so sees entities
+	// regardless of Consistent's eventual-consistency holding state.
+	AllKeys(kind string) []*ds.Key
+
+	// AutoIDPolicy selects how PutMulti assigns an ID to an incomplete
+	// key from now on; already-assigned IDs are unaffected. The default
+	// is AutoIDPolicySequential, matching this package's historical
+	// behavior.
+	AutoIDPolicy(policy AutoIDPolicy)
+
+	// FailIndex makes Run and Count fail every query of kind with
+	// ds.ErrIndexNotReady, simulating a composite index that hasn't
+	// finished building yet, until called again with notReady false.
+	FailIndex(kind string, notReady bool)
+
+	// SettleAll brings the store to a single fully-consistent state in
+	// one call: it's equivalent to Consistent(true), CatchUpIndexes(),
+	// and clearing RateLimit's per-group backoff clock, so a test that
+	// mixes eventual-consistency simulation and write-rate-limiting with
+	// transactions doesn't have to settle each knob individually before
+	// asserting on query results. It does not change the
+	// writesPerSecPerGroup limit itself or FailIndex's per-kind state —
+	// those are explicit test setup, not pending state to settle.
+	SettleAll()
+
+	// AddIndexes records composite indexes as existing, for Indexes to
+	// report back. This package doesn't evaluate Query.Filters or enforce
+	// that a query's composite index actually exists the way production
+	// Cloud Datastore does (FailIndex is the only index-readiness
+	// simulation it has), so AddIndexes has no effect on which queries
+	// succeed; it exists purely so a test (or a developer poking at a
+	// test's setup interactively) can ask Indexes what composite indexes
+	// the store was told about, e.g. to transcribe them into index.yaml.
+	AddIndexes(indexes ...ds.CompositeIndex)
+
+	// Indexes returns the composite indexes given to AddIndexes so far,
+	// in a stable order (by Kind, then by Properties). There is no
+	// built-in/auto-created index to exclude: unlike production Cloud
+	// Datastore, this package never auto-creates a composite index, so
+	// every entry Indexes returns came from an explicit AddIndexes call.
+	Indexes() []ds.CompositeIndex
+
+	// HotKey simulates contention on the entity group rooted at root:
+	// every RunInTransaction attempt whose body PutMulti's or
+	// DeleteMulti's a key under root fails that attempt with
+	// ds.ErrConcurrentTransaction with probability probability, the way
+	// production datastore can when multiple clients race to write the
+	// same entity group. RunInTransaction's existing retry loop handles
+	// it exactly as it would a real one. This package has no built-in
+	// flakiness-injection facility to extend (filter/featureBreaker fails
+	// calls by method name, not by entity group); HotKey is new,
+	// narrowly-scoped infrastructure for this one failure mode. Pass a nil root to
+	// disable.
+	HotKey(root *ds.Key, probability float64)
+
+	// SeedHotKeyRand seeds the random source HotKey draws its
+	// probability rolls from, so a test asserting on HotKey's behavior
+	// doesn't depend on real randomness.
+	SeedHotKeyRand(seed int64)
+}
+
+// GetTestable returns the Testable view of the datastore installed in
+// ctx by Use, or nil if ctx's datastore isn't an in-memory one.
+func GetTestable(ctx context.Context) Testable {
+	t, _ := ds.RawUnfiltered(ctx).(Testable)
+	return t
+}
+
+func (d *datastoreImpl) Consistent(consistent bool) {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+	d.data.consistent = consistent
+}
+
+func (d *datastoreImpl) CatchUpIndexes() {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+	d.data.pending = map[string]map[string]bool{}
+}
+
+func (d *datastoreImpl) RateLimit(writesPerSecPerGroup float64) {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+	d.data.writesPerSecPerGroup = writesPerSecPerGroup
+	d.data.lastWrite = map[string]time.Time{}
+}
+
+func (d *datastoreImpl) AllKeys(kind string) []*ds.Key {
+	d.data.mu.RLock()
+	defer d.data.mu.RUnlock()
+
+	var entries []dsEntry
+	if kind != "" {
+		for _, e := range d.data.byKind[kind] {
+			entries = append(entries, e)
+		}
+	} else {
+		for _, m := range d.data.byKind {
+			for _, e := range m {
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return encodeKey(entries[i].key) < encodeKey(entries[j].key) })
+	out := make([]*ds.Key, len(entries))
+	for i, e := range entries {
+		out[i] = e.key
+	}
+	return out
+}
+
+func (d *datastoreImpl) AutoIDPolicy(policy AutoIDPolicy) {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+	d.data.autoIDPolicy = policy
+}
+
+func (d *datastoreImpl) FailIndex(kind string, notReady bool) {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+	if notReady {
+		d.data.notReadyKinds[kind] = true
+	} else {
+		delete(d.data.notReadyKinds, kind)
+	}
+}
+
+func (d *datastoreImpl) SettleAll() {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+	d.data.consistent = true
+	d.data.pending = map[string]map[string]bool{}
+	d.data.lastWrite = map[string]time.Time{}
+}
+
+func (d *datastoreImpl) AddIndexes(indexes ...ds.CompositeIndex) {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+	d.data.compositeIndexes = append(d.data.compositeIndexes, indexes...)
+}
+
+func (d *datastoreImpl) Indexes() []ds.CompositeIndex {
+	d.data.mu.RLock()
+	defer d.data.mu.RUnlock()
+
+	out := make([]ds.CompositeIndex, len(d.data.compositeIndexes))
+	copy(out, d.data.compositeIndexes)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return strings.Join(out[i].Properties, ",") < strings.Join(out[j].Properties, ",")
+	})
+	return out
+}
+
+func (d *datastoreImpl) HotKey(root *ds.Key, probability float64) {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+	d.data.hotRoot = root
+	d.data.hotProbability = probability
+}
+
+func (d *datastoreImpl) SeedHotKeyRand(seed int64) {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+	d.data.hotRand = rand.New(rand.NewSource(seed))
+}
+
+func (d *datastoreImpl) DeleteUnderAncestor(parent *ds.Key) int {
+	d.data.mu.Lock()
+	defer d.data.mu.Unlock()
+
+	n := 0
+	for kind, m := range d.data.byKind {
+		for enc, e := range m {
+			if isDescendant(e.key, parent) {
+				delete(d.data.byKind[kind], enc)
+				n++
+			}
+		}
+	}
+	return n
+}
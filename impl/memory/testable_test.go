@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestDeleteUnderAncestor(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	raw := ds.Raw(ctx)
+
+	parent := ds.NewKey("app", "", "Parent", "p1", 0, nil)
+	otherParent := ds.NewKey("app", "", "Parent", "p2", 0, nil)
+	child1 := ds.NewKey("app", "", "Child", "c1", 0, parent)
+	child2 := ds.NewKey("app", "", "Child", "c2", 0, parent)
+	otherChild := ds.NewKey("app", "", "Child", "c3", 0, otherParent)
+
+	keys := []*ds.Key{parent, child1, child2, otherParent, otherChild}
+	vals := make([]ds.PropertyMap, len(keys))
+	for i := range vals {
+		vals[i] = ds.PropertyMap{}
+	}
+	if _, err := raw.PutMulti(keys, vals); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	n := GetTestable(ctx).DeleteUnderAncestor(parent)
+	if n != 3 {
+		t.Fatalf("DeleteUnderAncestor removed %d, want 3 (parent + 2 children)", n)
+	}
+
+	got := []ds.PropertyMap{{}}
+	if err := raw.GetMulti([]*ds.Key{otherChild}, got); err != nil {
+		t.Errorf("unrelated entity should survive: %v", err)
+	}
+}
+
+func TestIndexesReportsAddedCompositeIndexes(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	testable := GetTestable(ctx)
+
+	if got := testable.Indexes(); len(got) != 0 {
+		t.Fatalf("Indexes() = %v before any AddIndexes, want empty", got)
+	}
+
+	testable.AddIndexes(
+		ds.CompositeIndex{Kind: "Widget", Properties: []string{"Price", "Name"}},
+		ds.CompositeIndex{Kind: "Gadget", Properties: []string{"Name"}},
+	)
+
+	got := testable.Indexes()
+	if len(got) != 2 {
+		t.Fatalf("Indexes() = %+v, want 2 entries", got)
+	}
+	if got[0].Kind != "Gadget" || got[1].Kind != "Widget" {
+		t.Errorf("Indexes() order = %+v, want Gadget before Widget", got)
+	}
+}
+
+func TestHotKeyRetriesTransactionsOnContention(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	testable := GetTestable(ctx)
+
+	hot := ds.NewKey("app", "", "Counter", "hot", 0, nil)
+	cold := ds.NewKey("app", "", "Counter", "cold", 0, nil)
+
+	testable.HotKey(hot, 1)
+	testable.SeedHotKeyRand(1)
+
+	attempts := 0
+	err := ds.RunInTransaction(ctx, func(txnCtx context.Context) error {
+		attempts++
+		_, err := ds.Raw(txnCtx).PutMulti([]*ds.Key{hot}, []ds.PropertyMap{{}})
+		return err
+	}, &ds.TransactionOptions{Attempts: 3})
+
+	if !errors.Is(err, ds.ErrConcurrentTransaction) {
+		t.Fatalf("RunInTransaction err = %v, want ErrConcurrentTransaction", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3 (HotKey(1.0) should fail every one)", attempts)
+	}
+
+	coldAttempts := 0
+	err = ds.RunInTransaction(ctx, func(txnCtx context.Context) error {
+		coldAttempts++
+		_, err := ds.Raw(txnCtx).PutMulti([]*ds.Key{cold}, []ds.PropertyMap{{}})
+		return err
+	}, nil)
+	if err != nil {
+		t.Fatalf("transaction on unrelated key: %v", err)
+	}
+	if coldAttempts != 1 {
+		t.Errorf("attempts on unrelated key = %d, want 1 (HotKey shouldn't affect it)", coldAttempts)
+	}
+}
+
+func TestHotKeyDisabledByDefault(t *testing.T) {
+	ctx := Use(context.Background(), "app")
+	key := ds.NewKey("app", "", "Counter", "c", 0, nil)
+
+	err := ds.RunInTransaction(ctx, func(txnCtx context.Context) error {
+		_, err := ds.Raw(txnCtx).PutMulti([]*ds.Key{key}, []ds.PropertyMap{{}})
+		return err
+	}, nil)
+	if err != nil {
+		t.Fatalf("transaction with no HotKey configured: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	fb "github.com/luci/gae/filter/featureBreaker"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestTransactionAttemptsCap(t *testing.T) {
+	breaker := fb.NewBreaker(ds.ErrConcurrentTransaction)
+	breaker.BreakMethod("RunInTransaction", 10) // far more than the cap
+
+	raw := fb.FilterRDS(NewDatastore("app"), breaker)
+
+	attempts := 0
+	ctx := ds.WithTransactionRetryObserver(context.Background(), func(int, error) { attempts++ })
+
+	err := raw.RunInTransaction(ctx, func(context.Context) error {
+		t.Fatal("transaction body should never succeed within the forced-failure window")
+		return nil
+	}, &ds.TransactionOptions{Attempts: 3})
+
+	if err != ds.ErrConcurrentTransaction {
+		t.Fatalf("RunInTransaction err = %v, want ErrConcurrentTransaction", err)
+	}
+	if attempts != 3 {
+		t.Errorf("observed %d retry attempts, want 3 (capped)", attempts)
+	}
+}
@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	fb "github.com/luci/gae/filter/featureBreaker"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestTransactionRetryObserver(t *testing.T) {
+	breaker := fb.NewBreaker(ds.ErrConcurrentTransaction)
+	breaker.BreakMethod("RunInTransaction", 2) // fail twice, then succeed
+
+	raw := fb.FilterRDS(NewDatastore("app"), breaker)
+
+	var observed []int
+	ctx := ds.WithTransactionRetryObserver(context.Background(), func(attempt int, err error) {
+		observed = append(observed, attempt)
+		if err != ds.ErrConcurrentTransaction {
+			t.Errorf("observer got err=%v, want ErrConcurrentTransaction", err)
+		}
+	})
+
+	ran := 0
+	err := raw.RunInTransaction(ctx, func(context.Context) error {
+		ran++
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+	if ran != 1 {
+		t.Errorf("transaction body ran %d times, want 1 (only on the successful attempt)", ran)
+	}
+	if len(observed) != 2 || observed[0] != 1 || observed[1] != 2 {
+		t.Errorf("observer saw attempts %v, want [1 2]", observed)
+	}
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type widget struct {
+	Name  string
+	Count int64
+}
+
+func TestGetTPutTRoundTrip(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "gizmo", 0, nil)
+
+	in := &widget{Name: "gizmo", Count: 3}
+	if err := ds.PutT(ctx, k, in); err != nil {
+		t.Fatalf("PutT: %v", err)
+	}
+
+	out, err := ds.GetT[widget](ctx, k)
+	if err != nil {
+		t.Fatalf("GetT: %v", err)
+	}
+	if *out != *in {
+		t.Errorf("GetT = %+v, want %+v", out, in)
+	}
+}
+
+func TestGetAllT(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), NewDatastore("app"))
+
+	for i, name := range []string{"a", "b", "c"} {
+		k := ds.NewKey("app", "", "Widget", name, 0, nil)
+		if err := ds.PutT(ctx, k, &widget{Name: name, Count: int64(i)}); err != nil {
+			t.Fatalf("PutT(%s): %v", name, err)
+		}
+	}
+
+	got, err := ds.GetAllT[widget](ctx, ds.NewQuery("Widget"))
+	if err != nil {
+		t.Fatalf("GetAllT: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("GetAllT returned %d results, want 3", len(got))
+	}
+}
@@ -0,0 +1,37 @@
+package datastore
+
+import "context"
+
+// AllocateIDsRetrying assigns IDs to incompleteKeys, retrying up to
+// attempts times while isTransient(err) reports true for the error a
+// retry returned, and giving up immediately on the first non-transient
+// error (or once attempts is exhausted).
+//
+// This package has no standalone AllocateIDs RPC to retry: an
+// incomplete key only gets an ID as a side effect of PutMulti (see
+// RawInterface.PutMulti's doc, "the (possibly newly-allocated) keys").
+// AllocateIDsRetrying therefore Puts an empty PropertyMap under each
+// key and returns the resulting assigned keys, discarding the written
+// values; callers after real entities should simply PutMulti their
+// data directly; this helper is for callers that only want IDs
+// reserved ahead of time, accepting that it leaves an empty placeholder
+// entity behind under each one until it's overwritten.
+func AllocateIDsRetrying(ctx context.Context, incompleteKeys []*Key, attempts int, isTransient func(error) bool) ([]*Key, error) {
+	vals := make([]PropertyMap, len(incompleteKeys))
+	for i := range vals {
+		vals[i] = PropertyMap{}
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		keys, err := Raw(ctx).PutMulti(incompleteKeys, vals)
+		if err == nil {
+			return keys, nil
+		}
+		lastErr = err
+		if !isTransient(err) {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,53 @@
+package datastore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luci/gae/filter/featureBreaker"
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestAllocateIDsRetryingSucceedsAfterTransientFailures(t *testing.T) {
+	boom := errors.New("boom")
+	b := featureBreaker.NewBreaker(boom)
+	b.BreakMethod("PutMulti", 2) // fail the first 2 attempts, succeed the 3rd
+	ctx := ds.SetRaw(context.Background(), featureBreaker.FilterRaw(memory.NewDatastore("app"), b))
+
+	incomplete := []*ds.Key{ds.NewKey("app", "", "Widget", "", 0, nil)}
+	keys, err := ds.AllocateIDsRetrying(ctx, incomplete, 3, func(error) bool { return true })
+	if err != nil {
+		t.Fatalf("AllocateIDsRetrying: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Incomplete() {
+		t.Fatalf("keys = %v, want one complete key", keys)
+	}
+}
+
+func TestAllocateIDsRetryingGivesUpOnNonTransientError(t *testing.T) {
+	boom := errors.New("boom")
+	b := featureBreaker.NewBreaker(boom)
+	b.BreakMethod("PutMulti", 1)
+	ctx := ds.SetRaw(context.Background(), featureBreaker.FilterRaw(memory.NewDatastore("app"), b))
+
+	incomplete := []*ds.Key{ds.NewKey("app", "", "Widget", "", 0, nil)}
+	_, err := ds.AllocateIDsRetrying(ctx, incomplete, 3, func(error) bool { return false })
+	if err != boom {
+		t.Fatalf("err = %v, want the non-transient error returned immediately", err)
+	}
+}
+
+func TestAllocateIDsRetryingExhaustsAttempts(t *testing.T) {
+	boom := errors.New("boom")
+	b := featureBreaker.NewBreaker(boom)
+	b.BreakMethod("PutMulti", 5)
+	ctx := ds.SetRaw(context.Background(), featureBreaker.FilterRaw(memory.NewDatastore("app"), b))
+
+	incomplete := []*ds.Key{ds.NewKey("app", "", "Widget", "", 0, nil)}
+	_, err := ds.AllocateIDsRetrying(ctx, incomplete, 3, func(error) bool { return true })
+	if err != boom {
+		t.Fatalf("err = %v, want boom after exhausting attempts", err)
+	}
+}
@@ -0,0 +1,201 @@
+package datastore
+
+import "context"
+
+// defaultGetMultiBatchSize bounds how many keys batchFilter sends to the
+// inner RawInterface in a single GetMulti call; larger calls are split
+// into sequential sub-batches of at most this many keys.
+const defaultGetMultiBatchSize = 500
+
+type failFastMultiKeyType struct{}
+
+var failFastMultiKey failFastMultiKeyType
+
+// WithFailFastMulti returns a context in which batchFilter's GetMulti
+// aborts as soon as any sub-batch fails with a non-per-item error (an
+// error other than a MultiError, e.g. an RPC failure affecting the whole
+// sub-batch), instead of running every remaining sub-batch to
+// completion. Per-item errors (like ErrNoSuchEntity, carried inside a
+// MultiError) never trigger this, since they mean the sub-batch itself
+// succeeded, just with some keys missing. Keys in sub-batches that were
+// never attempted because of an abort come back as ErrBatchCancelled.
+func WithFailFastMulti(ctx context.Context) context.Context {
+	return context.WithValue(ctx, failFastMultiKey, true)
+}
+
+func failFastMulti(ctx context.Context) bool {
+	v, _ := ctx.Value(failFastMultiKey).(bool)
+	return v
+}
+
+type errorThresholdKeyType struct{}
+
+var errorThresholdKey errorThresholdKeyType
+
+// WithErrorThreshold returns a context in which batchFilter's GetMulti
+// aborts once maxErrors or more failed keys have accumulated across
+// sub-batches (each per-item error, e.g. an ErrNoSuchEntity entry,
+// counts once, and a sub-batch that fails as a whole counts every one
+// of its keys), instead of running
+// every remaining sub-batch to completion, on the theory that a bulk
+// read failing this broadly indicates a systemic problem rather than a
+// few missing entities. It returns the errors collected before the
+// abort; keys in sub-batches never attempted come back as
+// ErrBatchCancelled, the same as WithFailFastMulti. The default, an
+// unconfigured ctx, is unlimited.
+//
+// PutMulti and DeleteMulti aren't split into sub-batches (there is
+// nothing to abort between — see batchFilter's doc comment), so
+// WithErrorThreshold currently has no effect on them.
+func WithErrorThreshold(ctx context.Context, maxErrors int) context.Context {
+	return context.WithValue(ctx, errorThresholdKey, maxErrors)
+}
+
+func errorThreshold(ctx context.Context) (int, bool) {
+	v, ok := ctx.Value(errorThresholdKey).(int)
+	return v, ok
+}
+
+type queryProgressKeyType struct{}
+
+var queryProgressKey queryProgressKeyType
+
+// WithQueryProgressCallback returns a context in which batchFilter's Run
+// calls progress with the cumulative count of results emitted so far,
+// immediately after each one is delivered to the query's own callback,
+// so a long-running GetAll/Run caller can drive a progress indicator
+// without its own counting wrapper.
+func WithQueryProgressCallback(ctx context.Context, progress func(processed int)) context.Context {
+	return context.WithValue(ctx, queryProgressKey, progress)
+}
+
+func queryProgressCallback(ctx context.Context) func(int) {
+	cb, _ := ctx.Value(queryProgressKey).(func(int))
+	return cb
+}
+
+type withoutBatchingKeyType struct{}
+
+var withoutBatchingKey withoutBatchingKeyType
+
+// WithoutBatching returns a context in which batchFilter's GetMulti
+// sends every key in a single call to the inner RawInterface, instead
+// of splitting calls over defaultGetMultiBatchSize keys into sequential
+// sub-batches. Use it for the rare call whose backend enforces its own,
+// larger per-RPC limit and that wants to reach that limit directly
+// instead of paying for extra round trips; it does not change the
+// zero-key guard, and WithFailFastMulti has no effect on a call made
+// without batching (there's only ever one sub-batch).
+func WithoutBatching(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutBatchingKey, true)
+}
+
+func withoutBatching(ctx context.Context) bool {
+	v, _ := ctx.Value(withoutBatchingKey).(bool)
+	return v
+}
+
+// batchFilter wraps a RawInterface so that GetMulti/PutMulti/DeleteMulti
+// called with zero keys return immediately without ever reaching the
+// inner RawInterface. Passing an empty slice happens often enough in
+// generic call sites (e.g. a filtered-down batch) that it's worth
+// avoiding the wasted RPC some backends would otherwise issue. It also
+// splits large GetMulti calls into sequential sub-batches (see
+// WithFailFastMulti and WithoutBatching). PutMulti and DeleteMulti's
+// per-item errors are annotated with the key they happened to (see
+// keyedError) so a failing batch's logged error names the entity, not
+// just the bare underlying error repeated once per failure.
+//
+// It is installed automatically by SetRaw, so every RawInterface
+// obtained via Raw(ctx) already has this guarantee.
+type batchFilter struct {
+	RawInterface
+	failFast     bool
+	noBatch      bool
+	progress     func(int)
+	maxErrors    int
+	hasMaxErrors bool
+}
+
+func (b batchFilter) Run(q *Query, cb RunCB) error {
+	if b.progress == nil {
+		return b.RawInterface.Run(q, cb)
+	}
+	n := 0
+	return b.RawInterface.Run(q, func(k *Key, pm PropertyMap) error {
+		if err := cb(k, pm); err != nil {
+			return err
+		}
+		n++
+		b.progress(n)
+		return nil
+	})
+}
+
+func (b batchFilter) GetMulti(keys []*Key, vals []PropertyMap) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if b.noBatch || len(keys) <= defaultGetMultiBatchSize {
+		return b.RawInterface.GetMulti(keys, vals)
+	}
+
+	merged := make(MultiError, len(keys))
+	anyErr := false
+	errCount := 0
+	for start := 0; start < len(keys); start += defaultGetMultiBatchSize {
+		end := start + defaultGetMultiBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		err := b.RawInterface.GetMulti(keys[start:end], vals[start:end])
+		if err == nil {
+			continue
+		}
+		anyErr = true
+
+		me, isPerItem := err.(MultiError)
+		if isPerItem {
+			copy(merged[start:end], me)
+			for _, e := range me {
+				if e != nil {
+					errCount++
+				}
+			}
+		} else {
+			// A non-per-item error: every key in this sub-batch failed with it.
+			for i := start; i < end; i++ {
+				merged[i] = err
+			}
+			errCount += end - start
+		}
+
+		abort := (b.failFast && !isPerItem) || (b.hasMaxErrors && errCount >= b.maxErrors)
+		if abort {
+			for i := end; i < len(keys); i++ {
+				merged[i] = ErrBatchCancelled
+			}
+			return merged
+		}
+	}
+	if !anyErr {
+		return nil
+	}
+	return merged
+}
+
+func (b batchFilter) PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	outKeys, err := b.RawInterface.PutMulti(keys, vals)
+	return outKeys, annotateWithKeys(keys, err)
+}
+
+func (b batchFilter) DeleteMulti(keys []*Key) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return annotateWithKeys(keys, b.RawInterface.DeleteMulti(keys))
+}
@@ -0,0 +1,98 @@
+package datastore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luci/gae/filter/featureBreaker"
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+// subBatchCounter counts how many GetMulti calls actually reach the
+// wrapped RawInterface, so a test can tell whether a later sub-batch was
+// attempted after an earlier one failed.
+type subBatchCounter struct {
+	ds.RawInterface
+	calls *int
+}
+
+func (c subBatchCounter) GetMulti(keys []*ds.Key, vals []ds.PropertyMap) error {
+	*c.calls++
+	return c.RawInterface.GetMulti(keys, vals)
+}
+
+func manyKeys(n int) []*ds.Key {
+	keys := make([]*ds.Key, n)
+	for i := range keys {
+		keys[i] = ds.NewKey("app", "", "Widget", "", int64(i+1), nil)
+	}
+	return keys
+}
+
+func TestWithFailFastMultiAbortsRemainingSubBatches(t *testing.T) {
+	calls := 0
+	counted := subBatchCounter{RawInterface: memory.NewDatastore("app"), calls: &calls}
+	b := featureBreaker.NewBreaker(errors.New("boom"))
+	b.BreakMethod("GetMulti", 1) // fail only the first sub-batch call
+	broken := featureBreaker.FilterRaw(counted, b)
+
+	ctx := ds.SetRaw(context.Background(), broken)
+	ctx = ds.WithFailFastMulti(ctx)
+
+	keys := manyKeys(1200) // 3 sub-batches of <=500
+	vals := make([]ds.PropertyMap, len(keys))
+	for i := range vals {
+		vals[i] = ds.PropertyMap{}
+	}
+
+	err := ds.Raw(ctx).GetMulti(keys, vals)
+	me, ok := err.(ds.MultiError)
+	if !ok {
+		t.Fatalf("GetMulti err = %v (%T), want a MultiError", err, err)
+	}
+	if len(me) != len(keys) {
+		t.Fatalf("MultiError has %d entries, want %d", len(me), len(keys))
+	}
+	for i, e := range me[500:] {
+		if !errors.Is(e, ds.ErrBatchCancelled) {
+			t.Fatalf("key %d err = %v, want ErrBatchCancelled", 500+i, e)
+			break
+		}
+	}
+
+	if calls != 0 {
+		t.Errorf("inner GetMulti reached %d times, want 0 (the breaker should have failed the first sub-batch before it got there)", calls)
+	}
+}
+
+func TestWithoutFailFastMultiRunsAllSubBatches(t *testing.T) {
+	calls := 0
+	counted := subBatchCounter{RawInterface: memory.NewDatastore("app"), calls: &calls}
+	ctx := ds.SetRaw(context.Background(), counted)
+
+	keys := manyKeys(1200)
+	vals := make([]ds.PropertyMap, len(keys))
+	for i := range vals {
+		vals[i] = ds.PropertyMap{}
+	}
+
+	err := ds.Raw(ctx).GetMulti(keys, vals)
+	me, ok := err.(ds.MultiError)
+	if !ok {
+		t.Fatalf("GetMulti err = %v (%T), want a MultiError (all keys missing)", err, err)
+	}
+	if len(me) != len(keys) {
+		t.Fatalf("MultiError has %d entries, want %d", len(me), len(keys))
+	}
+	for i, e := range me {
+		if !errors.Is(e, ds.ErrNoSuchEntity) {
+			t.Fatalf("key %d err = %v, want ErrNoSuchEntity", i, e)
+		}
+	}
+
+	if calls != 3 {
+		t.Errorf("inner GetMulti reached %d times, want 3 (all sub-batches attempted)", calls)
+	}
+}
@@ -0,0 +1,42 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+)
+
+// countingFilter counts calls that reach the wrapped RawInterface, to
+// let tests assert whether a call was actually forwarded.
+type countingFilter struct {
+	RawInterface
+	calls *int
+}
+
+func (c countingFilter) GetMulti(keys []*Key, vals []PropertyMap) error {
+	*c.calls++
+	return c.RawInterface.GetMulti(keys, vals)
+}
+
+func TestEmptyGetMultiShortCircuits(t *testing.T) {
+	calls := 0
+	ctx := context.Background()
+	ctx = SetRaw(ctx, fakeRaw{})
+	ctx = AddRawFilters(ctx, func(_ context.Context, raw RawInterface) RawInterface {
+		return countingFilter{RawInterface: raw, calls: &calls}
+	})
+
+	if err := Raw(ctx).GetMulti(nil, nil); err != nil {
+		t.Fatalf("GetMulti(empty): %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("inner GetMulti called %d times for empty input, want 0", calls)
+	}
+
+	k := NewKey("app", "", "Kind", "a", 0, nil)
+	if err := Raw(ctx).GetMulti([]*Key{k}, []PropertyMap{{}}); err == nil {
+		t.Fatalf("expected fakeRaw's MultiError for a non-empty call")
+	}
+	if calls != 1 {
+		t.Errorf("inner GetMulti called %d times for non-empty input, want 1", calls)
+	}
+}
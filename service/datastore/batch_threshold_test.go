@@ -0,0 +1,73 @@
+package datastore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luci/gae/filter/featureBreaker"
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestWithErrorThresholdAbortsOnceExceeded(t *testing.T) {
+	calls := 0
+	counted := subBatchCounter{RawInterface: memory.NewDatastore("app"), calls: &calls}
+	b := featureBreaker.NewBreaker(errors.New("boom"))
+	b.BreakMethod("GetMulti", 2) // fail the first two 500-key sub-batches wholesale
+	broken := featureBreaker.FilterRaw(counted, b)
+
+	ctx := ds.SetRaw(context.Background(), broken)
+	ctx = ds.WithErrorThreshold(ctx, 500) // first sub-batch alone already meets this
+
+	keys := manyKeys(1200)
+	vals := make([]ds.PropertyMap, len(keys))
+	for i := range vals {
+		vals[i] = ds.PropertyMap{}
+	}
+
+	err := ds.Raw(ctx).GetMulti(keys, vals)
+	me, ok := err.(ds.MultiError)
+	if !ok {
+		t.Fatalf("GetMulti err = %v (%T), want a MultiError", err, err)
+	}
+	for i, e := range me[500:] {
+		if !errors.Is(e, ds.ErrBatchCancelled) {
+			t.Fatalf("key %d err = %v, want ErrBatchCancelled", 500+i, e)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("inner GetMulti reached %d times, want 0 (breaker fails sub-batches before they get there)", calls)
+	}
+}
+
+func TestWithErrorThresholdToleratesErrorsBelowLimit(t *testing.T) {
+	calls := 0
+	counted := subBatchCounter{RawInterface: memory.NewDatastore("app"), calls: &calls}
+	b := featureBreaker.NewBreaker(errors.New("boom"))
+	b.BreakMethod("GetMulti", 1) // fail only the first sub-batch wholesale
+	broken := featureBreaker.FilterRaw(counted, b)
+
+	ctx := ds.SetRaw(context.Background(), broken)
+	ctx = ds.WithErrorThreshold(ctx, 10000) // never exceeded by 1200 keys
+
+	keys := manyKeys(1200)
+	vals := make([]ds.PropertyMap, len(keys))
+	for i := range vals {
+		vals[i] = ds.PropertyMap{}
+	}
+
+	err := ds.Raw(ctx).GetMulti(keys, vals)
+	me, ok := err.(ds.MultiError)
+	if !ok {
+		t.Fatalf("GetMulti err = %v (%T), want a MultiError", err, err)
+	}
+	for i, e := range me[500:] {
+		if errors.Is(e, ds.ErrBatchCancelled) {
+			t.Fatalf("key %d err = %v, should not have been cancelled (threshold not exceeded)", 500+i, e)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("inner GetMulti reached %d times, want 2 (the remaining sub-batches after the first, broken one)", calls)
+	}
+}
@@ -0,0 +1,57 @@
+package datastore_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestOpenBlobPropertyReadsBytesThroughReader(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Blob", "b1", 0, nil)
+	want := []byte("a large blob of bytes")
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Data": ds.MkProperty(want)}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	rc, err := ds.OpenBlobProperty(ctx, k, "Data")
+	if err != nil {
+		t.Fatalf("OpenBlobProperty: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestOpenBlobPropertyErrorsForMissingProperty(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Blob", "b1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	if _, err := ds.OpenBlobProperty(ctx, k, "Data"); err == nil {
+		t.Fatalf("expected an error for a missing property")
+	}
+}
+
+func TestOpenBlobPropertyErrorsForWrongType(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Blob", "b1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Data": ds.MkProperty("not-bytes")}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	if _, err := ds.OpenBlobProperty(ctx, k, "Data"); err == nil {
+		t.Fatalf("expected an error for a non-[]byte property")
+	}
+}
@@ -0,0 +1,65 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestCompareAndMutateAppliesWhenCondTrue(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Account", "", 1, nil)
+
+	ok, err := ds.CompareAndMutate(ctx, k,
+		func(pm ds.PropertyMap) bool { return len(pm) == 0 },
+		func() []ds.Mutation {
+			return []ds.Mutation{ds.PutMutation(k, ds.PropertyMap{"Balance": int64(100)})}
+		})
+	if err != nil {
+		t.Fatalf("CompareAndMutate: %v", err)
+	}
+	if !ok {
+		t.Fatalf("CompareAndMutate returned false, want true for a nonexistent entity")
+	}
+
+	var out ds.PropertyMap
+	out = ds.PropertyMap{}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, []ds.PropertyMap{out}); err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if out["Balance"].(int64) != 100 {
+		t.Errorf("Balance = %v, want 100", out["Balance"])
+	}
+}
+
+func TestCompareAndMutateSkipsWhenCondFalse(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Account", "", 1, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Balance": int64(50)}}); err != nil {
+		t.Fatalf("seed PutMulti: %v", err)
+	}
+
+	ok, err := ds.CompareAndMutate(ctx, k,
+		func(pm ds.PropertyMap) bool { return pm["Balance"].(int64) >= 100 },
+		func() []ds.Mutation {
+			t.Fatalf("mutate should not be called when cond is false")
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("CompareAndMutate: %v", err)
+	}
+	if ok {
+		t.Errorf("CompareAndMutate returned true, want false")
+	}
+
+	var out ds.PropertyMap
+	out = ds.PropertyMap{}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, []ds.PropertyMap{out}); err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if out["Balance"].(int64) != 50 {
+		t.Errorf("Balance changed to %v, want unchanged 50", out["Balance"])
+	}
+}
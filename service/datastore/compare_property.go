@@ -0,0 +1,134 @@
+package datastore
+
+import (
+	"bytes"
+	"time"
+)
+
+// propertyTypeRank assigns the cross-type ordering CompareProperty falls
+// back to when a and b hold values of different Go types, following
+// Cloud Datastore's own mixed-type property ordering: nil < bool <
+// int64 < float64 < time.Time < string < []byte < *Key.
+func propertyTypeRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case int64:
+		return 2
+	case float64:
+		return 3
+	case time.Time:
+		return 4
+	case string:
+		return 5
+	case []byte:
+		return 6
+	case *Key:
+		return 7
+	default:
+		return 8
+	}
+}
+
+// CompareProperty returns -1, 0, or 1 as a's value sorts before, equal
+// to, or after b's — the ordering a client-side filter or sort needs to
+// replicate Cloud Datastore's own index order. Values of different
+// types never compare equal; they sort by propertyTypeRank.
+//
+// impl/memory, this package's in-memory backend, has no internal
+// per-property ordering index to reuse here: its Run sorts results
+// purely by each entity's encoded key (see encodeKey in
+// impl/memory/memory.go) and does not evaluate Query.Filters/Orders at
+// all. CompareProperty is therefore a fresh implementation, following
+// the same type-then-value comparison shape encodeKey uses for keys.
+func CompareProperty(a, b Property) int {
+	av, bv := a.Value(), b.Value()
+	if ar, br := propertyTypeRank(av), propertyTypeRank(bv); ar != br {
+		return compareInt(ar, br)
+	}
+
+	switch x := av.(type) {
+	case nil:
+		return 0
+	case bool:
+		y := bv.(bool)
+		if x == y {
+			return 0
+		}
+		if !x {
+			return -1
+		}
+		return 1
+	case int64:
+		return compareInt64(x, bv.(int64))
+	case float64:
+		return compareFloat64(x, bv.(float64))
+	case time.Time:
+		y := bv.(time.Time)
+		switch {
+		case x.Before(y):
+			return -1
+		case x.After(y):
+			return 1
+		default:
+			return 0
+		}
+	case string:
+		return compareString(x, bv.(string))
+	case []byte:
+		return bytes.Compare(x, bv.([]byte))
+	case *Key:
+		// Not a true Cloud Datastore key order (this package has no key
+		// codec to reuse), but stable and sufficient for comparing keys
+		// of the same kind, which is the common case for a sort term.
+		return compareString(x.String(), bv.(*Key).String())
+	default:
+		return 0
+	}
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareString(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
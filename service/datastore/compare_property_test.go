@@ -0,0 +1,62 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComparePropertySameType(t *testing.T) {
+	cases := []struct {
+		a, b interface{}
+		want int
+	}{
+		{int64(1), int64(2), -1},
+		{int64(2), int64(2), 0},
+		{int64(3), int64(2), 1},
+		{"a", "b", -1},
+		{"b", "b", 0},
+		{"c", "b", 1},
+		{false, true, -1},
+		{true, true, 0},
+		{1.5, 2.5, -1},
+	}
+	for _, c := range cases {
+		got := CompareProperty(MkProperty(c.a), MkProperty(c.b))
+		if got != c.want {
+			t.Errorf("CompareProperty(%v, %v) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestComparePropertyDifferentTypesOrderByRank(t *testing.T) {
+	lo := MkProperty(int64(1000000))
+	hi := MkProperty("a")
+	if got := CompareProperty(lo, hi); got != -1 {
+		t.Errorf("CompareProperty(int64, string) = %d, want -1 (int64 ranks before string)", got)
+	}
+	if got := CompareProperty(hi, lo); got != 1 {
+		t.Errorf("CompareProperty(string, int64) = %d, want 1", got)
+	}
+}
+
+func TestComparePropertyTime(t *testing.T) {
+	now := time.Unix(1000, 0)
+	later := now.Add(time.Hour)
+	if got := CompareProperty(MkProperty(now), MkProperty(later)); got != -1 {
+		t.Errorf("CompareProperty(now, later) = %d, want -1", got)
+	}
+	if got := CompareProperty(MkProperty(now), MkProperty(now)); got != 0 {
+		t.Errorf("CompareProperty(now, now) = %d, want 0", got)
+	}
+}
+
+func TestComparePropertyKeysOfSameKindAreStable(t *testing.T) {
+	k1 := NewKey("app", "", "Widget", "a", 0, nil)
+	k2 := NewKey("app", "", "Widget", "b", 0, nil)
+	if got := CompareProperty(MkProperty(k1), MkProperty(k2)); got != -1 {
+		t.Errorf("CompareProperty(k1, k2) = %d, want -1", got)
+	}
+	if got := CompareProperty(MkProperty(k1), MkProperty(k1)); got != 0 {
+		t.Errorf("CompareProperty(k1, k1) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,60 @@
+package datastore
+
+import "context"
+
+type rawKeyType struct{}
+
+var rawKey rawKeyType
+
+// SetRaw installs raw as the datastore.RawInterface implementation for
+// ctx. Service backends (impl/memory, impl/cloud, ...) call this from
+// their Use() helpers; application code should not normally need to.
+func SetRaw(ctx context.Context, raw RawInterface) context.Context {
+	return context.WithValue(ctx, rawKey, raw)
+}
+
+// CtxBinder is implemented by backends that need the context of each
+// individual call, rather than just the one in effect when they were
+// installed via SetRaw (for example, to honor ctx cancellation). Raw(ctx)
+// calls BindCtx with the call's own ctx before applying filters, so every
+// RawInterface method the caller invokes sees it.
+type CtxBinder interface {
+	BindCtx(ctx context.Context) RawInterface
+}
+
+// Raw returns the RawInterface installed in ctx, with every filter added
+// via AddRawFilters applied on top, outermost-last, then the zero-input
+// batch guard (see batch.go), then the global read-only guard (see
+// global_readonly.go) outermost of all, so that read-only mode rejects a
+// write before it even reaches the batch guard, any AddRawFilters
+// filter, or the backend. It panics if no RawInterface has been
+// installed.
+func Raw(ctx context.Context) RawInterface {
+	raw, ok := ctx.Value(rawKey).(RawInterface)
+	if !ok {
+		panic("datastore: no RawInterface installed in context; did you forget to call Use()?")
+	}
+	if b, ok := raw.(CtxBinder); ok {
+		raw = b.BindCtx(ctx)
+	}
+	maxErrors, hasMaxErrors := errorThreshold(ctx)
+	batched := batchFilter{
+		RawInterface: ApplyRawFilters(ctx, raw),
+		failFast:     failFastMulti(ctx),
+		noBatch:      withoutBatching(ctx),
+		progress:     queryProgressCallback(ctx),
+		maxErrors:    maxErrors,
+		hasMaxErrors: hasMaxErrors,
+	}
+	return readOnlyFilter{RawInterface: batched}
+}
+
+// RawUnfiltered returns the RawInterface installed in ctx by SetRaw
+// exactly as given, without the batch guard or any AddRawFilters filters
+// applied. Backend Testable helpers use this to reach the concrete
+// implementation (e.g. to type-assert it) regardless of what filters a
+// test has installed.
+func RawUnfiltered(ctx context.Context) RawInterface {
+	raw, _ := ctx.Value(rawKey).(RawInterface)
+	return raw
+}
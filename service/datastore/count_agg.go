@@ -0,0 +1,41 @@
+package datastore
+
+import "context"
+
+// AggregationCounter is optionally implemented by a RawInterface that
+// can count a query's matches using a backend aggregation RPC (e.g.
+// Cloud Datastore's COUNT aggregation query) instead of streaming every
+// matching key through Run, as the default Count does. CountAgg
+// consults it when present.
+type AggregationCounter interface {
+	CountAggregation(q *Query) (int64, error)
+}
+
+// CountAgg reports the number of entities q matches, the same as
+// Raw(ctx).Count, but uses the installed RawInterface's aggregation RPC
+// when it implements AggregationCounter, which is considerably cheaper
+// for large result sets than impl/memory's and older backends' streaming
+// Count.
+//
+// AggregationCounter is checked against the backend installed via
+// SetRaw (after BindCtx, like CtxBinder), not the filtered RawInterface
+// Raw(ctx) returns: a Filter wraps RawInterface in a new struct that
+// doesn't promote extra methods the backend it wraps happens to have, so
+// checking the filtered value would never see AggregationCounter even
+// when the backend implements it. When the backend doesn't implement
+// AggregationCounter, CountAgg falls back to the fully filtered
+// Raw(ctx).Count, so filter behavior (namespace scoping, key validation,
+// ...) still applies to the fallback path.
+func CountAgg(ctx context.Context, q *Query) (int64, error) {
+	raw, ok := ctx.Value(rawKey).(RawInterface)
+	if !ok {
+		panic("datastore: no RawInterface installed in context; did you forget to call Use()?")
+	}
+	if b, ok := raw.(CtxBinder); ok {
+		raw = b.BindCtx(ctx)
+	}
+	if ac, ok := raw.(AggregationCounter); ok {
+		return ac.CountAggregation(q)
+	}
+	return Raw(ctx).Count(q)
+}
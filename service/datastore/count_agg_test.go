@@ -0,0 +1,69 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+)
+
+type countingQueryRaw struct {
+	dummyRaw
+	keys []*Key
+}
+
+func (r *countingQueryRaw) Run(q *Query, cb RunCB) error {
+	for _, k := range r.keys {
+		if err := cb(k, PropertyMap{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *countingQueryRaw) Count(q *Query) (int64, error) {
+	return int64(len(r.keys)), nil
+}
+
+func TestCountAggFallsBackToStreamingCount(t *testing.T) {
+	raw := &countingQueryRaw{keys: []*Key{
+		NewKey("app", "", "Widget", "a", 0, nil),
+		NewKey("app", "", "Widget", "b", 0, nil),
+	}}
+	ctx := SetRaw(context.Background(), raw)
+
+	got, err := CountAgg(ctx, NewQuery("Widget"))
+	if err != nil {
+		t.Fatalf("CountAgg: %v", err)
+	}
+	if got != 2 {
+		t.Errorf("CountAgg = %d, want 2", got)
+	}
+}
+
+type aggregatingRaw struct {
+	dummyRaw
+	count     int64
+	queried   *Query
+	aggCalled bool
+}
+
+func (r *aggregatingRaw) CountAggregation(q *Query) (int64, error) {
+	r.aggCalled = true
+	r.queried = q
+	return r.count, nil
+}
+
+func TestCountAggUsesAggregationWhenAvailable(t *testing.T) {
+	raw := &aggregatingRaw{count: 42}
+	ctx := SetRaw(context.Background(), raw)
+
+	got, err := CountAgg(ctx, NewQuery("Widget"))
+	if err != nil {
+		t.Fatalf("CountAgg: %v", err)
+	}
+	if got != 42 {
+		t.Errorf("CountAgg = %d, want 42", got)
+	}
+	if !raw.aggCalled {
+		t.Error("CountAgg did not use the backend's CountAggregation")
+	}
+}
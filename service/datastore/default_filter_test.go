@@ -0,0 +1,55 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegisterDefaultFilterAppliesWithoutPerContextAddRawFilters(t *testing.T) {
+	calls := 0
+	RegisterDefaultFilter(func(_ context.Context, raw RawInterface) RawInterface {
+		return countingFilter{RawInterface: raw, calls: &calls}
+	})
+	t.Cleanup(func() { defaultFilters = nil })
+
+	ctx := SetRaw(context.Background(), fakeRaw{})
+
+	k := NewKey("app", "", "Kind", "a", 0, nil)
+	if err := Raw(ctx).GetMulti([]*Key{k}, []PropertyMap{{}}); err == nil {
+		t.Fatalf("expected fakeRaw's MultiError for a non-empty call")
+	}
+	if calls != 1 {
+		t.Errorf("default filter saw %d calls, want 1 (no AddRawFilters call was made at this call site)", calls)
+	}
+}
+
+func TestRegisterDefaultFilterRunsBeforePerContextFilters(t *testing.T) {
+	var order []string
+	RegisterDefaultFilter(func(_ context.Context, raw RawInterface) RawInterface {
+		return orderRecordingFilter{RawInterface: raw, name: "default", order: &order}
+	})
+	t.Cleanup(func() { defaultFilters = nil })
+
+	ctx := SetRaw(context.Background(), fakeRaw{})
+	ctx = AddRawFilters(ctx, func(_ context.Context, raw RawInterface) RawInterface {
+		return orderRecordingFilter{RawInterface: raw, name: "per-context", order: &order}
+	})
+
+	k := NewKey("app", "", "Kind", "a", 0, nil)
+	Raw(ctx).GetMulti([]*Key{k}, []PropertyMap{{}})
+
+	if len(order) != 2 || order[0] != "per-context" || order[1] != "default" {
+		t.Errorf("call order = %v, want [per-context default] (per-context filters wrap, and so run before, default filters)", order)
+	}
+}
+
+type orderRecordingFilter struct {
+	RawInterface
+	name  string
+	order *[]string
+}
+
+func (f orderRecordingFilter) GetMulti(keys []*Key, vals []PropertyMap) error {
+	*f.order = append(*f.order, f.name)
+	return f.RawInterface.GetMulti(keys, vals)
+}
@@ -0,0 +1,95 @@
+package datastore
+
+import (
+	"context"
+	"reflect"
+)
+
+// Mismatch describes a single key present in both backends with
+// differing property values.
+type Mismatch struct {
+	Key *Key
+	A   PropertyMap
+	B   PropertyMap
+}
+
+// DiffReport is the result of DiffKind.
+type DiffReport struct {
+	OnlyInA    []*Key
+	OnlyInB    []*Key
+	Mismatched []Mismatch
+}
+
+type kv struct {
+	key *Key
+	pm  PropertyMap
+}
+
+// streamSorted runs q against raw and sends every result, in the
+// ascending key order that Run over a single kind is expected to
+// produce, to out, which is closed when iteration finishes. If Run
+// fails, its error is sent to errc; errc itself is never closed.
+func streamSorted(raw RawInterface, kind string, out chan<- kv, errc chan<- error) {
+	defer close(out)
+	err := raw.Run(NewQuery(kind), func(k *Key, pm PropertyMap) error {
+		out <- kv{key: k, pm: pm}
+		return nil
+	})
+	if err != nil {
+		errc <- err
+	}
+}
+
+// DiffKind compares every entity of kind between two RawInterfaces (e.g.
+// an old backend vs a new one during a migration) and reports keys only
+// present in a, keys only present in b, and keys present in both whose
+// PropertyMap differs.
+//
+// Both sides are streamed in ascending key order and merged with a
+// two-pointer scan, so apart from the returned report (which holds every
+// only-in key and every mismatched pair) memory use does not grow with
+// the size of the kind.
+func DiffKind(ctx context.Context, a, b RawInterface, kind string) (DiffReport, error) {
+	chanA, chanB := make(chan kv, 16), make(chan kv, 16)
+	errc := make(chan error, 2)
+
+	go streamSorted(a, kind, chanA, errc)
+	go streamSorted(b, kind, chanB, errc)
+
+	var report DiffReport
+	curA, okA := <-chanA
+	curB, okB := <-chanB
+
+	for okA && okB {
+		ka, kb := curA.key.String(), curB.key.String()
+		switch {
+		case ka < kb:
+			report.OnlyInA = append(report.OnlyInA, curA.key)
+			curA, okA = <-chanA
+		case ka > kb:
+			report.OnlyInB = append(report.OnlyInB, curB.key)
+			curB, okB = <-chanB
+		default:
+			if !reflect.DeepEqual(curA.pm, curB.pm) {
+				report.Mismatched = append(report.Mismatched, Mismatch{Key: curA.key, A: curA.pm, B: curB.pm})
+			}
+			curA, okA = <-chanA
+			curB, okB = <-chanB
+		}
+	}
+	for okA {
+		report.OnlyInA = append(report.OnlyInA, curA.key)
+		curA, okA = <-chanA
+	}
+	for okB {
+		report.OnlyInB = append(report.OnlyInB, curB.key)
+		curB, okB = <-chanB
+	}
+
+	select {
+	case err := <-errc:
+		return report, err
+	default:
+		return report, nil
+	}
+}
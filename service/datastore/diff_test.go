@@ -0,0 +1,44 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestDiffKind(t *testing.T) {
+	ctx := context.Background()
+	a := memory.NewDatastore("app")
+	b := memory.NewDatastore("app")
+
+	put := func(raw ds.RawInterface, id string, val int64) *ds.Key {
+		k := ds.NewKey("app", "", "Widget", id, 0, nil)
+		if _, err := raw.PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"V": val}}); err != nil {
+			t.Fatalf("PutMulti: %v", err)
+		}
+		return k
+	}
+
+	put(a, "only-a", 1)
+	put(b, "only-b", 2)
+	put(a, "same", 3)
+	put(b, "same", 3)
+	put(a, "diff", 4)
+	put(b, "diff", 5)
+
+	report, err := ds.DiffKind(ctx, a, b, "Widget")
+	if err != nil {
+		t.Fatalf("DiffKind: %v", err)
+	}
+	if len(report.OnlyInA) != 1 || report.OnlyInA[0].StringID() != "only-a" {
+		t.Errorf("OnlyInA = %v, want [only-a]", report.OnlyInA)
+	}
+	if len(report.OnlyInB) != 1 || report.OnlyInB[0].StringID() != "only-b" {
+		t.Errorf("OnlyInB = %v, want [only-b]", report.OnlyInB)
+	}
+	if len(report.Mismatched) != 1 || report.Mismatched[0].Key.StringID() != "diff" {
+		t.Errorf("Mismatched = %v, want [diff]", report.Mismatched)
+	}
+}
@@ -0,0 +1,22 @@
+package datastore
+
+import "context"
+
+// dummyRaw is a RawInterface whose every method panics. It is installed
+// in place of a real backend when a service is deliberately disabled, so
+// that a handler touching it fails loudly instead of silently hitting a
+// live backend.
+type dummyRaw struct{ reason string }
+
+// Dummy returns a RawInterface that panics with reason on every call.
+// impl/cloud uses this to back disabled services.
+func Dummy(reason string) RawInterface { return dummyRaw{reason: reason} }
+
+func (d dummyRaw) GetMulti(keys []*Key, vals []PropertyMap) error { panic(d.reason) }
+func (d dummyRaw) PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error) { panic(d.reason) }
+func (d dummyRaw) DeleteMulti(keys []*Key) error                           { panic(d.reason) }
+func (d dummyRaw) Run(q *Query, cb RunCB) error                            { panic(d.reason) }
+func (d dummyRaw) Count(q *Query) (int64, error)                          { panic(d.reason) }
+func (d dummyRaw) RunInTransaction(ctx context.Context, f func(context.Context) error, opts *TransactionOptions) error {
+	panic(d.reason)
+}
@@ -0,0 +1,51 @@
+package datastore
+
+import "errors"
+
+// ErrNoSuchEntity is returned (per-key, inside a MultiError) when a Get
+// finds no entity for the given key.
+var ErrNoSuchEntity = errors.New("datastore: no such entity")
+
+// ErrBatchCancelled marks, inside a MultiError returned by batchFilter's
+// GetMulti, a key whose sub-batch was never attempted because
+// WithFailFastMulti or WithErrorThreshold aborted the call after an
+// earlier sub-batch failed.
+var ErrBatchCancelled = errors.New("datastore: batch cancelled after an earlier sub-batch failed")
+
+// MultiError is returned by the *Multi family of calls, with one entry
+// per input item. A nil entry means that item succeeded.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	if len(m) == 0 {
+		return "(0 errors)"
+	}
+	n := 0
+	first := error(nil)
+	for _, e := range m {
+		if e != nil {
+			n++
+			if first == nil {
+				first = e
+			}
+		}
+	}
+	switch n {
+	case 0:
+		return "(0 errors)"
+	case 1:
+		return first.Error()
+	default:
+		return first.Error() + " (and more errors)"
+	}
+}
+
+// Any returns true if at least one entry of m is non-nil.
+func (m MultiError) Any() bool {
+	for _, e := range m {
+		if e != nil {
+			return true
+		}
+	}
+	return false
+}
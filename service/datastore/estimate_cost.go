@@ -0,0 +1,78 @@
+package datastore
+
+// CompositeIndex describes one multi-property index a Put may need an
+// extra index write for, the same shape a datastore-index.yaml entry
+// describes. EstimateCost is the only thing in this package that
+// currently consumes it; there is no broader composite-index registry
+// or enforcement here — see ErrIndexNotReady's doc comment, this
+// package already assumes indexes themselves are managed out of band.
+type CompositeIndex struct {
+	// Kind restricts this index to entities of the given kind.
+	Kind string
+	// Properties are the property names this index covers, in order.
+	Properties []string
+}
+
+// Cost estimates the Cloud Datastore "operation" counts production
+// billing is based on.
+type Cost struct {
+	// EntityReads is the estimated number of entity read operations:
+	// one per Delete, since removing an entity's index entries requires
+	// first reading which entries exist.
+	EntityReads int
+	// EntityWrites is the estimated number of entity write operations:
+	// one per Mutation, whether a Put or a Delete.
+	EntityWrites int
+	// IndexWrites is the estimated number of index write operations:
+	// two per indexed property on a Put (Cloud Datastore maintains both
+	// an ascending and a descending single-property index for every
+	// indexed property by default), plus one per CompositeIndex a Put's
+	// entity matches.
+	IndexWrites int
+}
+
+// EstimateCost returns the estimated operation cost of applying ops,
+// the same batch shape Mutate/CompareAndMutate accept, against the
+// composite indexes described by indexes. It is a pure function over
+// its arguments: it never reads from or calls into a backend, so its
+// estimate reflects only what ops and indexes describe, not whatever
+// indexes a real deployment's index.yaml actually declares.
+//
+// Every non-meta property on a Put's Value counts as indexed; this
+// package has no NoIndex/exclude-from-indexes concept yet (see
+// PropertyMap's doc comment on MetaPrefix), so EstimateCost can't
+// discount excluded properties the way production billing would.
+func EstimateCost(ops []Mutation, indexes []CompositeIndex) Cost {
+	var cost Cost
+	for _, m := range ops {
+		cost.EntityWrites++
+		if m.Value == nil {
+			cost.EntityReads++
+			continue
+		}
+		indexedProps := 0
+		for name := range m.Value {
+			if IsMetaKey(name) {
+				continue
+			}
+			indexedProps++
+		}
+		cost.IndexWrites += indexedProps * 2
+		for _, idx := range indexes {
+			if idx.Kind == m.Key.Kind() && coveredBy(m.Value, idx.Properties) {
+				cost.IndexWrites++
+			}
+		}
+	}
+	return cost
+}
+
+// coveredBy reports whether pm has a value for every property in props.
+func coveredBy(pm PropertyMap, props []string) bool {
+	for _, p := range props {
+		if _, ok := pm[p]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package datastore_test
+
+import (
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestEstimateCostCountsEntityReadsAndWrites(t *testing.T) {
+	k1 := ds.NewKey("app", "", "Widget", "a", 0, nil)
+	k2 := ds.NewKey("app", "", "Widget", "b", 0, nil)
+	ops := []ds.Mutation{
+		ds.PutMutation(k1, ds.PropertyMap{"Name": "a"}),
+		ds.DeleteMutation(k2),
+	}
+	cost := ds.EstimateCost(ops, nil)
+	if cost.EntityWrites != 2 {
+		t.Errorf("EntityWrites = %d, want 2", cost.EntityWrites)
+	}
+	if cost.EntityReads != 1 {
+		t.Errorf("EntityReads = %d, want 1 (one per Delete)", cost.EntityReads)
+	}
+}
+
+func TestEstimateCostIndexWritesScaleWithIndexedProperties(t *testing.T) {
+	k := ds.NewKey("app", "", "Widget", "a", 0, nil)
+
+	one := ds.EstimateCost([]ds.Mutation{ds.PutMutation(k, ds.PropertyMap{"Name": "a"})}, nil)
+	two := ds.EstimateCost([]ds.Mutation{ds.PutMutation(k, ds.PropertyMap{"Name": "a", "Price": 1})}, nil)
+
+	if one.IndexWrites != 2 {
+		t.Errorf("IndexWrites for 1 property = %d, want 2", one.IndexWrites)
+	}
+	if two.IndexWrites != 4 {
+		t.Errorf("IndexWrites for 2 properties = %d, want 4", two.IndexWrites)
+	}
+}
+
+func TestEstimateCostIgnoresMetaPropertiesWhenCountingIndexWrites(t *testing.T) {
+	k := ds.NewKey("app", "", "Widget", "a", 0, nil)
+	cost := ds.EstimateCost([]ds.Mutation{ds.PutMutation(k, ds.PropertyMap{"Name": "a", "$id": "a"})}, nil)
+	if cost.IndexWrites != 2 {
+		t.Errorf("IndexWrites = %d, want 2 ($id should not count)", cost.IndexWrites)
+	}
+}
+
+func TestEstimateCostAddsOneIndexWritePerMatchingCompositeIndex(t *testing.T) {
+	k := ds.NewKey("app", "", "Widget", "a", 0, nil)
+	pm := ds.PropertyMap{"Name": "a", "Price": 1}
+	indexes := []ds.CompositeIndex{
+		{Kind: "Widget", Properties: []string{"Name", "Price"}},
+		{Kind: "Widget", Properties: []string{"Name"}},
+		{Kind: "Gadget", Properties: []string{"Name"}},       // different kind: shouldn't match
+		{Kind: "Widget", Properties: []string{"Name", "SKU"}}, // missing property: shouldn't match
+	}
+
+	withoutIndexes := ds.EstimateCost([]ds.Mutation{ds.PutMutation(k, pm)}, nil)
+	withIndexes := ds.EstimateCost([]ds.Mutation{ds.PutMutation(k, pm)}, indexes)
+
+	if withIndexes.IndexWrites != withoutIndexes.IndexWrites+2 {
+		t.Errorf("IndexWrites with composite indexes = %d, want %d (2 matching indexes)",
+			withIndexes.IndexWrites, withoutIndexes.IndexWrites+2)
+	}
+}
@@ -0,0 +1,18 @@
+package datastore
+
+import "encoding/json"
+
+// EstimateSize returns an approximate serialized size of pm in bytes,
+// measured the same way KindStats measures entity size: JSON-encoding
+// the PropertyMap via ToGeneric. It is meant for rough capacity
+// planning (e.g. deciding whether a batch of Puts is getting close to a
+// size limit), not as an exact count of what the backend writes to
+// disk. Returns 0 if pm fails to encode (which should not happen for a
+// well-formed PropertyMap).
+func EstimateSize(pm PropertyMap) int {
+	b, err := json.Marshal(ToGeneric(pm))
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
@@ -0,0 +1,25 @@
+package datastore
+
+import "testing"
+
+func TestEstimateSizeGrowsWithAddedProperties(t *testing.T) {
+	base := EstimateSize(PropertyMap{"Name": "a"})
+	bigger := EstimateSize(PropertyMap{"Name": "a", "Age": int64(30)})
+	if bigger <= base {
+		t.Errorf("EstimateSize with an extra property = %d, want more than %d", bigger, base)
+	}
+}
+
+func TestEstimateSizeGrowsWithBlobLength(t *testing.T) {
+	small := EstimateSize(PropertyMap{"Blob": []byte("short")})
+	large := EstimateSize(PropertyMap{"Blob": []byte("a much, much longer blob value than the short one")})
+	if large <= small {
+		t.Errorf("EstimateSize with a longer blob = %d, want more than %d", large, small)
+	}
+}
+
+func TestEstimateSizeEmptyPropertyMap(t *testing.T) {
+	if got := EstimateSize(PropertyMap{}); got == 0 {
+		t.Errorf("EstimateSize(empty) = %d, want > 0 for the encoded empty object", got)
+	}
+}
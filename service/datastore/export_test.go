@@ -0,0 +1,53 @@
+package datastore_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestExportImportKindRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := memory.NewDatastore("app")
+
+	keys := []*ds.Key{
+		ds.NewKey("app", "", "Widget", "a", 0, nil),
+		ds.NewKey("app", "", "Widget", "b", 0, nil),
+	}
+	vals := []ds.PropertyMap{
+		{"Name": "alpha", "Count": int64(1)},
+		{"Name": "beta", "Count": int64(2)},
+	}
+	if _, err := src.PutMulti(keys, vals); err != nil {
+		t.Fatalf("seed PutMulti: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := ds.ExportKind(ctx, src, "Widget", &buf)
+	if err != nil {
+		t.Fatalf("ExportKind: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("exported %d entities, want 2", n)
+	}
+
+	dst := memory.NewDatastore("app")
+	n, err = ds.ImportKind(ctx, dst, &buf)
+	if err != nil {
+		t.Fatalf("ImportKind: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("imported %d entities, want 2", n)
+	}
+
+	got := []ds.PropertyMap{{}, {}}
+	if err := dst.GetMulti(keys, got); err != nil {
+		t.Fatalf("GetMulti after import: %v", err)
+	}
+	if got[0]["Name"] != "alpha" || got[1]["Name"] != "beta" {
+		t.Fatalf("unexpected imported data: %#v", got)
+	}
+}
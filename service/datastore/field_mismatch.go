@@ -0,0 +1,195 @@
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrFieldMismatch is returned by LoadStruct (and by PropertyLoadSaver
+// implementations that choose to use it) when a stored property's type
+// cannot be assigned to the destination struct field without coercion.
+// It carries enough structure for schema-evolution code to decide
+// whether to skip the field, coerce it itself, or fail the load.
+type ErrFieldMismatch struct {
+	FieldName    string
+	PropertyType string
+	DestType     string
+}
+
+func (e *ErrFieldMismatch) Error() string {
+	return fmt.Sprintf("datastore: cannot load field %q: stored type %s does not match destination type %s",
+		e.FieldName, e.PropertyType, e.DestType)
+}
+
+// IsFieldMismatch reports whether err is (or wraps) an *ErrFieldMismatch,
+// returning it for inspection.
+func IsFieldMismatch(err error) (*ErrFieldMismatch, bool) {
+	var fm *ErrFieldMismatch
+	if errors.As(err, &fm) {
+		return fm, true
+	}
+	return nil, false
+}
+
+// LoadStruct populates the exported fields of the struct pointed to by
+// dst from pm, matching PropertyMap keys to field names (or to a
+// `gae:"Name"` tag override — see propertyName). A field whose stored
+// value cannot be assigned without coercion is reported as an
+// *ErrFieldMismatch; loading continues for the remaining fields, and
+// only the first mismatch is returned (inspect it with IsFieldMismatch).
+// Later mismatches are not reported.
+//
+// A field tagged `gae:"default=VALUE"` (optionally combined with a name
+// override, e.g. `gae:"Name,default=VALUE"`) that pm has no entry for
+// (e.g. an older entity stored before the field existed) loads as VALUE
+// instead of the field's zero value. VALUE is parsed according to the
+// field's own type: strconv.ParseBool for bool, strconv.ParseInt/
+// ParseFloat for integer/float kinds, and used verbatim for string.
+func LoadStruct(dst interface{}, pm PropertyMap) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("datastore: LoadStruct requires a pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	var firstErr error
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		raw, ok := pm[propertyName(f)]
+		if !ok {
+			if def, hasDef := defaultTagValue(f.Tag); hasDef {
+				if err := applyDefault(f.Name, fv, def); err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}
+			continue
+		}
+		rv := reflect.ValueOf(raw)
+		if !rv.IsValid() {
+			continue
+		}
+		if rv.Type().AssignableTo(fv.Type()) {
+			fv.Set(rv)
+			continue
+		}
+		if rv.Type().ConvertibleTo(fv.Type()) && isSafeNumericConversion(rv.Kind(), fv.Kind()) {
+			fv.Set(rv.Convert(fv.Type()))
+			continue
+		}
+		if firstErr == nil {
+			firstErr = &ErrFieldMismatch{
+				FieldName:    f.Name,
+				PropertyType: rv.Type().String(),
+				DestType:     fv.Type().String(),
+			}
+		}
+	}
+	return firstErr
+}
+
+// SaveStruct is the inverse of LoadStruct: it builds a PropertyMap from
+// the exported fields of the struct pointed to by src, one entry per
+// field keyed by its name (or by a `gae:"Name"` tag override — see
+// propertyName).
+func SaveStruct(src interface{}) (PropertyMap, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("datastore: SaveStruct requires a pointer to a struct, got %T", src)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	pm := make(PropertyMap, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		pm[propertyName(f)] = v.Field(i).Interface()
+	}
+	return pm, nil
+}
+
+// propertyName returns the PropertyMap key f.Name loads/saves under: the
+// first comma-separated segment of a `gae:"..."` tag, if present and not
+// itself a "default=" clause, otherwise f.Name unchanged.
+func propertyName(f reflect.StructField) string {
+	gaeTag, ok := f.Tag.Lookup("gae")
+	if !ok {
+		return f.Name
+	}
+	first := strings.Split(gaeTag, ",")[0]
+	if first == "" || strings.HasPrefix(first, "default=") {
+		return f.Name
+	}
+	return first
+}
+
+// defaultTagValue returns the value of a `gae:"default=VALUE"` tag, if
+// present.
+func defaultTagValue(tag reflect.StructTag) (string, bool) {
+	gaeTag, ok := tag.Lookup("gae")
+	if !ok {
+		return "", false
+	}
+	for _, part := range strings.Split(gaeTag, ",") {
+		if v, ok := strings.CutPrefix(part, "default="); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// applyDefault parses raw per fv's kind and sets fv to the result,
+// reporting an *ErrFieldMismatch if raw can't be parsed as fv's type.
+func applyDefault(fieldName string, fv reflect.Value, raw string) error {
+	mismatch := func() error {
+		return &ErrFieldMismatch{FieldName: fieldName, PropertyType: "default=" + raw, DestType: fv.Type().String()}
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return mismatch()
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return mismatch()
+		}
+		fv.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return mismatch()
+		}
+		fv.SetFloat(f)
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+func isSafeNumericConversion(from, to reflect.Kind) bool {
+	isNum := func(k reflect.Kind) bool {
+		switch k {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	}
+	return isNum(from) && isNum(to)
+}
@@ -0,0 +1,105 @@
+package datastore
+
+import "testing"
+
+func TestLoadStructFieldMismatch(t *testing.T) {
+	type Widget struct {
+		Name  string
+		Count int
+	}
+
+	var w Widget
+	err := LoadStruct(&w, PropertyMap{"Name": "widget-1", "Count": "not-a-number"})
+
+	fm, ok := IsFieldMismatch(err)
+	if !ok {
+		t.Fatalf("expected ErrFieldMismatch, got %v", err)
+	}
+	if fm.FieldName != "Count" || fm.PropertyType != "string" || fm.DestType != "int" {
+		t.Errorf("unexpected mismatch details: %+v", fm)
+	}
+	if w.Name != "widget-1" {
+		t.Errorf("Name should still be loaded: got %q", w.Name)
+	}
+}
+
+func TestLoadStructAppliesDefaultsForMissingFields(t *testing.T) {
+	type Widget struct {
+		Name    string
+		Count   int     `gae:"default=42"`
+		Enabled bool    `gae:"default=true"`
+		Ratio   float64 `gae:"default=1.5"`
+		Label   string  `gae:"default=fallback"`
+	}
+
+	var w Widget
+	if err := LoadStruct(&w, PropertyMap{"Name": "widget-1"}); err != nil {
+		t.Fatalf("LoadStruct: %v", err)
+	}
+	if w.Count != 42 {
+		t.Errorf("Count = %d, want 42", w.Count)
+	}
+	if !w.Enabled {
+		t.Errorf("Enabled = false, want true")
+	}
+	if w.Ratio != 1.5 {
+		t.Errorf("Ratio = %v, want 1.5", w.Ratio)
+	}
+	if w.Label != "fallback" {
+		t.Errorf("Label = %q, want %q", w.Label, "fallback")
+	}
+}
+
+func TestLoadStructDefaultDoesNotOverrideStoredValue(t *testing.T) {
+	type Widget struct {
+		Count int `gae:"default=42"`
+	}
+
+	var w Widget
+	if err := LoadStruct(&w, PropertyMap{"Count": int64(7)}); err != nil {
+		t.Fatalf("LoadStruct: %v", err)
+	}
+	if w.Count != 7 {
+		t.Errorf("Count = %d, want 7 (stored value, not the default)", w.Count)
+	}
+}
+
+func TestSaveAndLoadStructHonorNameOverrideTag(t *testing.T) {
+	type Widget struct {
+		Count int `gae:"ItemCount"`
+	}
+
+	pm, err := SaveStruct(&Widget{Count: 3})
+	if err != nil {
+		t.Fatalf("SaveStruct: %v", err)
+	}
+	if _, ok := pm["Count"]; ok {
+		t.Errorf("pm should not have a Count entry: %+v", pm)
+	}
+	if pm["ItemCount"] != 3 {
+		t.Errorf(`pm["ItemCount"] = %v, want 3`, pm["ItemCount"])
+	}
+
+	var w Widget
+	if err := LoadStruct(&w, pm); err != nil {
+		t.Fatalf("LoadStruct: %v", err)
+	}
+	if w.Count != 3 {
+		t.Errorf("Count = %d, want 3", w.Count)
+	}
+}
+
+func TestLoadStructSuccess(t *testing.T) {
+	type Widget struct {
+		Name  string
+		Count int64
+	}
+
+	var w Widget
+	if err := LoadStruct(&w, PropertyMap{"Name": "widget-1", "Count": int64(3)}); err != nil {
+		t.Fatalf("LoadStruct: %v", err)
+	}
+	if w.Name != "widget-1" || w.Count != 3 {
+		t.Errorf("unexpected result: %+v", w)
+	}
+}
@@ -0,0 +1,44 @@
+package datastore
+
+// PropertyMapFromGeneric converts a loosely-typed map, such as one
+// decoded from JSON, into a PropertyMap. Nested map[string]interface{}
+// values are rejected (datastore properties are flat); slices and
+// scalars pass through unchanged, which covers every JSON-decoded
+// value as well as the common case of already-Go-typed data (int64,
+// float64, string, bool, []byte, time.Time, nil).
+func PropertyMapFromGeneric(m map[string]interface{}) (PropertyMap, error) {
+	pm := make(PropertyMap, len(m))
+	for k, v := range m {
+		if _, ok := v.(map[string]interface{}); ok {
+			return nil, &ErrFieldMismatch{FieldName: k, PropertyType: "map[string]interface{}", DestType: "Property"}
+		}
+		pm[k] = v
+	}
+	return pm, nil
+}
+
+// ToGeneric converts pm into a map[string]interface{} suitable for
+// encoding as JSON or handing to other loosely-typed consumers. It is
+// the inverse of PropertyMapFromGeneric. Per PropertyMap's documented
+// convention, a value may be wrapped as a Property or []Property; those
+// are unwrapped to their underlying value(s). A value that isn't
+// wrapped (as PropertyMapFromGeneric itself produces) passes through
+// unchanged.
+func ToGeneric(pm PropertyMap) map[string]interface{} {
+	m := make(map[string]interface{}, len(pm))
+	for k, v := range pm {
+		switch x := v.(type) {
+		case Property:
+			m[k] = x.Value()
+		case []Property:
+			vals := make([]interface{}, len(x))
+			for i, p := range x {
+				vals[i] = p.Value()
+			}
+			m[k] = vals
+		default:
+			m[k] = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,55 @@
+package datastore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGenericRoundTrip(t *testing.T) {
+	now := time.Date(2021, 5, 6, 7, 8, 9, 0, time.UTC)
+	m := map[string]interface{}{
+		"Name":   "widget",
+		"Count":  int64(3),
+		"Tags":   []interface{}{"a", "b"},
+		"Active": true,
+		"When":   now,
+	}
+
+	pm, err := PropertyMapFromGeneric(m)
+	if err != nil {
+		t.Fatalf("PropertyMapFromGeneric: %v", err)
+	}
+
+	back := ToGeneric(pm)
+	if !reflect.DeepEqual(m, back) {
+		t.Errorf("round trip mismatch:\n  in:  %#v\n  out: %#v", m, back)
+	}
+}
+
+func TestGenericRoundTripWithPropertyWrappedValues(t *testing.T) {
+	pm := PropertyMap{
+		"Name":  MkProperty("widget"),
+		"Count": MkProperty(int64(3)),
+		"Tags":  []Property{MkProperty("a"), MkProperty("b")},
+	}
+
+	got := ToGeneric(pm)
+	want := map[string]interface{}{
+		"Name":  "widget",
+		"Count": int64(3),
+		"Tags":  []interface{}{"a", "b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToGeneric(MkProperty-built map) = %#v, want %#v", got, want)
+	}
+}
+
+func TestGenericRejectsNestedMap(t *testing.T) {
+	_, err := PropertyMapFromGeneric(map[string]interface{}{
+		"Nested": map[string]interface{}{"a": 1},
+	})
+	if _, ok := IsFieldMismatch(err); !ok {
+		t.Fatalf("expected ErrFieldMismatch, got %v", err)
+	}
+}
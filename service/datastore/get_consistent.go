@@ -0,0 +1,35 @@
+package datastore
+
+import "context"
+
+// ConsistentGetter is implemented by backends that support choosing read
+// consistency per GetMulti call; see GetConsistent.
+type ConsistentGetter interface {
+	GetMultiConsistent(keys []*Key, vals []PropertyMap, strong bool) error
+}
+
+// GetConsistent is like Raw(ctx).GetMulti, but additionally lets the
+// caller select read consistency per call where the backend supports it
+// (see ConsistentGetter): strong=true requests the usual strongly
+// consistent read; strong=false allows an eventually consistent one,
+// which for a backend simulating eventual consistency (impl/memory,
+// after Testable.Consistent(false)) may not see a very recent write.
+// Backends that don't implement ConsistentGetter just run a normal,
+// strongly consistent GetMulti regardless of strong.
+func GetConsistent(ctx context.Context, keys []*Key, vals []PropertyMap, strong bool) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	raw, ok := ctx.Value(rawKey).(RawInterface)
+	if !ok {
+		panic("datastore: no RawInterface installed in context; did you forget to call Use()?")
+	}
+	if b, ok := raw.(CtxBinder); ok {
+		raw = b.BindCtx(ctx)
+	}
+	if cg, ok := raw.(ConsistentGetter); ok {
+		return cg.GetMultiConsistent(keys, vals, strong)
+	}
+	return Raw(ctx).GetMulti(keys, vals)
+}
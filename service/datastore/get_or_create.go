@@ -0,0 +1,42 @@
+package datastore
+
+import "context"
+
+// GetOrCreate loads the entity at key into obj via LoadStruct; if it
+// doesn't exist, it calls create to populate obj with defaults and
+// Puts it instead. The load-or-create decision and the Put (if any)
+// happen in one transaction on key's entity group, so a concurrent
+// GetOrCreate racing on the same key can never both decide to create:
+// whichever commits second sees ErrConcurrentTransaction and is
+// retried by RunInTransaction, at which point it finds the entity the
+// first one just created and loads it instead.
+//
+// Unlike PutT, key is explicit (this package has no way to derive one
+// from an arbitrary obj).
+func GetOrCreate(ctx context.Context, key *Key, obj interface{}, create func() error) (created bool, err error) {
+	err = RunInTransaction(ctx, func(ctx context.Context) error {
+		created = false
+
+		pm := PropertyMap{}
+		getErr := Raw(ctx).GetMulti([]*Key{key}, []PropertyMap{pm})
+		if getErr == nil {
+			return LoadStruct(obj, pm)
+		}
+		if me, ok := getErr.(MultiError); !ok || me[0] != ErrNoSuchEntity {
+			return getErr
+		}
+
+		if err := create(); err != nil {
+			return err
+		}
+		created = true
+
+		pm, err := SaveStruct(obj)
+		if err != nil {
+			return err
+		}
+		_, err = Raw(ctx).PutMulti([]*Key{key}, []PropertyMap{pm})
+		return err
+	}, nil)
+	return created, err
+}
@@ -0,0 +1,93 @@
+package datastore_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type gocWidget struct {
+	Count int64
+}
+
+func TestGetOrCreateCreatesWhenMissing(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+
+	var w gocWidget
+	created, err := ds.GetOrCreate(ctx, k, &w, func() error {
+		w.Count = 42
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	if !created {
+		t.Errorf("created = false, want true for a missing entity")
+	}
+	if w.Count != 42 {
+		t.Errorf("Count = %d, want 42", w.Count)
+	}
+}
+
+func TestGetOrCreateLoadsWhenExisting(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Count": int64(7)}}); err != nil {
+		t.Fatalf("seed PutMulti: %v", err)
+	}
+
+	var w gocWidget
+	created, err := ds.GetOrCreate(ctx, k, &w, func() error {
+		t.Fatalf("create should not be called for an existing entity")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	if created {
+		t.Errorf("created = true, want false for an existing entity")
+	}
+	if w.Count != 7 {
+		t.Errorf("Count = %d, want 7", w.Count)
+	}
+}
+
+func TestGetOrCreateConcurrentCallsCreateExactlyOnce(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+
+	const n = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	createdCount := 0
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var w gocWidget
+			created, err := ds.GetOrCreate(ctx, k, &w, func() error {
+				w.Count = 1
+				return nil
+			})
+			if err != nil {
+				t.Errorf("GetOrCreate: %v", err)
+				return
+			}
+			if created {
+				mu.Lock()
+				createdCount++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if createdCount != 1 {
+		t.Errorf("createdCount = %d, want exactly 1 across %d concurrent calls", createdCount, n)
+	}
+}
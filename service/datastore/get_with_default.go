@@ -0,0 +1,26 @@
+package datastore
+
+import "context"
+
+// GetWithDefault loads the entity at key into obj via LoadStruct. If no
+// entity exists, it calls defaults to populate obj instead and returns
+// nil rather than the miss error; any other error from the Get is
+// returned unchanged.
+//
+// Unlike PutT, key is explicit (this package has no way to derive one
+// from an arbitrary obj, see GetOrCreate). Unlike GetOrCreate,
+// GetWithDefault never writes: it's meant for read-only singleton
+// config entities that should fall back to in-memory defaults until
+// something else creates them, not be auto-persisted on first miss.
+func GetWithDefault(ctx context.Context, key *Key, obj interface{}, defaults func()) error {
+	pm := PropertyMap{}
+	err := Raw(ctx).GetMulti([]*Key{key}, []PropertyMap{pm})
+	if err == nil {
+		return LoadStruct(obj, pm)
+	}
+	if me, ok := err.(MultiError); ok && me[0] == ErrNoSuchEntity {
+		defaults()
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package datastore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luci/gae/filter/featureBreaker"
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type gwdConfig struct {
+	MaxItems int64
+}
+
+func TestGetWithDefaultLoadsExistingEntity(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Config", "singleton", 0, nil)
+
+	pm, err := ds.SaveStruct(&gwdConfig{MaxItems: 7})
+	if err != nil {
+		t.Fatalf("SaveStruct: %v", err)
+	}
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{pm}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	var cfg gwdConfig
+	defaultsCalled := false
+	err = ds.GetWithDefault(ctx, k, &cfg, func() {
+		defaultsCalled = true
+		cfg.MaxItems = 100
+	})
+	if err != nil {
+		t.Fatalf("GetWithDefault: %v", err)
+	}
+	if defaultsCalled {
+		t.Errorf("defaults was called for an existing entity")
+	}
+	if cfg.MaxItems != 7 {
+		t.Errorf("MaxItems = %d, want 7 (loaded from the entity)", cfg.MaxItems)
+	}
+}
+
+func TestGetWithDefaultInvokesDefaultsWhenMissing(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Config", "singleton", 0, nil)
+
+	var cfg gwdConfig
+	err := ds.GetWithDefault(ctx, k, &cfg, func() { cfg.MaxItems = 100 })
+	if err != nil {
+		t.Fatalf("GetWithDefault: %v", err)
+	}
+	if cfg.MaxItems != 100 {
+		t.Errorf("MaxItems = %d, want 100 (from defaults)", cfg.MaxItems)
+	}
+}
+
+func TestGetWithDefaultReturnsRealErrors(t *testing.T) {
+	boom := errors.New("boom")
+	b := featureBreaker.NewBreaker(boom)
+	b.BreakMethod("GetMulti", 1)
+	ctx := ds.SetRaw(context.Background(), featureBreaker.FilterRaw(memory.NewDatastore("app"), b))
+	k := ds.NewKey("app", "", "Config", "singleton", 0, nil)
+
+	var cfg gwdConfig
+	defaultsCalled := false
+	err := ds.GetWithDefault(ctx, k, &cfg, func() { defaultsCalled = true })
+	if err != boom {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if defaultsCalled {
+		t.Errorf("defaults was called despite a real error")
+	}
+}
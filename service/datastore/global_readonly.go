@@ -0,0 +1,63 @@
+package datastore
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrReadOnly is returned by PutMulti/DeleteMulti (including calls made
+// from inside a RunInTransaction body) while the process is in global
+// read-only mode. See SetGlobalReadOnly.
+var ErrReadOnly = errors.New("datastore: process is in read-only mode")
+
+// globalReadOnly is 0 (writes allowed) or 1 (writes rejected), flipped by
+// SetGlobalReadOnly. It is process-global rather than per-context: unlike
+// everything else in this package, read-only mode is meant to be toggled
+// by an operator during a maintenance window without touching, let alone
+// redeploying, the application's own context plumbing. This package has
+// no pre-existing per-context readonly filter for it to complement; it's
+// an operational kill-switch in its own right.
+var globalReadOnly int32
+
+// SetGlobalReadOnly enables or disables read-only mode for every
+// RawInterface obtained from Raw(ctx) in this process, regardless of
+// which ctx it came from. While enabled, PutMulti and DeleteMulti fail
+// immediately with ErrReadOnly, without reaching any filter installed
+// via AddRawFilters or the backend itself; GetMulti, Run and Count are
+// unaffected.
+func SetGlobalReadOnly(ro bool) {
+	v := int32(0)
+	if ro {
+		v = 1
+	}
+	atomic.StoreInt32(&globalReadOnly, v)
+}
+
+// IsGlobalReadOnly reports the current state set by SetGlobalReadOnly.
+func IsGlobalReadOnly() bool {
+	return atomic.LoadInt32(&globalReadOnly) != 0
+}
+
+// readOnlyFilter rejects writes while global read-only mode is enabled.
+// Raw(ctx) applies it unconditionally, the same way it applies
+// batchFilter, so no application code needs to opt in for the
+// maintenance-window switch to take effect. Writes issued from inside a
+// RunInTransaction body reach it too, since each one calls Raw(ctx)
+// again to get its RawInterface.
+type readOnlyFilter struct {
+	RawInterface
+}
+
+func (f readOnlyFilter) PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error) {
+	if IsGlobalReadOnly() {
+		return nil, ErrReadOnly
+	}
+	return f.RawInterface.PutMulti(keys, vals)
+}
+
+func (f readOnlyFilter) DeleteMulti(keys []*Key) error {
+	if IsGlobalReadOnly() {
+		return ErrReadOnly
+	}
+	return f.RawInterface.DeleteMulti(keys)
+}
@@ -0,0 +1,72 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestGlobalReadOnlyRejectsWrites(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+
+	ds.SetGlobalReadOnly(true)
+	defer ds.SetGlobalReadOnly(false)
+
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != ds.ErrReadOnly {
+		t.Fatalf("PutMulti err = %v, want ErrReadOnly", err)
+	}
+	if err := ds.Raw(ctx).DeleteMulti([]*ds.Key{k}); err != ds.ErrReadOnly {
+		t.Fatalf("DeleteMulti err = %v, want ErrReadOnly", err)
+	}
+
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		_, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}})
+		return err
+	}, nil)
+	if err != ds.ErrReadOnly {
+		t.Fatalf("transactional PutMulti err = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestGlobalReadOnlyCanBeToggledBackOff(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+
+	ds.SetGlobalReadOnly(true)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != ds.ErrReadOnly {
+		t.Fatalf("PutMulti err = %v, want ErrReadOnly while read-only", err)
+	}
+
+	ds.SetGlobalReadOnly(false)
+	t.Cleanup(func() { ds.SetGlobalReadOnly(false) })
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti after disabling read-only: %v", err)
+	}
+
+	vals := []ds.PropertyMap{{}}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals); err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+}
+
+func TestGlobalReadOnlyDoesNotAffectReads(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"X": ds.MkProperty(int64(1))}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	ds.SetGlobalReadOnly(true)
+	defer ds.SetGlobalReadOnly(false)
+
+	vals := []ds.PropertyMap{{}}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, vals); err != nil {
+		t.Fatalf("GetMulti while read-only: %v", err)
+	}
+	if got := vals[0]["X"].(ds.Property).Value(); got != int64(1) {
+		t.Fatalf("X = %v, want 1", got)
+	}
+}
@@ -0,0 +1,13 @@
+package datastore
+
+import "errors"
+
+// ErrIndexNotReady is returned by Run/Count when the composite index a
+// query needs exists but hasn't finished building yet, mirroring
+// production Cloud Datastore's behavior for a newly added index.
+var ErrIndexNotReady = errors.New("datastore: index not ready")
+
+// IsIndexNotReady reports whether err is, or wraps, ErrIndexNotReady.
+func IsIndexNotReady(err error) bool {
+	return errors.Is(err, ErrIndexNotReady)
+}
@@ -0,0 +1,18 @@
+package datastore
+
+import "context"
+
+// RunWithIndexFallback runs q against ctx's datastore exactly as Run
+// would, invoking cb once per result. If the query fails because its
+// composite index isn't ready yet (see IsIndexNotReady), fallback is
+// invoked instead of returning the error; fallback is responsible for
+// producing an equivalent result some other way (e.g. a differently
+// shaped query that doesn't need the missing index) and feeding it to
+// cb itself. Any other error from Run is returned as-is.
+func RunWithIndexFallback(ctx context.Context, q *Query, fallback func() error, cb RunCB) error {
+	err := Raw(ctx).Run(q, cb)
+	if err != nil && IsIndexNotReady(err) {
+		return fallback()
+	}
+	return err
+}
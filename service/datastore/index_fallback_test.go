@@ -0,0 +1,67 @@
+package datastore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestRunWithIndexFallbackInvokesFallbackOnIndexNotReady(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	memory.GetTestable(ctx).FailIndex("Widget", true)
+
+	fallbackCalled := false
+	err := ds.RunWithIndexFallback(ctx, ds.NewQuery("Widget"), func() error {
+		fallbackCalled = true
+		return nil
+	}, func(*ds.Key, ds.PropertyMap) error { return nil })
+	if err != nil {
+		t.Fatalf("RunWithIndexFallback: %v", err)
+	}
+	if !fallbackCalled {
+		t.Errorf("fallback was not invoked for a not-ready index")
+	}
+}
+
+func TestRunWithIndexFallbackPassesThroughOtherErrors(t *testing.T) {
+	boom := errors.New("boom")
+	ctx := ds.SetRaw(context.Background(), fakeRunRaw{runErr: boom})
+
+	err := ds.RunWithIndexFallback(ctx, ds.NewQuery("Widget"), func() error {
+		t.Fatalf("fallback should not be invoked for a non-index error")
+		return nil
+	}, func(*ds.Key, ds.PropertyMap) error { return nil })
+	if err != boom {
+		t.Fatalf("err = %v, want boom passed through unchanged", err)
+	}
+}
+
+type fakeRunRaw struct {
+	ds.RawInterface
+	runErr error
+}
+
+func (f fakeRunRaw) Run(q *ds.Query, cb ds.RunCB) error { return f.runErr }
+
+func TestRunWithIndexFallbackSucceedsWithoutFallback(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	seen := 0
+	err := ds.RunWithIndexFallback(ctx, ds.NewQuery("Widget"), func() error {
+		t.Fatalf("fallback should not be invoked when the index is ready")
+		return nil
+	}, func(*ds.Key, ds.PropertyMap) error { seen++; return nil })
+	if err != nil {
+		t.Fatalf("RunWithIndexFallback: %v", err)
+	}
+	if seen != 1 {
+		t.Errorf("cb called %d times, want 1", seen)
+	}
+}
@@ -0,0 +1,110 @@
+package datastore
+
+import (
+	"context"
+	"sync"
+)
+
+// RawInterface is the low-level datastore service surface that backend
+// implementations (impl/memory, impl/cloud, ...) implement, and that
+// filters wrap. Application code should normally use this package's
+// typed helpers (GetT, PutT, TypedQuery, ...) instead.
+type RawInterface interface {
+	// GetMulti retrieves the entities for keys, writing results into vals
+	// in place. The returned MultiError (if non-nil) has one entry per
+	// key; ErrNoSuchEntity marks a missing entity.
+	GetMulti(keys []*Key, vals []PropertyMap) error
+
+	// PutMulti writes vals under keys, returning the (possibly
+	// newly-allocated) keys and a MultiError with one entry per item.
+	//
+	// Outside of a transaction, each entity is written independently: a
+	// validation failure on one item does not prevent the others from
+	// being committed. The returned MultiError has a nil entry for every
+	// item that was written successfully and a non-nil entry, at the same
+	// index, for every item that was rejected; the corresponding entry in
+	// the returned []*Key slice is nil for rejected items. Inside a
+	// transaction, backends are expected to fail the whole batch instead.
+	PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error)
+
+	// DeleteMulti deletes the entities for keys, returning a MultiError
+	// with one entry per key.
+	DeleteMulti(keys []*Key) error
+
+	// Run executes q, invoking cb once per matching result in order.
+	// Returning Stop from cb halts iteration without error.
+	Run(q *Query, cb RunCB) error
+
+	// Count reports the number of entities q matches. It is equivalent to
+	// running q and counting the results, but backends that support
+	// server-side aggregation may implement it more cheaply.
+	Count(q *Query) (int64, error)
+
+	// RunInTransaction runs f inside a transaction, retrying on
+	// ErrConcurrentTransaction per the backend's policy and opts.
+	RunInTransaction(ctx context.Context, f func(context.Context) error, opts *TransactionOptions) error
+}
+
+// Filter is a function which wraps a RawInterface with additional
+// behavior (validation, caching, retries, ...). Filters are applied in
+// the order they were added, with the first-added filter being the
+// innermost wrapper and the last-added one the outermost.
+type Filter func(context.Context, RawInterface) RawInterface
+
+type filtersKeyType struct{}
+
+var filtersKey filtersKeyType
+
+// AddRawFilters installs one or more RawInterface filters into ctx,
+// appending them after any already present. Filters added later wrap
+// filters added earlier.
+func AddRawFilters(ctx context.Context, filts ...Filter) context.Context {
+	if len(filts) == 0 {
+		return ctx
+	}
+	cur, _ := ctx.Value(filtersKey).([]Filter)
+	next := make([]Filter, 0, len(cur)+len(filts))
+	next = append(next, cur...)
+	next = append(next, filts...)
+	return context.WithValue(ctx, filtersKey, next)
+}
+
+var (
+	defaultFiltersMu sync.Mutex
+	defaultFilters   []Filter
+)
+
+// RegisterDefaultFilter registers f to be applied, in registration
+// order, to every context's RawInterface, without needing a matching
+// AddRawFilters call at each context-construction site. It is meant to
+// be called from a package's init(), for filters (e.g. txndefer) that
+// should always be present.
+//
+// Default filters wrap the raw backend first, before any per-context
+// filters added via AddRawFilters; per-context filters therefore end up
+// outermost and can see (and override) what a default filter did. There
+// is no way to unregister a default filter.
+func RegisterDefaultFilter(f Filter) {
+	defaultFiltersMu.Lock()
+	defer defaultFiltersMu.Unlock()
+	defaultFilters = append(defaultFilters, f)
+}
+
+// ApplyRawFilters wraps raw with every filter registered via
+// RegisterDefaultFilter, followed by every filter installed in ctx via
+// AddRawFilters, in registration order, and returns the resulting
+// RawInterface.
+func ApplyRawFilters(ctx context.Context, raw RawInterface) RawInterface {
+	defaultFiltersMu.Lock()
+	defaults := append([]Filter{}, defaultFilters...)
+	defaultFiltersMu.Unlock()
+	for _, f := range defaults {
+		raw = f(ctx, raw)
+	}
+
+	filts, _ := ctx.Value(filtersKey).([]Filter)
+	for _, f := range filts {
+		raw = f(ctx, raw)
+	}
+	return raw
+}
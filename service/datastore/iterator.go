@@ -0,0 +1,102 @@
+package datastore
+
+import "context"
+
+// Iterator pulls a Query's results one at a time, for callers that want
+// a for-loop instead of a Run callback. It is implemented on top of
+// Raw(ctx).Run, with a goroutine feeding Next through a channel; Close
+// must be called once the caller is done with the Iterator (whether or
+// not it was drained), so that goroutine can exit instead of blocking
+// forever on a result nobody will read. A panic inside Run (including
+// one from a backend bug, since Iterator has no user callback of its
+// own to panic) is recovered on the background goroutine and reported
+// as an *ErrPanicInCallback from Next, instead of crashing the process.
+type Iterator struct {
+	results chan iterResult
+	done    chan struct{}
+	fp      string
+	n       int
+	closed  bool
+	lastKey *Key
+	err     error
+}
+
+type iterResult struct {
+	key *Key
+	pm  PropertyMap
+}
+
+// NewIterator starts running q in the background and returns an
+// Iterator over its results. The query itself doesn't begin producing
+// results (and any error from running it isn't visible) until the first
+// call to Next; NewIterator itself can only fail if q can't be
+// fingerprinted for Cursor (see Query.Start), i.e. the same cases
+// FinalizeQuery rejects.
+func NewIterator(ctx context.Context, q *Query) (*Iterator, error) {
+	fp, err := queryFingerprint(q)
+	if err != nil {
+		return nil, err
+	}
+	it := &Iterator{
+		results: make(chan iterResult),
+		done:    make(chan struct{}),
+		fp:      fp,
+	}
+	go func() {
+		defer close(it.results)
+		defer recoverCallbackPanic(&it.err)
+		if err := Raw(ctx).Run(q, func(k *Key, pm PropertyMap) error {
+			select {
+			case it.results <- iterResult{key: k, pm: pm}:
+				return nil
+			case <-it.done:
+				return Stop
+			}
+		}); err != nil && err != Stop {
+			it.err = err
+		}
+	}()
+	return it, nil
+}
+
+// Next decodes the next result into dst (via LoadStruct) and reports
+// true, or reports false if the query is exhausted. Once Next returns
+// false, every subsequent call also returns false.
+func (it *Iterator) Next(dst interface{}) (bool, error) {
+	r, ok := <-it.results
+	if !ok {
+		return false, it.err
+	}
+	it.n++
+	if err := LoadStruct(dst, r.pm); err != nil {
+		return false, err
+	}
+	it.lastKey = r.key
+	return true, nil
+}
+
+// Key returns the key of the result most recently returned by Next.
+func (it *Iterator) Key() *Key { return it.lastKey }
+
+// Cursor returns a resumable position immediately after the result most
+// recently returned by Next, fingerprinted against the Query it was
+// built from; passing it to a different Query's Start is rejected.
+func (it *Iterator) Cursor() (Cursor, error) {
+	return newCursor(it.fp, it.n), nil
+}
+
+// Close stops the Iterator's background goroutine. It is safe to call
+// more than once, and safe to call before the Iterator is drained (the
+// common case when a caller stops early).
+func (it *Iterator) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	close(it.done)
+	for range it.results {
+		// Drain any in-flight result so the background goroutine's send
+		// doesn't block forever on the done case racing the send case.
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type iterWidget struct {
+	Name string
+}
+
+func seedWidgets(t *testing.T, ctx context.Context, names ...string) {
+	t.Helper()
+	keys := make([]*ds.Key, len(names))
+	vals := make([]ds.PropertyMap, len(names))
+	for i, n := range names {
+		keys[i] = ds.NewKey("app", "", "Widget", n, 0, nil)
+		vals[i] = ds.PropertyMap{"Name": n}
+	}
+	if _, err := ds.Raw(ctx).PutMulti(keys, vals); err != nil {
+		t.Fatalf("seed PutMulti: %v", err)
+	}
+}
+
+func TestIteratorDrainsAllResults(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	seedWidgets(t, ctx, "a", "b", "c")
+
+	it, err := ds.NewIterator(ctx, ds.NewQuery("Widget"))
+	if err != nil {
+		t.Fatalf("NewIterator: %v", err)
+	}
+	defer it.Close()
+
+	var got []string
+	for {
+		var w iterWidget
+		ok, err := it.Next(&w)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if !ok {
+			break
+		}
+		got = append(got, w.Name)
+	}
+	if len(got) != 3 {
+		t.Errorf("got %d results, want 3: %v", len(got), got)
+	}
+}
+
+func TestIteratorCloseBeforeDrainDoesNotBlock(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	seedWidgets(t, ctx, "a", "b", "c", "d", "e")
+
+	it, err := ds.NewIterator(ctx, ds.NewQuery("Widget"))
+	if err != nil {
+		t.Fatalf("NewIterator: %v", err)
+	}
+
+	var w iterWidget
+	ok, err := it.Next(&w)
+	if err != nil || !ok {
+		t.Fatalf("Next: ok=%v err=%v", ok, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- it.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return; goroutine likely leaked")
+	}
+}
+
+func TestIteratorCursorAdvances(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	seedWidgets(t, ctx, "a", "b")
+
+	it, err := ds.NewIterator(ctx, ds.NewQuery("Widget"))
+	if err != nil {
+		t.Fatalf("NewIterator: %v", err)
+	}
+	defer it.Close()
+
+	var w iterWidget
+	if ok, err := it.Next(&w); err != nil || !ok {
+		t.Fatalf("Next: ok=%v err=%v", ok, err)
+	}
+	c1, err := it.Cursor()
+	if err != nil {
+		t.Fatalf("Cursor: %v", err)
+	}
+	if ok, err := it.Next(&w); err != nil || !ok {
+		t.Fatalf("Next: ok=%v err=%v", ok, err)
+	}
+	c2, err := it.Cursor()
+	if err != nil {
+		t.Fatalf("Cursor: %v", err)
+	}
+	if c1 == c2 {
+		t.Errorf("cursor did not advance: c1=%v c2=%v", c1, c2)
+	}
+}
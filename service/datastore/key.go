@@ -0,0 +1,98 @@
+// Package datastore exposes a filterable, mockable interface to the
+// underlying App Engine / Cloud Datastore service. Applications normally
+// interact with it through this package's functions (GetT, PutT,
+// RunInTransaction, ...), which call the RawInterface installed in ctx
+// (see Raw); service implementations and filters operate on the
+// lower-level RawInterface directly.
+package datastore
+
+import "fmt"
+
+// Key represents a datastore key: the App ID and namespace it lives in,
+// an optional parent, and either a string or integer ID within its Kind.
+//
+// Keys are immutable once constructed.
+type Key struct {
+	appID     string
+	namespace string
+	kind      string
+	stringID  string
+	intID     int64
+	parent    *Key
+}
+
+// NewKey constructs a Key. Exactly one of stringID or intID should be set
+// (non-zero); if both are zero, the Key is "incomplete" and is only valid
+// as an argument to Put/AllocateIDs.
+func NewKey(appID, namespace, kind, stringID string, intID int64, parent *Key) *Key {
+	return &Key{
+		appID:     appID,
+		namespace: namespace,
+		kind:      kind,
+		stringID:  stringID,
+		intID:     intID,
+		parent:    parent,
+	}
+}
+
+// AppID returns the App ID this key belongs to.
+func (k *Key) AppID() string { return k.appID }
+
+// Namespace returns the namespace this key belongs to.
+func (k *Key) Namespace() string { return k.namespace }
+
+// Kind returns the kind of the entity this key identifies.
+func (k *Key) Kind() string { return k.kind }
+
+// StringID returns the string ID component of this key, if any.
+func (k *Key) StringID() string { return k.stringID }
+
+// IntID returns the integer ID component of this key, if any.
+func (k *Key) IntID() int64 { return k.intID }
+
+// Parent returns the parent of this key, or nil if it is a root key.
+func (k *Key) Parent() *Key { return k.parent }
+
+// Incomplete returns true if this key has neither a StringID nor an IntID,
+// meaning it is awaiting ID allocation from a Put.
+func (k *Key) Incomplete() bool { return k.stringID == "" && k.intID == 0 }
+
+// Root returns the root-most key in this key's ancestor chain.
+func (k *Key) Root() *Key {
+	cur := k
+	for cur.parent != nil {
+		cur = cur.parent
+	}
+	return cur
+}
+
+// Equal returns true if k and other identify the same entity.
+func (k *Key) Equal(other *Key) bool {
+	if k == other {
+		return true
+	}
+	if k == nil || other == nil {
+		return false
+	}
+	if k.appID != other.appID || k.namespace != other.namespace ||
+		k.kind != other.kind || k.stringID != other.stringID || k.intID != other.intID {
+		return false
+	}
+	return k.parent.Equal(other.parent)
+}
+
+// String renders the key as a human-readable, non-parseable slash-path,
+// e.g. "Parent,1/Child,\"name\"".
+func (k *Key) String() string {
+	if k == nil {
+		return "<nil>"
+	}
+	s := ""
+	if k.parent != nil {
+		s = k.parent.String() + "/"
+	}
+	if k.stringID != "" {
+		return fmt.Sprintf("%s%s,%q", s, k.kind, k.stringID)
+	}
+	return fmt.Sprintf("%s%s,%d", s, k.kind, k.intID)
+}
@@ -0,0 +1,71 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+)
+
+// keyValidationFilter rejects any key whose AppID or Namespace does not
+// match the expected values, rather than silently passing it through to
+// the backend (which, for a mismatched app/namespace, typically just
+// returns ErrNoSuchEntity).
+type keyValidationFilter struct {
+	RawInterface
+
+	appID     string
+	namespace string
+}
+
+func (f *keyValidationFilter) validate(keys []*Key) error {
+	me := make(MultiError, len(keys))
+	bad := false
+	for i, k := range keys {
+		if k == nil {
+			continue
+		}
+		root := k.Root()
+		if root.AppID() != f.appID || root.Namespace() != f.namespace {
+			me[i] = fmt.Errorf("datastore: key %s belongs to app %q/ns %q, expected %q/%q",
+				k, root.AppID(), root.Namespace(), f.appID, f.namespace)
+			bad = true
+		}
+	}
+	if bad {
+		return me
+	}
+	return nil
+}
+
+func (f *keyValidationFilter) GetMulti(keys []*Key, vals []PropertyMap) error {
+	if err := f.validate(keys); err != nil {
+		return err
+	}
+	return f.RawInterface.GetMulti(keys, vals)
+}
+
+func (f *keyValidationFilter) PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error) {
+	if err := f.validate(keys); err != nil {
+		return nil, err
+	}
+	return f.RawInterface.PutMulti(keys, vals)
+}
+
+func (f *keyValidationFilter) DeleteMulti(keys []*Key) error {
+	if err := f.validate(keys); err != nil {
+		return err
+	}
+	return f.RawInterface.DeleteMulti(keys)
+}
+
+// WithKeyValidation installs a filter into ctx which rejects, with a
+// descriptive per-key error, any key passed to GetMulti/PutMulti/
+// DeleteMulti whose root AppID or Namespace doesn't match appID/namespace.
+//
+// This guards against the common multi-app/multi-namespace bug where a
+// key constructed for the wrong app or namespace is passed to a Get and
+// silently returns ErrNoSuchEntity instead of surfacing the mistake.
+func WithKeyValidation(ctx context.Context, appID, namespace string) context.Context {
+	return AddRawFilters(ctx, func(_ context.Context, raw RawInterface) RawInterface {
+		return &keyValidationFilter{RawInterface: raw, appID: appID, namespace: namespace}
+	})
+}
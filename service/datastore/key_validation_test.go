@@ -0,0 +1,42 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeRaw is a minimal RawInterface that always succeeds, for exercising
+// filters without a full backend.
+type fakeRaw struct{}
+
+func (fakeRaw) GetMulti(keys []*Key, vals []PropertyMap) error { return make(MultiError, len(keys)) }
+func (fakeRaw) PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error) {
+	return keys, make(MultiError, len(keys))
+}
+func (fakeRaw) DeleteMulti(keys []*Key) error { return make(MultiError, len(keys)) }
+func (fakeRaw) Run(q *Query, cb RunCB) error  { return nil }
+func (fakeRaw) Count(q *Query) (int64, error) { return 0, nil }
+func (fakeRaw) RunInTransaction(ctx context.Context, f func(context.Context) error, opts *TransactionOptions) error {
+	return f(MarkInTransaction(ctx))
+}
+
+func TestWithKeyValidation(t *testing.T) {
+	ctx := WithKeyValidation(context.Background(), "goodapp", "ns")
+	raw := ApplyRawFilters(ctx, fakeRaw{})
+
+	good := NewKey("goodapp", "ns", "Kind", "", 1, nil)
+	foreign := NewKey("otherapp", "ns", "Kind", "", 2, nil)
+
+	vals := []PropertyMap{{}, {}}
+	err := raw.GetMulti([]*Key{good, foreign}, vals)
+	me, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("expected MultiError, got %T: %v", err, err)
+	}
+	if me[0] != nil {
+		t.Errorf("expected key from goodapp to pass validation, got %v", me[0])
+	}
+	if me[1] == nil {
+		t.Errorf("expected key from otherapp to fail validation")
+	}
+}
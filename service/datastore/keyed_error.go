@@ -0,0 +1,39 @@
+package datastore
+
+import "fmt"
+
+// keyedError annotates an underlying per-item error with the key it
+// happened to, so a log of a failed PutMulti/DeleteMulti's MultiError
+// shows which entity failed instead of just the bare error N times
+// over. Unwrap returns the original error unchanged, so callers that
+// classify errors with errors.Is/errors.As (or, for the sentinel errors
+// in this package, a direct comparison against the Unwrap'd value) keep
+// working against an annotated MultiError exactly as they would against
+// the unannotated one.
+type keyedError struct {
+	key *Key
+	err error
+}
+
+func (e *keyedError) Error() string {
+	return fmt.Sprintf("%s: %s", e.key, e.err)
+}
+
+func (e *keyedError) Unwrap() error { return e.err }
+
+// annotateWithKeys returns a copy of err with each non-nil entry wrapped
+// in a keyedError naming the corresponding entry of keys, or err
+// unchanged if it isn't a MultiError.
+func annotateWithKeys(keys []*Key, err error) error {
+	me, ok := err.(MultiError)
+	if !ok {
+		return err
+	}
+	annotated := make(MultiError, len(me))
+	for i, e := range me {
+		if e != nil {
+			annotated[i] = &keyedError{key: keys[i], err: e}
+		}
+	}
+	return annotated
+}
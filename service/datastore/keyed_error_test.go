@@ -0,0 +1,65 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// failingRaw is a minimal RawInterface whose PutMulti/DeleteMulti fail
+// every item with the given error.
+type failingRaw struct {
+	err error
+}
+
+func (f failingRaw) GetMulti(keys []*Key, vals []PropertyMap) error { return nil }
+func (f failingRaw) PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error) {
+	me := make(MultiError, len(keys))
+	for i := range me {
+		me[i] = f.err
+	}
+	return nil, me
+}
+func (f failingRaw) DeleteMulti(keys []*Key) error {
+	me := make(MultiError, len(keys))
+	for i := range me {
+		me[i] = f.err
+	}
+	return me
+}
+func (f failingRaw) Run(q *Query, cb RunCB) error  { return nil }
+func (f failingRaw) Count(q *Query) (int64, error) { return 0, nil }
+func (f failingRaw) RunInTransaction(ctx context.Context, fn func(context.Context) error, opts *TransactionOptions) error {
+	return fn(ctx)
+}
+
+func TestPutMultiAnnotatesErrorsWithKeys(t *testing.T) {
+	ctx := SetRaw(context.Background(), failingRaw{err: ErrNoSuchEntity})
+
+	k := NewKey("app", "", "Kind", "a", 0, nil)
+	_, err := Raw(ctx).PutMulti([]*Key{k}, []PropertyMap{{}})
+	me, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("expected MultiError, got %T: %v", err, err)
+	}
+	if !errors.Is(me[0], ErrNoSuchEntity) {
+		t.Errorf("errors.Is(me[0], ErrNoSuchEntity) = false, want true")
+	}
+	if got := me[0].Error(); got == ErrNoSuchEntity.Error() {
+		t.Errorf("annotated error message = %q, want it to mention the key too", got)
+	}
+}
+
+func TestDeleteMultiAnnotatesErrorsWithKeys(t *testing.T) {
+	ctx := SetRaw(context.Background(), failingRaw{err: ErrNoSuchEntity})
+
+	k := NewKey("app", "", "Kind", "a", 0, nil)
+	err := Raw(ctx).DeleteMulti([]*Key{k})
+	me, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("expected MultiError, got %T: %v", err, err)
+	}
+	if !errors.Is(me[0], ErrNoSuchEntity) {
+		t.Errorf("errors.Is(me[0], ErrNoSuchEntity) = false, want true")
+	}
+}
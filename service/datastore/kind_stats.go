@@ -0,0 +1,69 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Stats summarizes a KindStats sample.
+type Stats struct {
+	// Count is the number of entities sampled.
+	Count int64
+	// AvgSize and MaxSize are the entities' approximate serialized sizes
+	// in bytes, measured by JSON-encoding each sampled PropertyMap (see
+	// ToGeneric). Zero if Count is zero.
+	AvgSize float64
+	MaxSize int
+	// PropertyFrequency maps each property name seen across the sample
+	// to the fraction (0 to 1) of sampled entities that had it set.
+	PropertyFrequency map[string]float64
+}
+
+// KindStats samples up to sample entities of kind (the whole kind, if
+// sample is <= 0) via Run and computes Stats over them. It streams
+// results one at a time and keeps only running totals and a
+// per-property counter in memory, not the sampled entities themselves.
+func KindStats(ctx context.Context, kind string, sample int) (Stats, error) {
+	q := NewQuery(kind)
+	if sample > 0 {
+		q = q.Limit(int32(sample))
+	}
+
+	var (
+		count      int64
+		totalSize  int64
+		maxSize    int
+		propCounts = map[string]int64{}
+	)
+	err := Raw(ctx).Run(q, func(k *Key, pm PropertyMap) error {
+		count++
+		b, err := json.Marshal(ToGeneric(pm))
+		if err != nil {
+			return err
+		}
+		if len(b) > maxSize {
+			maxSize = len(b)
+		}
+		totalSize += int64(len(b))
+		for prop := range pm {
+			propCounts[prop]++
+		}
+		return nil
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{
+		Count:             count,
+		MaxSize:           maxSize,
+		PropertyFrequency: make(map[string]float64, len(propCounts)),
+	}
+	if count > 0 {
+		stats.AvgSize = float64(totalSize) / float64(count)
+		for prop, c := range propCounts {
+			stats.PropertyFrequency[prop] = float64(c) / float64(count)
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,96 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// LoadReferences extracts the *Key value of each named field in
+// keyFields from every element of entities (a slice of pointers to
+// structs, the same shape SaveStruct/LoadStruct work on), de-duplicates
+// them, loads them all in a single GetMulti, and returns the results
+// keyed by key.String() so callers can look up a referenced entity by
+// the *Key they already have, instead of issuing one Get per reference
+// (and per entity) to load a one-level-deep entity graph.
+//
+// A nil *Key field, or an entity whose named field is missing or not of
+// type *Key, is skipped rather than treated as an error: not every
+// entity in a batch necessarily populates every reference field.
+//
+// The returned map has no entry for a key that failed to load with
+// ErrNoSuchEntity; any other per-key error is returned as a MultiError
+// indexed the same way GetMulti's would be, against the de-duplicated
+// key list, not the original entities slice.
+func LoadReferences(ctx context.Context, entities interface{}, keyFields ...string) (map[string]PropertyMap, error) {
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("datastore: LoadReferences requires a slice, got %T", entities)
+	}
+
+	seen := map[string]*Key{}
+	for i := 0; i < v.Len(); i++ {
+		ev := v.Index(i)
+		if ev.Kind() == reflect.Ptr {
+			ev = ev.Elem()
+		}
+		if ev.Kind() != reflect.Struct {
+			continue
+		}
+		for _, name := range keyFields {
+			fv := ev.FieldByName(name)
+			if !fv.IsValid() || fv.Type() != reflect.TypeOf((*Key)(nil)) {
+				continue
+			}
+			k, _ := fv.Interface().(*Key)
+			if k == nil {
+				continue
+			}
+			seen[k.String()] = k
+		}
+	}
+	if len(seen) == 0 {
+		return map[string]PropertyMap{}, nil
+	}
+
+	keys := make([]*Key, 0, len(seen))
+	for _, k := range seen {
+		keys = append(keys, k)
+	}
+
+	vals := make([]PropertyMap, len(keys))
+	for i := range vals {
+		vals[i] = PropertyMap{}
+	}
+	err := Raw(ctx).GetMulti(keys, vals)
+
+	out := make(map[string]PropertyMap, len(keys))
+	if err == nil {
+		for i, k := range keys {
+			out[k.String()] = vals[i]
+		}
+		return out, nil
+	}
+
+	me, ok := err.(MultiError)
+	if !ok {
+		return nil, err
+	}
+	result := make(MultiError, len(keys))
+	anyErr := false
+	for i, k := range keys {
+		if me[i] == nil {
+			out[k.String()] = vals[i]
+			continue
+		}
+		if me[i] == ErrNoSuchEntity {
+			continue
+		}
+		result[i] = me[i]
+		anyErr = true
+	}
+	if anyErr {
+		return out, result
+	}
+	return out, nil
+}
@@ -0,0 +1,87 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type lrAuthor struct {
+	Name string
+}
+
+type lrPost struct {
+	Title  string
+	Author *ds.Key
+	Editor *ds.Key
+}
+
+func TestLoadReferencesDedupesAndLoadsSharedKeys(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+
+	alice := ds.NewKey("app", "", "Author", "alice", 0, nil)
+	bob := ds.NewKey("app", "", "Author", "bob", 0, nil)
+
+	alicePM, _ := ds.SaveStruct(&lrAuthor{Name: "Alice"})
+	bobPM, _ := ds.SaveStruct(&lrAuthor{Name: "Bob"})
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{alice, bob}, []ds.PropertyMap{alicePM, bobPM}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+
+	posts := []*lrPost{
+		{Title: "post1", Author: alice, Editor: bob},
+		{Title: "post2", Author: alice},
+	}
+
+	refs, err := ds.LoadReferences(ctx, posts, "Author", "Editor")
+	if err != nil {
+		t.Fatalf("LoadReferences: %v", err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("got %d references, want 2 (deduped): %v", len(refs), refs)
+	}
+
+	var got lrAuthor
+	if err := ds.LoadStruct(&got, refs[alice.String()]); err != nil {
+		t.Fatalf("LoadStruct(alice): %v", err)
+	}
+	if got.Name != "Alice" {
+		t.Errorf("alice.Name = %q, want Alice", got.Name)
+	}
+
+	if err := ds.LoadStruct(&got, refs[bob.String()]); err != nil {
+		t.Fatalf("LoadStruct(bob): %v", err)
+	}
+	if got.Name != "Bob" {
+		t.Errorf("bob.Name = %q, want Bob", got.Name)
+	}
+}
+
+func TestLoadReferencesSkipsNilAndMissingFields(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	posts := []*lrPost{{Title: "post1"}}
+
+	refs, err := ds.LoadReferences(ctx, posts, "Author", "Editor")
+	if err != nil {
+		t.Fatalf("LoadReferences: %v", err)
+	}
+	if len(refs) != 0 {
+		t.Errorf("got %d references, want 0", len(refs))
+	}
+}
+
+func TestLoadReferencesOmitsMissingEntities(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ghost := ds.NewKey("app", "", "Author", "ghost", 0, nil)
+	posts := []*lrPost{{Title: "post1", Author: ghost}}
+
+	refs, err := ds.LoadReferences(ctx, posts, "Author")
+	if err != nil {
+		t.Fatalf("LoadReferences: %v", err)
+	}
+	if len(refs) != 0 {
+		t.Errorf("got %d references, want 0 (ErrNoSuchEntity keys are omitted): %v", len(refs), refs)
+	}
+}
@@ -0,0 +1,94 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrTooManyTxnEntities is returned by GetMulti/PutMulti/DeleteMulti when
+// a call made within a RunInTransaction guarded by WithMaxTxnEntities
+// would push the transaction's total entity count over its limit.
+type ErrTooManyTxnEntities struct {
+	Max   int
+	Count int
+}
+
+func (e *ErrTooManyTxnEntities) Error() string {
+	return fmt.Sprintf("datastore: transaction touched %d entities, exceeding the limit of %d", e.Count, e.Max)
+}
+
+type txnEntityCounterKeyType struct{}
+
+var txnEntityCounterKey txnEntityCounterKeyType
+
+// txnEntityCounter is the mutable, shared counter a maxTxnEntitiesFilter
+// installs into a transaction's context for the duration of the
+// RunInTransaction call (including all of its retry attempts, since
+// RunInTransaction does not give each attempt a distinct context).
+type txnEntityCounter struct {
+	max   int
+	count int
+}
+
+func (c *txnEntityCounter) add(n int) error {
+	c.count += n
+	if c.count > c.max {
+		return &ErrTooManyTxnEntities{Max: c.max, Count: c.count}
+	}
+	return nil
+}
+
+// maxTxnEntitiesFilter enforces max as the maximum number of entities a
+// single RunInTransaction call may read or write. counter is the one
+// installed by the enclosing RunInTransaction call, captured fresh each
+// time Raw(ctx) rebuilds the filter chain; it is nil outside of a
+// transaction, in which case this filter does no counting at all.
+type maxTxnEntitiesFilter struct {
+	RawInterface
+	max     int
+	counter *txnEntityCounter
+}
+
+// WithMaxTxnEntities returns a context derived from ctx under which every
+// RunInTransaction call counts the entities touched by GetMulti/PutMulti/
+// DeleteMulti and fails with *ErrTooManyTxnEntities once the running
+// total exceeds max. Operations outside of a transaction are not
+// counted.
+func WithMaxTxnEntities(ctx context.Context, max int) context.Context {
+	return AddRawFilters(ctx, func(ctx context.Context, raw RawInterface) RawInterface {
+		counter, _ := ctx.Value(txnEntityCounterKey).(*txnEntityCounter)
+		return &maxTxnEntitiesFilter{RawInterface: raw, max: max, counter: counter}
+	})
+}
+
+func (f *maxTxnEntitiesFilter) RunInTransaction(ctx context.Context, fn func(context.Context) error, opts *TransactionOptions) error {
+	txnCtx := context.WithValue(ctx, txnEntityCounterKey, &txnEntityCounter{max: f.max})
+	return f.RawInterface.RunInTransaction(txnCtx, fn, opts)
+}
+
+func (f *maxTxnEntitiesFilter) GetMulti(keys []*Key, vals []PropertyMap) error {
+	if f.counter != nil {
+		if err := f.counter.add(len(keys)); err != nil {
+			return err
+		}
+	}
+	return f.RawInterface.GetMulti(keys, vals)
+}
+
+func (f *maxTxnEntitiesFilter) PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error) {
+	if f.counter != nil {
+		if err := f.counter.add(len(keys)); err != nil {
+			return nil, err
+		}
+	}
+	return f.RawInterface.PutMulti(keys, vals)
+}
+
+func (f *maxTxnEntitiesFilter) DeleteMulti(keys []*Key) error {
+	if f.counter != nil {
+		if err := f.counter.add(len(keys)); err != nil {
+			return err
+		}
+	}
+	return f.RawInterface.DeleteMulti(keys)
+}
@@ -0,0 +1,86 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type mteWidget struct {
+	Count int64
+}
+
+func TestMaxTxnEntitiesAllowsWritesWithinLimit(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ctx = ds.WithMaxTxnEntities(ctx, 2)
+
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		keys := []*ds.Key{
+			ds.NewKey("app", "", "Widget", "a", 0, nil),
+			ds.NewKey("app", "", "Widget", "b", 0, nil),
+		}
+		vals := []ds.PropertyMap{{"Count": int64(1)}, {"Count": int64(2)}}
+		_, err := ds.Raw(ctx).PutMulti(keys, vals)
+		return err
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+}
+
+func TestMaxTxnEntitiesFailsWhenLimitExceededInOneCall(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ctx = ds.WithMaxTxnEntities(ctx, 2)
+
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		keys := []*ds.Key{
+			ds.NewKey("app", "", "Widget", "a", 0, nil),
+			ds.NewKey("app", "", "Widget", "b", 0, nil),
+			ds.NewKey("app", "", "Widget", "c", 0, nil),
+		}
+		vals := []ds.PropertyMap{{"Count": int64(1)}, {"Count": int64(2)}, {"Count": int64(3)}}
+		_, err := ds.Raw(ctx).PutMulti(keys, vals)
+		return err
+	}, nil)
+	if _, ok := err.(*ds.ErrTooManyTxnEntities); !ok {
+		t.Fatalf("err = %v (%T), want *ErrTooManyTxnEntities", err, err)
+	}
+}
+
+func TestMaxTxnEntitiesFailsWhenLimitExceededAcrossCalls(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ctx = ds.WithMaxTxnEntities(ctx, 2)
+
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		if _, err := ds.Raw(ctx).PutMulti(
+			[]*ds.Key{ds.NewKey("app", "", "Widget", "a", 0, nil)},
+			[]ds.PropertyMap{{"Count": int64(1)}},
+		); err != nil {
+			return err
+		}
+		_, err := ds.Raw(ctx).PutMulti(
+			[]*ds.Key{ds.NewKey("app", "", "Widget", "b", 0, nil), ds.NewKey("app", "", "Widget", "c", 0, nil)},
+			[]ds.PropertyMap{{"Count": int64(2)}, {"Count": int64(3)}},
+		)
+		return err
+	}, nil)
+	if _, ok := err.(*ds.ErrTooManyTxnEntities); !ok {
+		t.Fatalf("err = %v (%T), want *ErrTooManyTxnEntities", err, err)
+	}
+}
+
+func TestMaxTxnEntitiesDoesNotLimitOutsideTransaction(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ctx = ds.WithMaxTxnEntities(ctx, 1)
+
+	keys := []*ds.Key{
+		ds.NewKey("app", "", "Widget", "a", 0, nil),
+		ds.NewKey("app", "", "Widget", "b", 0, nil),
+	}
+	vals := []ds.PropertyMap{{"Count": int64(1)}, {"Count": int64(2)}}
+	if _, err := ds.Raw(ctx).PutMulti(keys, vals); err != nil {
+		t.Fatalf("PutMulti outside transaction: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestMutateAppliesMixedPutsAndDeletesInOneEntityGroup(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	parent := ds.NewKey("app", "", "Account", "", 1, nil)
+	toDelete := ds.NewKey("app", "", "Entry", "old", 0, parent)
+	toPut := ds.NewKey("app", "", "Entry", "new", 0, parent)
+
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{toDelete}, []ds.PropertyMap{{"V": int64(1)}}); err != nil {
+		t.Fatalf("seed PutMulti: %v", err)
+	}
+
+	err := ds.Mutate(ctx, []ds.Mutation{
+		ds.DeleteMutation(toDelete),
+		ds.PutMutation(toPut, ds.PropertyMap{"V": int64(2)}),
+	})
+	if err != nil {
+		t.Fatalf("Mutate: %v", err)
+	}
+
+	var out ds.PropertyMap
+	out = ds.PropertyMap{}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{toDelete}, []ds.PropertyMap{out}); err == nil {
+		t.Errorf("expected toDelete to be gone")
+	}
+	out = ds.PropertyMap{}
+	if err := ds.Raw(ctx).GetMulti([]*ds.Key{toPut}, []ds.PropertyMap{out}); err != nil {
+		t.Fatalf("GetMulti(toPut): %v", err)
+	}
+	if out["V"].(int64) != 2 {
+		t.Errorf("toPut.V = %v, want 2", out["V"])
+	}
+}
+
+func TestMutateAppliesAcrossDifferentEntityGroupsNonAtomically(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k1 := ds.NewKey("app", "", "Account", "", 1, nil)
+	k2 := ds.NewKey("app", "", "Account", "", 2, nil)
+
+	err := ds.Mutate(ctx, []ds.Mutation{
+		ds.PutMutation(k1, ds.PropertyMap{"V": int64(1)}),
+		ds.PutMutation(k2, ds.PropertyMap{"V": int64(2)}),
+	})
+	if err != nil {
+		t.Fatalf("Mutate: %v", err)
+	}
+
+	for k, want := range map[*ds.Key]int64{k1: 1, k2: 2} {
+		out := ds.PropertyMap{}
+		if err := ds.Raw(ctx).GetMulti([]*ds.Key{k}, []ds.PropertyMap{out}); err != nil {
+			t.Fatalf("GetMulti(%v): %v", k, err)
+		}
+		if out["V"].(int64) != want {
+			t.Errorf("%v.V = %v, want %d", k, out["V"], want)
+		}
+	}
+}
+
+func TestMutateEmptyIsNoop(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	if err := ds.Mutate(ctx, nil); err != nil {
+		t.Fatalf("Mutate(nil): %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package datastore
+
+import "context"
+
+// Mutation is a single Put or Delete, as used by Mutate and
+// CompareAndMutate to describe a batch of writes of mixed kind without
+// forcing callers to split them into separate PutMulti/DeleteMulti
+// calls themselves.
+//
+// A Mutation is a Delete if Value is nil, and a Put otherwise.
+type Mutation struct {
+	// Key is the entity to put or delete.
+	Key *Key
+	// Value is the entity's new contents for a Put, or nil for a Delete.
+	Value PropertyMap
+}
+
+// PutMutation returns a Mutation that writes val under key.
+func PutMutation(key *Key, val PropertyMap) Mutation {
+	return Mutation{Key: key, Value: val}
+}
+
+// DeleteMutation returns a Mutation that deletes key.
+func DeleteMutation(key *Key) Mutation {
+	return Mutation{Key: key}
+}
+
+// CompareAndMutate reads condKey, evaluates cond against its current
+// contents (an empty PropertyMap if condKey does not exist), and, if
+// cond returns true, applies the Mutations returned by mutate. The read
+// and the mutations happen inside a single transaction on condKey's
+// entity group, so the check and the writes are atomic with respect to
+// concurrent transactions on that group: if cond returns false, nothing
+// is written and CompareAndMutate returns false, nil.
+//
+// Every key mutate returns must belong to condKey's entity group;
+// CompareAndMutate does not itself enforce this, and impl/memory's
+// transactions do not reject a cross-group write either.
+func CompareAndMutate(ctx context.Context, condKey *Key, cond func(PropertyMap) bool, mutate func() []Mutation) (bool, error) {
+	matched := false
+	err := RunInTransaction(ctx, func(ctx context.Context) error {
+		matched = false
+		pm := PropertyMap{}
+		err := Raw(ctx).GetMulti([]*Key{condKey}, []PropertyMap{pm})
+		if err != nil {
+			if me, ok := err.(MultiError); !ok || me[0] != ErrNoSuchEntity {
+				return err
+			}
+			pm = PropertyMap{}
+		}
+		if !cond(pm) {
+			return nil
+		}
+		matched = true
+		return applyMutations(ctx, mutate())
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+	return matched, nil
+}
+
+// Mutate applies muts to the backend installed in ctx. If every key in
+// muts belongs to the same entity group, Mutate applies them all inside
+// a single transaction, so the whole batch commits or none of it does;
+// otherwise it applies the puts with one PutMulti call and then the
+// deletes with one DeleteMulti call, each with that call's per-item
+// semantics. If PutMulti returns an error, Mutate returns it without
+// applying the deletes.
+func Mutate(ctx context.Context, muts []Mutation) error {
+	if len(muts) == 0 {
+		return nil
+	}
+	if sameEntityGroup(muts) {
+		return RunInTransaction(ctx, func(ctx context.Context) error {
+			return applyMutations(ctx, muts)
+		}, nil)
+	}
+	return applyMutations(ctx, muts)
+}
+
+func applyMutations(ctx context.Context, muts []Mutation) error {
+	var putKeys, delKeys []*Key
+	var putVals []PropertyMap
+	for _, m := range muts {
+		if m.Value == nil {
+			delKeys = append(delKeys, m.Key)
+		} else {
+			putKeys = append(putKeys, m.Key)
+			putVals = append(putVals, m.Value)
+		}
+	}
+	if len(putKeys) > 0 {
+		if _, err := Raw(ctx).PutMulti(putKeys, putVals); err != nil {
+			return err
+		}
+	}
+	if len(delKeys) > 0 {
+		if err := Raw(ctx).DeleteMulti(delKeys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameEntityGroup reports whether every key among muts shares the same
+// entity group root.
+func sameEntityGroup(muts []Mutation) bool {
+	if len(muts) == 0 {
+		return true
+	}
+	root := muts[0].Key.Root()
+	for _, m := range muts[1:] {
+		if !m.Key.Root().Equal(root) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package datastore
+
+import "context"
+
+// namespaceFilter scopes every operation it sees to ns, by rewriting any
+// key or query that doesn't already carry an explicit namespace.
+type namespaceFilter struct {
+	RawInterface
+	ns string
+}
+
+// WithNamespace returns a context derived from ctx whose datastore
+// operations default to namespace ns: any key passed to GetMulti/
+// PutMulti/DeleteMulti, and any query passed to Run/Count, that doesn't
+// already carry an explicit namespace is scoped to ns before reaching
+// the backend.
+//
+// A key or query that already specifies a non-default namespace is left
+// untouched — WithNamespace only fills in an unset namespace, it never
+// overrides one the caller set. Because "" is indistinguishable from
+// "never set", a key or query explicitly pinned to the default
+// namespace is also scoped to ns; pin it to a real namespace instead if
+// it must stay on default underneath WithNamespace.
+func WithNamespace(ctx context.Context, ns string) context.Context {
+	return AddRawFilters(ctx, func(_ context.Context, raw RawInterface) RawInterface {
+		return &namespaceFilter{RawInterface: raw, ns: ns}
+	})
+}
+
+func scopeKeyNamespace(k *Key, ns string) *Key {
+	if k == nil {
+		return nil
+	}
+	if k.namespace != "" {
+		return k
+	}
+	return &Key{
+		appID:     k.appID,
+		namespace: ns,
+		kind:      k.kind,
+		stringID:  k.stringID,
+		intID:     k.intID,
+		parent:    scopeKeyNamespace(k.parent, ns),
+	}
+}
+
+func (f *namespaceFilter) GetMulti(keys []*Key, vals []PropertyMap) error {
+	scoped := make([]*Key, len(keys))
+	for i, k := range keys {
+		scoped[i] = scopeKeyNamespace(k, f.ns)
+	}
+	return f.RawInterface.GetMulti(scoped, vals)
+}
+
+func (f *namespaceFilter) PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error) {
+	scoped := make([]*Key, len(keys))
+	for i, k := range keys {
+		scoped[i] = scopeKeyNamespace(k, f.ns)
+	}
+	return f.RawInterface.PutMulti(scoped, vals)
+}
+
+func (f *namespaceFilter) DeleteMulti(keys []*Key) error {
+	scoped := make([]*Key, len(keys))
+	for i, k := range keys {
+		scoped[i] = scopeKeyNamespace(k, f.ns)
+	}
+	return f.RawInterface.DeleteMulti(scoped)
+}
+
+func (f *namespaceFilter) Run(q *Query, cb RunCB) error {
+	return f.RawInterface.Run(f.scopeQuery(q), cb)
+}
+
+func (f *namespaceFilter) Count(q *Query) (int64, error) {
+	return f.RawInterface.Count(f.scopeQuery(q))
+}
+
+func (f *namespaceFilter) scopeQuery(q *Query) *Query {
+	if q.GetNamespace() != "" {
+		return q
+	}
+	return q.Namespace(f.ns)
+}
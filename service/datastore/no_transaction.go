@@ -0,0 +1,24 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInTransaction is returned by AssertNoTransaction when called from
+// inside a RunInTransaction body.
+var ErrInTransaction = errors.New("datastore: operation not allowed inside a transaction")
+
+// AssertNoTransaction returns ErrInTransaction if ctx is inside a
+// RunInTransaction body (per InTransaction), and nil otherwise.
+//
+// Library authors can call this as a guard at the top of a helper that
+// performs a non-idempotent side effect (e.g. an outbound RPC) to
+// prevent it from accidentally being included in a caller's transaction,
+// where it could be silently re-executed on retry.
+func AssertNoTransaction(ctx context.Context) error {
+	if InTransaction(ctx) {
+		return ErrInTransaction
+	}
+	return nil
+}
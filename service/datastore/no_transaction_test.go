@@ -0,0 +1,22 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAssertNoTransaction(t *testing.T) {
+	ctx := context.Background()
+	if err := AssertNoTransaction(ctx); err != nil {
+		t.Errorf("AssertNoTransaction outside a transaction = %v, want nil", err)
+	}
+
+	ctx = SetRaw(ctx, fakeRaw{})
+	err := RunInTransaction(ctx, func(txCtx context.Context) error {
+		return AssertNoTransaction(txCtx)
+	}, nil)
+	if !errors.Is(err, ErrInTransaction) {
+		t.Errorf("AssertNoTransaction inside a transaction = %v, want ErrInTransaction", err)
+	}
+}
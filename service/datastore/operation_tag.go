@@ -0,0 +1,27 @@
+package datastore
+
+import "context"
+
+type operationTagKeyType struct{}
+
+var operationTagKey operationTagKeyType
+
+// WithOperationTag returns a context carrying tag, a free-form label
+// for whatever higher-level business operation is about to issue
+// datastore calls with it (e.g. "ImportOrder", "NightlyReconcile").
+// Filters that record per-call metrics, logs, or traces (see
+// filter/oplog) can read it back via OperationTag and include it in
+// their output, so a slow query or an error can be correlated back to
+// the business operation that caused it instead of just the raw
+// RawInterface call that happened to be running at the time.
+func WithOperationTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, operationTagKey, tag)
+}
+
+// OperationTag returns the tag installed by WithOperationTag, and
+// whether one was present. It returns ("", false) for a ctx no
+// WithOperationTag call has touched.
+func OperationTag(ctx context.Context) (string, bool) {
+	tag, ok := ctx.Value(operationTagKey).(string)
+	return tag, ok
+}
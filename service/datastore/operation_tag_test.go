@@ -0,0 +1,23 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestOperationTagRoundTrips(t *testing.T) {
+	ctx := ds.WithOperationTag(context.Background(), "ImportOrder")
+	tag, ok := ds.OperationTag(ctx)
+	if !ok || tag != "ImportOrder" {
+		t.Errorf("OperationTag = %q, %v, want ImportOrder, true", tag, ok)
+	}
+}
+
+func TestOperationTagAbsentByDefault(t *testing.T) {
+	tag, ok := ds.OperationTag(context.Background())
+	if ok || tag != "" {
+		t.Errorf("OperationTag = %q, %v, want \"\", false", tag, ok)
+	}
+}
@@ -0,0 +1,37 @@
+package datastore
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrPanicInCallback wraps a panic recovered from a Run call (and so,
+// transitively, from whatever RunCB or backend code it invoked) made on
+// a background goroutine, so it surfaces to the goroutine's caller as an
+// error instead of crashing the process — the goroutine's own stack has
+// already unwound past anyone who could otherwise recover it. The
+// original panic value and a captured stack trace are both preserved
+// for debugging.
+//
+// This package has no paniccatcher dependency (nothing in this tree
+// vendors one), so this is a small self-contained equivalent, just
+// enough to turn a goroutine panic into a returned error.
+type ErrPanicInCallback struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *ErrPanicInCallback) Error() string {
+	return fmt.Sprintf("datastore: panic in callback: %v\n%s", e.Value, e.Stack)
+}
+
+// recoverCallbackPanic is deferred at the top of a goroutine that runs a
+// query or callback on another goroutine's behalf; if that work panics,
+// it's recovered here and stored through errOut instead of unwinding
+// into the goroutine's caller, which has already moved on (e.g. to wait
+// on a channel or WaitGroup) and isn't on the stack to catch it itself.
+func recoverCallbackPanic(errOut *error) {
+	if r := recover(); r != nil {
+		*errOut = &ErrPanicInCallback{Value: r, Stack: debug.Stack()}
+	}
+}
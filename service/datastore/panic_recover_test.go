@@ -0,0 +1,51 @@
+package datastore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type panickingRaw struct {
+	ds.RawInterface
+}
+
+func (panickingRaw) Run(q *ds.Query, cb ds.RunCB) error {
+	panic("boom")
+}
+
+func TestRunUnionRecoversPanicFromQueryRun(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), panickingRaw{memory.NewDatastore("app")})
+
+	err := ds.RunUnion(ctx, []*ds.Query{ds.NewQuery("Widget")}, func(a, b ds.PropertyMap) bool { return false },
+		func(*ds.Key, ds.PropertyMap) error { return nil })
+
+	var pe *ds.ErrPanicInCallback
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v, want *ErrPanicInCallback", err)
+	}
+	if pe.Value != "boom" {
+		t.Errorf("Value = %v, want %q", pe.Value, "boom")
+	}
+}
+
+func TestIteratorRecoversPanicFromRun(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), panickingRaw{memory.NewDatastore("app")})
+
+	it, err := ds.NewIterator(ctx, ds.NewQuery("Widget"))
+	if err != nil {
+		t.Fatalf("NewIterator: %v", err)
+	}
+	defer it.Close()
+
+	var dst struct{}
+	_, err = it.Next(&dst)
+
+	var pe *ds.ErrPanicInCallback
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v, want *ErrPanicInCallback", err)
+	}
+}
@@ -0,0 +1,26 @@
+package datastore
+
+import "context"
+
+// MutationCounter is implemented by a RawInterface that can report how
+// many mutations have been made so far in the transaction ctx is
+// running inside of. See PendingMutations.
+type MutationCounter interface {
+	PendingMutations(ctx context.Context) (int, bool)
+}
+
+// PendingMutations returns how many PutMulti/DeleteMulti mutations have
+// been made so far in the transaction ctx is running inside of, and
+// whether a count is available at all. It returns (0, false) outside of
+// a transaction, or when ctx's installed backend doesn't implement
+// MutationCounter — currently only impl/memory does, since it's the
+// only backend in this module with its own notion of a single
+// in-progress transaction to count against; impl/cloud is a logging/
+// auth config layer with no datastore RPC implementation of its own
+// (see BlobOpener's doc comment for the same caveat).
+func PendingMutations(ctx context.Context) (int, bool) {
+	if mc, ok := RawUnfiltered(ctx).(MutationCounter); ok {
+		return mc.PendingMutations(ctx)
+	}
+	return 0, false
+}
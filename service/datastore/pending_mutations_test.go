@@ -0,0 +1,53 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestPendingMutationsGrowsWithPutsAndDeletes(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+
+	var counts []int
+	err := ds.RunInTransaction(ctx, func(ctx context.Context) error {
+		if n, ok := ds.PendingMutations(ctx); !ok || n != 0 {
+			t.Fatalf("PendingMutations before any mutation = %d, %v, want 0, true", n, ok)
+		}
+
+		k := ds.NewKey("app", "", "Widget", "a", 0, nil)
+		if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{"Name": "a"}}); err != nil {
+			return err
+		}
+		n, ok := ds.PendingMutations(ctx)
+		counts = append(counts, n)
+		if !ok {
+			t.Fatalf("PendingMutations after Put: ok = false")
+		}
+
+		if err := ds.Raw(ctx).DeleteMulti([]*ds.Key{k}); err != nil {
+			return err
+		}
+		n, ok = ds.PendingMutations(ctx)
+		counts = append(counts, n)
+		if !ok {
+			t.Fatalf("PendingMutations after Delete: ok = false")
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+	if len(counts) != 2 || counts[0] != 1 || counts[1] != 2 {
+		t.Errorf("counts = %v, want [1 2]", counts)
+	}
+}
+
+func TestPendingMutationsFalseOutsideTransaction(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	if n, ok := ds.PendingMutations(ctx); ok || n != 0 {
+		t.Errorf("PendingMutations outside a transaction = %d, %v, want 0, false", n, ok)
+	}
+}
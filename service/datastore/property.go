@@ -0,0 +1,70 @@
+package datastore
+
+import "strings"
+
+// MetaPrefix is the prefix used on PropertyMap keys which hold metadata
+// (e.g. "$id", "$kind") rather than user data.
+const MetaPrefix = "$"
+
+// Property is a single datastore property value. A PropertyMap value may
+// be a Property, a []Property (for multi-valued/repeated properties), or
+// a plain Go value (as SaveStruct stores).
+type Property struct {
+	value interface{}
+}
+
+// MkProperty constructs a Property wrapping value.
+func MkProperty(value interface{}) Property { return Property{value: value} }
+
+// Value returns the underlying value held by this Property.
+func (p Property) Value() interface{} { return p.value }
+
+// PropertyMap is the schemaless representation of a datastore entity: a
+// map from property name to a Property, a []Property, or a plain Go
+// value such as an int64 or string (SaveStruct stores plain values). Keys
+// prefixed with MetaPrefix ("$") are metadata such as "$id", "$kind",
+// "$parent", and are not part of the entity's indexed data.
+type PropertyMap map[string]interface{}
+
+// IsMetaKey returns true if name identifies a metadata slot (e.g. "$id")
+// rather than a regular data property.
+func IsMetaKey(name string) bool {
+	return strings.HasPrefix(name, MetaPrefix)
+}
+
+// DataOnly returns a copy of pm containing only its non-meta (user data)
+// properties, i.e. those whose key does not begin with MetaPrefix.
+//
+// Use this before serializing a PropertyMap for consumption outside of
+// datastore, where internal bookkeeping like "$id" or "$kind" should not
+// leak. See also MetaOnly, its exact complement.
+func (pm PropertyMap) DataOnly() PropertyMap {
+	out := make(PropertyMap, len(pm))
+	for k, v := range pm {
+		if !IsMetaKey(k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// MetaOnly returns a copy of pm containing only its meta properties,
+// i.e. those whose key begins with MetaPrefix. It is the exact
+// complement of DataOnly: for any pm, DataOnly and MetaOnly partition
+// its keys with no overlap.
+func (pm PropertyMap) MetaOnly() PropertyMap {
+	out := make(PropertyMap, len(pm))
+	for k, v := range pm {
+		if IsMetaKey(k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// PropertyLoadSaver is implemented by types which know how to convert
+// themselves to and from a PropertyMap, bypassing struct-tag reflection.
+type PropertyLoadSaver interface {
+	Load(PropertyMap) error
+	Save(withMeta bool) (PropertyMap, error)
+}
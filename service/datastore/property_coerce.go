@@ -0,0 +1,86 @@
+package datastore
+
+import (
+	"fmt"
+	"time"
+)
+
+// As converts p's stored value into dst, which must be a non-nil pointer.
+// Supported coercions (source type -> *dst type):
+//
+//   - int64 -> *int, *int32, *int64, *float64
+//   - float64 -> *float64, *int64 (truncating)
+//   - string -> *string, *[]byte
+//   - []byte -> *[]byte, *string
+//   - bool -> *bool
+//   - time.Time -> *time.Time, *string (RFC3339)
+//
+// Any other combination, or a value that doesn't match the stored type
+// exactly, returns an error naming both types; an unsupported destination
+// type returns an error naming that type.
+func (p Property) As(dst interface{}) error {
+	switch d := dst.(type) {
+	case *int:
+		if v, ok := p.value.(int64); ok {
+			*d = int(v)
+			return nil
+		}
+	case *int32:
+		if v, ok := p.value.(int64); ok {
+			*d = int32(v)
+			return nil
+		}
+	case *int64:
+		switch v := p.value.(type) {
+		case int64:
+			*d = v
+			return nil
+		case float64:
+			*d = int64(v)
+			return nil
+		}
+	case *float64:
+		switch v := p.value.(type) {
+		case float64:
+			*d = v
+			return nil
+		case int64:
+			*d = float64(v)
+			return nil
+		}
+	case *string:
+		switch v := p.value.(type) {
+		case string:
+			*d = v
+			return nil
+		case []byte:
+			*d = string(v)
+			return nil
+		case time.Time:
+			*d = v.Format(time.RFC3339)
+			return nil
+		}
+	case *[]byte:
+		switch v := p.value.(type) {
+		case []byte:
+			*d = v
+			return nil
+		case string:
+			*d = []byte(v)
+			return nil
+		}
+	case *bool:
+		if v, ok := p.value.(bool); ok {
+			*d = v
+			return nil
+		}
+	case *time.Time:
+		if v, ok := p.value.(time.Time); ok {
+			*d = v
+			return nil
+		}
+	default:
+		return fmt.Errorf("datastore: Property.As does not support destination type %T", dst)
+	}
+	return fmt.Errorf("datastore: cannot coerce stored value of type %T into %T", p.value, dst)
+}
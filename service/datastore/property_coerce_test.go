@@ -0,0 +1,37 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPropertyAsCoercions(t *testing.T) {
+	var i int
+	if err := MkProperty(int64(42)).As(&i); err != nil || i != 42 {
+		t.Errorf("int64->int: %v, %d", err, i)
+	}
+
+	var b []byte
+	if err := MkProperty("hello").As(&b); err != nil || string(b) != "hello" {
+		t.Errorf("string->[]byte: %v, %q", err, b)
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var s string
+	if err := MkProperty(now).As(&s); err != nil || s != now.Format(time.RFC3339) {
+		t.Errorf("time->string: %v, %q", err, s)
+	}
+
+	var f float64
+	if err := MkProperty(int64(7)).As(&f); err != nil || f != 7 {
+		t.Errorf("int64->float64: %v, %v", err, f)
+	}
+}
+
+func TestPropertyAsIncompatible(t *testing.T) {
+	var i int
+	err := MkProperty("not a number").As(&i)
+	if err == nil {
+		t.Fatal("expected an error coercing a string into *int")
+	}
+}
@@ -0,0 +1,61 @@
+package datastore
+
+// MergePolicy controls how PropertyMap.Merge resolves a property
+// present in both maps being merged.
+type MergePolicy int
+
+const (
+	// MergeOverwrite replaces the existing value with the incoming one.
+	MergeOverwrite MergePolicy = iota
+
+	// MergeKeepExisting leaves the existing value untouched.
+	MergeKeepExisting
+
+	// MergeAppendMulti concatenates the two values into one
+	// multi-valued ([]Property) property, flattening either side that
+	// is already multi-valued rather than nesting it.
+	MergeAppendMulti
+)
+
+// Merge returns a new PropertyMap containing every property from pm and
+// other, neither of which is modified. A property present in only one
+// of the two is copied through unchanged; a property present in both is
+// resolved according to policy.
+func (pm PropertyMap) Merge(other PropertyMap, policy MergePolicy) PropertyMap {
+	out := make(PropertyMap, len(pm)+len(other))
+	for k, v := range pm {
+		out[k] = v
+	}
+	for k, v := range other {
+		existing, ok := out[k]
+		if !ok {
+			out[k] = v
+			continue
+		}
+		switch policy {
+		case MergeKeepExisting:
+			// out[k] already holds the existing value.
+		case MergeAppendMulti:
+			out[k] = append(asPropertySlice(existing), asPropertySlice(v)...)
+		default: // MergeOverwrite
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// asPropertySlice normalizes a PropertyMap value (a single Property, a
+// []Property, or a plain value, which is wrapped with MkProperty) to a
+// []Property, so MergeAppendMulti can
+// concatenate two values regardless of which side, if either, was
+// already multi-valued.
+func asPropertySlice(v interface{}) []Property {
+	switch x := v.(type) {
+	case Property:
+		return []Property{x}
+	case []Property:
+		return append([]Property{}, x...)
+	default:
+		return []Property{MkProperty(x)}
+	}
+}
@@ -0,0 +1,66 @@
+package datastore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPropertyMapMergeOverwrite(t *testing.T) {
+	pm := PropertyMap{"Name": MkProperty("old")}
+	other := PropertyMap{"Name": MkProperty("new")}
+
+	merged := pm.Merge(other, MergeOverwrite)
+	if got := merged["Name"].(Property).Value(); got != "new" {
+		t.Errorf("Name = %v, want %q", got, "new")
+	}
+	if got := pm["Name"].(Property).Value(); got != "old" {
+		t.Errorf("pm was mutated: Name = %v, want %q", got, "old")
+	}
+}
+
+func TestPropertyMapMergeKeepExisting(t *testing.T) {
+	pm := PropertyMap{"Name": MkProperty("old")}
+	other := PropertyMap{"Name": MkProperty("new")}
+
+	merged := pm.Merge(other, MergeKeepExisting)
+	if got := merged["Name"].(Property).Value(); got != "old" {
+		t.Errorf("Name = %v, want %q", got, "old")
+	}
+}
+
+func TestPropertyMapMergeAppendMulti(t *testing.T) {
+	pm := PropertyMap{"Tag": MkProperty("a")}
+	other := PropertyMap{"Tag": MkProperty("b")}
+
+	merged := pm.Merge(other, MergeAppendMulti)
+	got, ok := merged["Tag"].([]Property)
+	if !ok {
+		t.Fatalf("Tag = %#v (%T), want []Property", merged["Tag"], merged["Tag"])
+	}
+	want := []Property{MkProperty("a"), MkProperty("b")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tag = %v, want %v", got, want)
+	}
+}
+
+func TestPropertyMapMergeAppendMultiFlattensExistingMultiValue(t *testing.T) {
+	pm := PropertyMap{"Tag": []Property{MkProperty("a"), MkProperty("b")}}
+	other := PropertyMap{"Tag": MkProperty("c")}
+
+	merged := pm.Merge(other, MergeAppendMulti)
+	got := merged["Tag"].([]Property)
+	want := []Property{MkProperty("a"), MkProperty("b"), MkProperty("c")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tag = %v, want %v", got, want)
+	}
+}
+
+func TestPropertyMapMergeCopiesThroughDisjointProperties(t *testing.T) {
+	pm := PropertyMap{"Name": MkProperty("widget")}
+	other := PropertyMap{"Count": MkProperty(int64(3))}
+
+	merged := pm.Merge(other, MergeOverwrite)
+	if len(merged) != 2 {
+		t.Fatalf("merged = %v, want both properties present", merged)
+	}
+}
@@ -0,0 +1,82 @@
+package datastore
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// PropertyPaths returns every property path a query against T may
+// legally filter or order on: one entry per exported field, except that
+// a struct-typed field (other than time.Time, a single stored value) is
+// replaced, recursively, by one dotted entry per exported field of that
+// struct (e.g. an "Address Address" field with a "City" field
+// contributes "Address.City" and no "Address" entry). It does not descend into pointer,
+// slice, or map fields — those are leaf properties as far as filtering
+// is concerned, even though they may themselves be or contain structs.
+//
+// This only describes which property paths are meaningful to filter
+// on; LoadStruct and SaveStruct do not flatten nested structs, so a
+// query whose results are decoded with LoadStruct still sees the
+// embedded struct as a single property.
+func PropertyPaths[T any]() []string {
+	var t T
+	return flattenedPropertyPaths(reflect.TypeOf(t), "", map[reflect.Type]bool{})
+}
+
+func flattenedPropertyPaths(t reflect.Type, prefix string, seen map[reflect.Type]bool) []string {
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	var paths []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		path := f.Name
+		if prefix != "" {
+			path = prefix + "." + f.Name
+		}
+		if f.Type.Kind() == reflect.Struct && f.Type != timeType {
+			paths = append(paths, flattenedPropertyPaths(f.Type, path, seen)...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// ValidateQueryFields checks that every property q filters or orders on
+// is a property path of T (see PropertyPaths), catching a typo'd or
+// renamed field at query-construction time instead of as a silently
+// empty result set.
+func ValidateQueryFields[T any](q *Query) error {
+	known := make(map[string]bool)
+	for _, p := range PropertyPaths[T]() {
+		known[p] = true
+	}
+
+	var unknown []string
+	seen := map[string]bool{}
+	for _, f := range q.Filters() {
+		if !known[f.Property] && !seen[f.Property] {
+			unknown = append(unknown, f.Property)
+			seen[f.Property] = true
+		}
+	}
+	for _, o := range q.Orders() {
+		if !known[o.Property] && !seen[o.Property] {
+			unknown = append(unknown, o.Property)
+			seen[o.Property] = true
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("datastore: query references unknown field(s) %v for type %T", unknown, *new(T))
+}
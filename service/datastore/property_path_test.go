@@ -0,0 +1,44 @@
+package datastore
+
+import (
+	"sort"
+	"testing"
+)
+
+type ppAddress struct {
+	City string
+	Zip  string
+}
+
+type ppPerson struct {
+	Name    string
+	Address ppAddress
+}
+
+func TestPropertyPathsFlattensEmbeddedStruct(t *testing.T) {
+	got := PropertyPaths[ppPerson]()
+	sort.Strings(got)
+	want := []string{"Address.City", "Address.Zip", "Name"}
+	if len(got) != len(want) {
+		t.Fatalf("PropertyPaths = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("PropertyPaths[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestValidateQueryFieldsAcceptsKnownPaths(t *testing.T) {
+	q := NewQuery("Person").Filter("Address.City", Equal, "NYC").Order("Name")
+	if err := ValidateQueryFields[ppPerson](q); err != nil {
+		t.Errorf("ValidateQueryFields = %v, want nil", err)
+	}
+}
+
+func TestValidateQueryFieldsRejectsUnknownPath(t *testing.T) {
+	q := NewQuery("Person").Filter("Address.Country", Equal, "US")
+	if err := ValidateQueryFields[ppPerson](q); err == nil {
+		t.Fatal("ValidateQueryFields = nil, want an error for an unknown field")
+	}
+}
@@ -0,0 +1,36 @@
+package datastore
+
+import "testing"
+
+func TestPropertyMapDataMetaSplit(t *testing.T) {
+	pm := PropertyMap{
+		"$id":   int64(1),
+		"$kind": "Widget",
+		"Name":  "widget-1",
+		"Count": int64(3),
+	}
+
+	data := pm.DataOnly()
+	if len(data) != 2 || data["Name"] != "widget-1" || data["Count"] != int64(3) {
+		t.Errorf("DataOnly = %#v, want only Name/Count", data)
+	}
+	for k := range data {
+		if IsMetaKey(k) {
+			t.Errorf("DataOnly leaked meta key %q", k)
+		}
+	}
+
+	meta := pm.MetaOnly()
+	if len(meta) != 2 || meta["$id"] != int64(1) || meta["$kind"] != "Widget" {
+		t.Errorf("MetaOnly = %#v, want only $id/$kind", meta)
+	}
+	for k := range meta {
+		if !IsMetaKey(k) {
+			t.Errorf("MetaOnly leaked data key %q", k)
+		}
+	}
+
+	if len(data)+len(meta) != len(pm) {
+		t.Errorf("DataOnly and MetaOnly do not partition pm: %d + %d != %d", len(data), len(meta), len(pm))
+	}
+}
@@ -0,0 +1,48 @@
+package datastore
+
+import (
+	"context"
+	"time"
+)
+
+// idempotencyTokenKind is the kind PutIdempotent uses to record tokens
+// it has already applied.
+const idempotencyTokenKind = "DS_IdempotencyToken"
+
+// PutIdempotent saves obj under key, but only actually writes it once
+// per token: a retry using the same token (e.g. a client retrying after
+// an ambiguous RPC failure) detects the prior success and returns nil
+// without writing obj again. Unlike PutT, it takes an explicit key
+// (this package has no way to derive one from an arbitrary obj).
+//
+// The check and the write happen in one transaction on key's entity
+// group, so they commit atomically: a concurrent retry with the same
+// token can never slip through and double-write. The token record
+// itself expires after ttl, bounding how much idempotency bookkeeping
+// accumulates; reusing a token after its TTL has elapsed is treated as
+// a fresh token (the write is applied again).
+func PutIdempotent(ctx context.Context, key *Key, token string, ttl time.Duration, obj interface{}) error {
+	pm, err := SaveStruct(obj)
+	if err != nil {
+		return err
+	}
+	tokenKey := NewKey(key.AppID(), key.Namespace(), idempotencyTokenKind, token, 0, key.Root())
+
+	return RunInTransaction(ctx, func(ctx context.Context) error {
+		existing := PropertyMap{}
+		err := Raw(ctx).GetMulti([]*Key{tokenKey}, []PropertyMap{existing})
+		if err == nil {
+			if expires, ok := existing["Expires"].(time.Time); ok && expires.After(time.Now()) {
+				return nil
+			}
+		} else if me, ok := err.(MultiError); !ok || me[0] != ErrNoSuchEntity {
+			return err
+		}
+
+		_, err = Raw(ctx).PutMulti(
+			[]*Key{key, tokenKey},
+			[]PropertyMap{pm, {"Expires": time.Now().Add(ttl)}},
+		)
+		return err
+	}, nil)
+}
@@ -0,0 +1,74 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type piWidget struct {
+	Count int64
+}
+
+func TestPutIdempotentAppliesOncePerToken(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+
+	if err := ds.PutIdempotent(ctx, k, "tok-1", time.Hour, &piWidget{Count: 1}); err != nil {
+		t.Fatalf("PutIdempotent #1: %v", err)
+	}
+	if err := ds.PutIdempotent(ctx, k, "tok-1", time.Hour, &piWidget{Count: 2}); err != nil {
+		t.Fatalf("PutIdempotent #2 (retry): %v", err)
+	}
+
+	out, err := ds.GetT[piWidget](ctx, k)
+	if err != nil {
+		t.Fatalf("GetT: %v", err)
+	}
+	if out.Count != 1 {
+		t.Errorf("Count = %d, want 1 (second Put with the same token should have been a no-op)", out.Count)
+	}
+}
+
+func TestPutIdempotentAppliesAgainAfterTTLExpires(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+
+	if err := ds.PutIdempotent(ctx, k, "tok-1", -time.Second, &piWidget{Count: 1}); err != nil {
+		t.Fatalf("PutIdempotent #1: %v", err)
+	}
+	if err := ds.PutIdempotent(ctx, k, "tok-1", time.Hour, &piWidget{Count: 2}); err != nil {
+		t.Fatalf("PutIdempotent #2 (token expired): %v", err)
+	}
+
+	out, err := ds.GetT[piWidget](ctx, k)
+	if err != nil {
+		t.Fatalf("GetT: %v", err)
+	}
+	if out.Count != 2 {
+		t.Errorf("Count = %d, want 2 (token had already expired)", out.Count)
+	}
+}
+
+func TestPutIdempotentDifferentTokensBothApply(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	k := ds.NewKey("app", "", "Widget", "w1", 0, nil)
+
+	if err := ds.PutIdempotent(ctx, k, "tok-a", time.Hour, &piWidget{Count: 1}); err != nil {
+		t.Fatalf("PutIdempotent(tok-a): %v", err)
+	}
+	if err := ds.PutIdempotent(ctx, k, "tok-b", time.Hour, &piWidget{Count: 2}); err != nil {
+		t.Fatalf("PutIdempotent(tok-b): %v", err)
+	}
+
+	out, err := ds.GetT[piWidget](ctx, k)
+	if err != nil {
+		t.Fatalf("GetT: %v", err)
+	}
+	if out.Count != 2 {
+		t.Errorf("Count = %d, want 2 (a distinct token should not be treated as a retry)", out.Count)
+	}
+}
@@ -0,0 +1,194 @@
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Stop is returned by a Run callback to halt iteration early without
+// that being treated as an error by Run's caller.
+var Stop = errors.New("datastore: stop iteration")
+
+// Order describes a single sort term of a Query.
+type Order struct {
+	Property   string
+	Descending bool
+}
+
+// FilterOp is the comparison a QueryFilter applies between a property
+// and a value.
+type FilterOp int
+
+const (
+	Equal FilterOp = iota
+	LessThan
+	LessThanOrEqual
+	GreaterThan
+	GreaterThanOrEqual
+)
+
+// IsInequality reports whether op restricts a range (anything other than
+// an exact match), the category Cloud Datastore limits to a single
+// property per query.
+func (op FilterOp) IsInequality() bool { return op != Equal }
+
+var filterOpNames = map[FilterOp]string{
+	Equal:              "=",
+	LessThan:           "<",
+	LessThanOrEqual:    "<=",
+	GreaterThan:        ">",
+	GreaterThanOrEqual: ">=",
+}
+
+// String returns op's comparison symbol, e.g. "<=".
+func (op FilterOp) String() string {
+	if s, ok := filterOpNames[op]; ok {
+		return s
+	}
+	return fmt.Sprintf("FilterOp(%d)", int(op))
+}
+
+// QueryFilter describes a single Filter call on a Query.
+type QueryFilter struct {
+	Property string
+	Op       FilterOp
+	Value    Property
+}
+
+// Query describes a set of entities of a single Kind to retrieve, along
+// with their filters, ordering, and an optional ancestor restriction.
+// Query is immutable; the builder methods return a modified copy.
+type Query struct {
+	kind      string
+	namespace string
+	ancestor  *Key
+	limit     int32
+	hasLimit  bool
+	start     int
+	hasStart  bool
+	orders    []Order
+	filters   []QueryFilter
+}
+
+// Filter appends a comparison against property to the query.
+func (q *Query) Filter(property string, op FilterOp, value interface{}) *Query {
+	nq := *q
+	nq.filters = append(append([]QueryFilter{}, nq.filters...), QueryFilter{Property: property, Op: op, Value: MkProperty(value)})
+	return &nq
+}
+
+// Filters returns the query's filters, in the order they were added.
+func (q *Query) Filters() []QueryFilter { return append([]QueryFilter{}, q.filters...) }
+
+// NewQuery returns a Query over all entities of the given kind.
+func NewQuery(kind string) *Query {
+	return &Query{kind: kind}
+}
+
+// Namespace restricts the query to entities in the given namespace.
+// Queries, like in production Cloud Datastore, always run against
+// exactly one namespace; the zero value is the default namespace.
+func (q *Query) Namespace(ns string) *Query {
+	nq := *q
+	nq.namespace = ns
+	return &nq
+}
+
+// GetNamespace returns the query's namespace restriction.
+func (q *Query) GetNamespace() string { return q.namespace }
+
+// Ancestor restricts the query to descendants of anc (inclusive).
+func (q *Query) Ancestor(anc *Key) *Query {
+	nq := *q
+	nq.ancestor = anc
+	return &nq
+}
+
+// Limit caps the number of results returned by Run.
+func (q *Query) Limit(n int32) *Query {
+	nq := *q
+	nq.limit = n
+	nq.hasLimit = true
+	return &nq
+}
+
+// Order appends a sort term. A leading "-" sorts descending.
+func (q *Query) Order(property string) *Query {
+	nq := *q
+	desc := false
+	if len(property) > 0 && property[0] == '-' {
+		desc, property = true, property[1:]
+	}
+	nq.orders = append(append([]Order{}, nq.orders...), Order{Property: property, Descending: desc})
+	return &nq
+}
+
+// Kind returns the kind this query selects.
+func (q *Query) Kind() string { return q.kind }
+
+// WithKind returns a copy of q selecting a different kind, leaving its
+// namespace, ancestor, filters, orders and limit untouched. It exists
+// for filters (see filter/kindprefix) that need to rewrite a query's
+// kind without rebuilding the rest of it from scratch.
+func (q *Query) WithKind(kind string) *Query {
+	nq := *q
+	nq.kind = kind
+	return &nq
+}
+
+// GetAncestor returns the query's ancestor restriction, or nil.
+func (q *Query) GetAncestor() *Key { return q.ancestor }
+
+// QueryAncestor returns q's ancestor restriction and whether one is
+// set, the same (value, bool) shape GetLimit already uses for its own
+// optional value. It's a function rather than a same-named method
+// because Query's builder method is already called Ancestor; this
+// package also has no separate FinalizedQuery type; a query finalized
+// via FinalizeQuery is still a *Query, so QueryAncestor reads it the
+// same way whether or not it has been finalized.
+func QueryAncestor(q *Query) (*Key, bool) { return q.ancestor, q.ancestor != nil }
+
+// GetLimit returns the query's limit and whether one was set.
+func (q *Query) GetLimit() (int32, bool) { return q.limit, q.hasLimit }
+
+// Orders returns the query's sort terms, in precedence order.
+func (q *Query) Orders() []Order { return append([]Order{}, q.orders...) }
+
+// RunCB is the callback passed to RawInterface.Run; it is invoked once
+// per result, in order. Returning Stop halts iteration cleanly; any other
+// non-nil error aborts Run and is returned to its caller.
+type RunCB func(*Key, PropertyMap) error
+
+// DebugString returns a human-readable summary of q, for logging (see
+// WithQueryLogging) and debugging; its format is not stable and should
+// not be parsed.
+func (q *Query) DebugString() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "SELECT * FROM %s", q.kind)
+	if q.namespace != "" {
+		fmt.Fprintf(&b, " IN NAMESPACE %s", q.namespace)
+	}
+	if q.ancestor != nil {
+		fmt.Fprintf(&b, " WHERE ANCESTOR IS %s", q.ancestor)
+	}
+	for _, f := range q.filters {
+		fmt.Fprintf(&b, " AND %s %s %v", f.Property, f.Op, f.Value)
+	}
+	for i, o := range q.orders {
+		if i == 0 {
+			b.WriteString(" ORDER BY ")
+		} else {
+			b.WriteString(", ")
+		}
+		if o.Descending {
+			fmt.Fprintf(&b, "%s DESC", o.Property)
+		} else {
+			b.WriteString(o.Property)
+		}
+	}
+	if q.hasLimit {
+		fmt.Fprintf(&b, " LIMIT %d", q.limit)
+	}
+	return b.String()
+}
@@ -0,0 +1,28 @@
+package datastore
+
+import "testing"
+
+func TestQueryAncestorReportsSetAncestor(t *testing.T) {
+	anc := NewKey("app", "", "Parent", "p1", 0, nil)
+	q := NewQuery("Widget").Ancestor(anc)
+
+	k, ok := QueryAncestor(q)
+	if !ok {
+		t.Fatalf("ok = false, want true for a query with an ancestor")
+	}
+	if !k.Equal(anc) {
+		t.Errorf("k = %v, want %v", k, anc)
+	}
+}
+
+func TestQueryAncestorReportsNoAncestor(t *testing.T) {
+	q := NewQuery("Widget")
+
+	k, ok := QueryAncestor(q)
+	if ok {
+		t.Errorf("ok = true, want false for a query with no ancestor")
+	}
+	if k != nil {
+		t.Errorf("k = %v, want nil", k)
+	}
+}
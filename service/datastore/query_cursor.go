@@ -0,0 +1,81 @@
+package datastore
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// queryFingerprint returns a short fingerprint of q's finalized shape
+// (kind, namespace, ancestor, filters and orders as FinalizeQuery would
+// repair them), embedded in every Cursor this package produces so that a
+// Cursor from one Query can never be silently accepted by a different
+// one — see Query.Start. It's computed from FinalizeQuery's result
+// rather than q directly so that two queries differing only in the
+// implicit ordering FinalizeQuery would insert (e.g. one already has the
+// __key__ tiebreaker order, the other doesn't) still fingerprint the
+// same; DebugString is otherwise documented as unstable output not
+// meant for parsing, but hashing it for equality comparison within a
+// single process doesn't rely on that stability across versions.
+func queryFingerprint(q *Query) (string, error) {
+	fq, err := FinalizeQuery(q)
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New64a()
+	h.Write([]byte(fq.DebugString()))
+	return strconv.FormatUint(h.Sum64(), 16), nil
+}
+
+// newCursor builds the Cursor for position n of the query whose
+// fingerprint is fp, in the form "<fingerprint>:<n>".
+func newCursor(fp string, n int) Cursor {
+	return Cursor(fp + ":" + strconv.Itoa(n))
+}
+
+// parseCursor splits a Cursor produced by newCursor back into its
+// fingerprint and position.
+func parseCursor(c Cursor) (fp string, n int, err error) {
+	s := string(c)
+	idx := strings.LastIndex(s, ":")
+	if idx < 0 {
+		return "", 0, fmt.Errorf("datastore: malformed cursor %q", c)
+	}
+	fp = s[:idx]
+	n, err = strconv.Atoi(s[idx+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("datastore: malformed cursor %q: %w", c, err)
+	}
+	return fp, n, nil
+}
+
+// Start returns a copy of q that resumes from c, for a caller (e.g.
+// Scanner) that wants to skip the results c already covers. It returns a
+// descriptive error if c is malformed, or if its embedded fingerprint
+// doesn't match q's — which happens when c was produced by a different
+// query (one with a different kind, filter, order or ancestor) than the
+// one it's now being used with. See QueryStart to read the resulting
+// position back off the returned Query.
+func (q *Query) Start(c Cursor) (*Query, error) {
+	wantFP, n, err := parseCursor(c)
+	if err != nil {
+		return nil, err
+	}
+	gotFP, err := queryFingerprint(q)
+	if err != nil {
+		return nil, err
+	}
+	if wantFP != gotFP {
+		return nil, fmt.Errorf("datastore: cursor %q does not match this query; it was produced by running a different query", c)
+	}
+	nq := *q
+	nq.start = n
+	nq.hasStart = true
+	return &nq, nil
+}
+
+// QueryStart returns q's start position set by Query.Start, and whether
+// one is set, the same (value, bool) shape GetLimit and QueryAncestor
+// already use.
+func QueryStart(q *Query) (int, bool) { return q.start, q.hasStart }
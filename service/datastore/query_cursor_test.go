@@ -0,0 +1,72 @@
+package datastore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestQueryStartAcceptsACursorFromTheSameQuery(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	seedWidgets(t, ctx, "a", "b", "c")
+
+	it, err := ds.NewIterator(ctx, ds.NewQuery("Widget"))
+	if err != nil {
+		t.Fatalf("NewIterator: %v", err)
+	}
+	defer it.Close()
+
+	var w iterWidget
+	if ok, err := it.Next(&w); err != nil || !ok {
+		t.Fatalf("Next: ok=%v err=%v", ok, err)
+	}
+	cursor, err := it.Cursor()
+	if err != nil {
+		t.Fatalf("Cursor: %v", err)
+	}
+
+	resumed, err := ds.NewQuery("Widget").Start(cursor)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if n, ok := ds.QueryStart(resumed); !ok || n != 1 {
+		t.Errorf("QueryStart = %d, %v, want 1, true", n, ok)
+	}
+}
+
+func TestQueryStartRejectsACursorFromADifferentQuery(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	seedWidgets(t, ctx, "a", "b")
+
+	it, err := ds.NewIterator(ctx, ds.NewQuery("Widget").Filter("Name", ds.Equal, "a"))
+	if err != nil {
+		t.Fatalf("NewIterator: %v", err)
+	}
+	defer it.Close()
+
+	var w iterWidget
+	if ok, err := it.Next(&w); err != nil || !ok {
+		t.Fatalf("Next: ok=%v err=%v", ok, err)
+	}
+	cursor, err := it.Cursor()
+	if err != nil {
+		t.Fatalf("Cursor: %v", err)
+	}
+
+	if _, err := ds.NewQuery("Gadget").Start(cursor); err == nil {
+		t.Fatalf("Start: got nil error for a cursor from a different kind, want a mismatch error")
+	}
+	_, err = ds.NewQuery("Widget").Start(cursor)
+	if err == nil || !strings.Contains(err.Error(), "does not match this query") {
+		t.Fatalf("Start: err = %v, want a fingerprint-mismatch error", err)
+	}
+}
+
+func TestQueryStartRejectsAMalformedCursor(t *testing.T) {
+	if _, err := ds.NewQuery("Widget").Start(ds.Cursor("not-a-real-cursor")); err == nil {
+		t.Fatalf("Start: got nil error for a malformed cursor")
+	}
+}
@@ -0,0 +1,63 @@
+package datastore
+
+import "fmt"
+
+// KeyProperty is the sentinel order property FinalizeQuery appends as a
+// tiebreaker, mirroring Cloud Datastore's "__key__" pseudo-property:
+// ordering on it last guarantees no two results ever sort equal.
+const KeyProperty = "__key__"
+
+// FinalizeQuery repairs q's sort order the way Cloud Datastore requires:
+// when q has an inequality filter, that property must be the first sort
+// order, and the results should end with a KeyProperty tiebreaker so
+// that otherwise-equal results still sort deterministically. A query
+// with no explicit order on the inequality property gets one inserted
+// automatically; a query whose explicit first order is on some other
+// property instead gets a descriptive error, since silently
+// reordering the caller's sort would be surprising. A KeyProperty
+// tiebreaker is always appended if not already present, regardless of
+// whether q has an inequality filter.
+//
+// It also rejects inequality filters on more than one property, the
+// other half of Cloud Datastore's single-inequality-property
+// restriction.
+func FinalizeQuery(q *Query) (*Query, error) {
+	ineqProp := ""
+	for _, f := range q.filters {
+		if !f.Op.IsInequality() {
+			continue
+		}
+		if ineqProp == "" {
+			ineqProp = f.Property
+		} else if ineqProp != f.Property {
+			return nil, fmt.Errorf("datastore: query has inequality filters on both %q and %q; only one inequality property is allowed per query", ineqProp, f.Property)
+		}
+	}
+
+	nq := *q
+	nq.orders = append([]Order{}, q.orders...)
+
+	if ineqProp != "" {
+		switch {
+		case len(nq.orders) == 0:
+			nq.orders = []Order{{Property: ineqProp}}
+		case nq.orders[0].Property != ineqProp:
+			return nil, fmt.Errorf("datastore: query has an inequality filter on %q, which must be the first sort order, but its first order is on %q", ineqProp, nq.orders[0].Property)
+		}
+	}
+
+	if !hasOrderOn(nq.orders, KeyProperty) {
+		nq.orders = append(nq.orders, Order{Property: KeyProperty})
+	}
+
+	return &nq, nil
+}
+
+func hasOrderOn(orders []Order, property string) bool {
+	for _, o := range orders {
+		if o.Property == property {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package datastore
+
+import "testing"
+
+func TestFinalizeQueryInsertsMissingInequalityOrder(t *testing.T) {
+	q := NewQuery("Widget").Filter("Price", GreaterThan, 10)
+	fq, err := FinalizeQuery(q)
+	if err != nil {
+		t.Fatalf("FinalizeQuery: %v", err)
+	}
+	orders := fq.Orders()
+	if len(orders) != 2 || orders[0].Property != "Price" || orders[1].Property != KeyProperty {
+		t.Fatalf("Orders = %+v, want [{Price false} {%s false}]", orders, KeyProperty)
+	}
+}
+
+func TestFinalizeQueryLeavesCompatibleOrderAlone(t *testing.T) {
+	q := NewQuery("Widget").Filter("Price", GreaterThan, 10).Order("Price").Order("-Name")
+	fq, err := FinalizeQuery(q)
+	if err != nil {
+		t.Fatalf("FinalizeQuery: %v", err)
+	}
+	orders := fq.Orders()
+	if len(orders) != 3 || orders[0].Property != "Price" || orders[1].Property != "Name" || orders[2].Property != KeyProperty {
+		t.Fatalf("Orders = %+v, want [Price, -Name, %s]", orders, KeyProperty)
+	}
+}
+
+func TestFinalizeQueryErrorsOnConflictingOrder(t *testing.T) {
+	q := NewQuery("Widget").Filter("Price", GreaterThan, 10).Order("Name")
+	if _, err := FinalizeQuery(q); err == nil {
+		t.Fatalf("FinalizeQuery: got nil error, want a conflicting-order error")
+	}
+}
+
+func TestFinalizeQueryErrorsOnTwoInequalityProperties(t *testing.T) {
+	q := NewQuery("Widget").Filter("Price", GreaterThan, 10).Filter("Stock", LessThan, 5)
+	if _, err := FinalizeQuery(q); err == nil {
+		t.Fatalf("FinalizeQuery: got nil error, want a multiple-inequality-property error")
+	}
+}
+
+func TestFinalizeQueryAppendsKeyTiebreakerWithoutInequality(t *testing.T) {
+	q := NewQuery("Widget").Order("Name")
+	fq, err := FinalizeQuery(q)
+	if err != nil {
+		t.Fatalf("FinalizeQuery: %v", err)
+	}
+	orders := fq.Orders()
+	if len(orders) != 2 || orders[0].Property != "Name" || orders[1].Property != KeyProperty {
+		t.Fatalf("Orders = %+v, want [Name, %s]", orders, KeyProperty)
+	}
+}
+
+func TestFinalizeQueryDoesNotDuplicateExistingKeyOrder(t *testing.T) {
+	q := NewQuery("Widget").Order(KeyProperty)
+	fq, err := FinalizeQuery(q)
+	if err != nil {
+		t.Fatalf("FinalizeQuery: %v", err)
+	}
+	orders := fq.Orders()
+	if len(orders) != 1 || orders[0].Property != KeyProperty {
+		t.Fatalf("Orders = %+v, want [%s]", orders, KeyProperty)
+	}
+}
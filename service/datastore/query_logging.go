@@ -0,0 +1,38 @@
+package datastore
+
+import "context"
+
+// QueryLogger is invoked once per logical Run or Count call when
+// installed via WithQueryLogging.
+type QueryLogger func(q *Query)
+
+// queryLoggingFilter logs every Run/Count it sees via log. Since it
+// wraps the RawInterface installed in ctx directly (before batchFilter's
+// own GetMulti sub-batch splitting, which only ever touches GetMulti),
+// each Run/Count here corresponds to exactly one caller-issued query,
+// not one sub-batch.
+type queryLoggingFilter struct {
+	RawInterface
+	log QueryLogger
+}
+
+// WithQueryLogging returns a context in which every datastore query
+// (Run or Count) is reported to log, once per logical query, via
+// Query.DebugString's format. It composes with batching: a large
+// GetMulti split into sub-batches by batchFilter never goes through
+// Run/Count, so this never double-logs a split call.
+func WithQueryLogging(ctx context.Context, log QueryLogger) context.Context {
+	return AddRawFilters(ctx, func(_ context.Context, raw RawInterface) RawInterface {
+		return &queryLoggingFilter{RawInterface: raw, log: log}
+	})
+}
+
+func (f *queryLoggingFilter) Run(q *Query, cb RunCB) error {
+	f.log(q)
+	return f.RawInterface.Run(q, cb)
+}
+
+func (f *queryLoggingFilter) Count(q *Query) (int64, error) {
+	f.log(q)
+	return f.RawInterface.Count(q)
+}
@@ -0,0 +1,35 @@
+package datastore
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestQueryDebugStringIncludesKindFiltersAndLimit(t *testing.T) {
+	q := NewQuery("Widget").Filter("Color", Equal, "red").Order("-Name").Limit(10)
+	s := q.DebugString()
+	for _, want := range []string{"Widget", "Color", "=", "red", "Name DESC", "LIMIT 10"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("DebugString() = %q, want it to contain %q", s, want)
+		}
+	}
+}
+
+func TestWithQueryLoggingReportsEachRunOnce(t *testing.T) {
+	var logged []*Query
+	ctx := SetRaw(context.Background(), dummyRunOKRaw{})
+	ctx = WithQueryLogging(ctx, func(q *Query) { logged = append(logged, q) })
+
+	q := NewQuery("Widget")
+	if err := Raw(ctx).Run(q, func(k *Key, pm PropertyMap) error { return nil }); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(logged) != 1 || logged[0] != q {
+		t.Errorf("logged = %v, want exactly one entry for q", logged)
+	}
+}
+
+type dummyRunOKRaw struct{ dummyRaw }
+
+func (dummyRunOKRaw) Run(q *Query, cb RunCB) error { return nil }
@@ -0,0 +1,58 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+)
+
+type sequentialRunRaw struct {
+	dummyRaw
+	n int
+}
+
+func (r sequentialRunRaw) Run(q *Query, cb RunCB) error {
+	for i := 0; i < r.n; i++ {
+		if err := cb(NewKey("app", "", "Widget", "", int64(i+1), nil), PropertyMap{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestQueryProgressCallbackReportsMonotonicCounts(t *testing.T) {
+	const total = 5
+	ctx := SetRaw(context.Background(), sequentialRunRaw{n: total})
+
+	var reported []int
+	ctx = WithQueryProgressCallback(ctx, func(processed int) { reported = append(reported, processed) })
+
+	err := Raw(ctx).Run(NewQuery("Widget"), func(k *Key, pm PropertyMap) error { return nil })
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(reported) != total {
+		t.Fatalf("got %d progress reports, want %d", len(reported), total)
+	}
+	for i, v := range reported {
+		if v != i+1 {
+			t.Errorf("reported[%d] = %d, want %d", i, v, i+1)
+		}
+	}
+	if reported[len(reported)-1] != total {
+		t.Errorf("final reported count = %d, want it to reach the total %d", reported[len(reported)-1], total)
+	}
+}
+
+func TestNoQueryProgressCallbackByDefault(t *testing.T) {
+	ctx := SetRaw(context.Background(), sequentialRunRaw{n: 3})
+
+	n := 0
+	err := Raw(ctx).Run(NewQuery("Widget"), func(k *Key, pm PropertyMap) error { n++; return nil })
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("got %d results, want 3", n)
+	}
+}
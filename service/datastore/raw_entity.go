@@ -0,0 +1,54 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// RawEntityRunner is implemented by a RawInterface that can hand back
+// the serialized entity bytes it received from its backend alongside
+// the decoded PropertyMap for every Run result, letting a caller that
+// only needs to pipe entities through unchanged (e.g. a bulk export)
+// skip a decode/re-encode cycle. Only a backend that speaks Cloud
+// Datastore's protobuf wire format directly can provide true raw
+// bytes this way.
+//
+// No backend in this module implements RawEntityRunner today:
+// impl/memory has no wire format to capture, and this tree's
+// impl/cloud package only wires together an already-constructed
+// ds.RawInterface (see cloud.Config.Datastore) — it's a logging/auth
+// config layer, not a Cloud Datastore RPC client, so there's no Run
+// implementation here to extend. RunRawEntity falls through to
+// RawEntity's re-encoding fallback until a RawEntityRunner backend
+// exists.
+type RawEntityRunner interface {
+	RunRawEntity(q *Query, cb func(key *Key, raw []byte, pm PropertyMap) error) error
+}
+
+// RawEntity re-encodes pm as this package's generic JSON representation
+// (the same one EstimateSize sizes). It is the fallback RunRawEntity
+// uses when the installed backend is not a RawEntityRunner, so it is
+// not a Cloud Datastore protobuf encoding — just a standin with the
+// same "bytes for this entity" shape.
+func RawEntity(pm PropertyMap) ([]byte, error) {
+	return json.Marshal(ToGeneric(pm))
+}
+
+// RunRawEntity runs q, handing cb the raw entity bytes alongside each
+// result's decoded PropertyMap. If ctx's installed backend implements
+// RawEntityRunner, its bytes are passed through unchanged; RawUnfiltered
+// is used to reach it directly, the same way backend Testable helpers
+// do, so a filter in the chain can't mask the optional interface.
+// Otherwise each result is re-encoded via RawEntity.
+func RunRawEntity(ctx context.Context, q *Query, cb func(key *Key, raw []byte, pm PropertyMap) error) error {
+	if rer, ok := RawUnfiltered(ctx).(RawEntityRunner); ok {
+		return rer.RunRawEntity(q, cb)
+	}
+	return Raw(ctx).Run(q, func(k *Key, pm PropertyMap) error {
+		b, err := RawEntity(pm)
+		if err != nil {
+			return err
+		}
+		return cb(k, b, pm)
+	})
+}
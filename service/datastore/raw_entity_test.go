@@ -0,0 +1,84 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type fakeRawEntityRaw struct {
+	dummyRaw
+}
+
+func (r fakeRawEntityRaw) RunRawEntity(q *Query, cb func(key *Key, raw []byte, pm PropertyMap) error) error {
+	k := NewKey("app", "", "Widget", "", 1, nil)
+	return cb(k, []byte("raw-bytes-from-backend"), PropertyMap{})
+}
+
+func TestRunRawEntityPassesThroughRawEntityRunnerBytes(t *testing.T) {
+	ctx := SetRaw(context.Background(), fakeRawEntityRaw{})
+
+	var gotRaw []byte
+	err := RunRawEntity(ctx, NewQuery("Widget"), func(k *Key, raw []byte, pm PropertyMap) error {
+		gotRaw = raw
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunRawEntity: %v", err)
+	}
+	if string(gotRaw) != "raw-bytes-from-backend" {
+		t.Errorf("raw = %q, want the backend's bytes unchanged", gotRaw)
+	}
+}
+
+func TestRunRawEntityFallsBackToReencoding(t *testing.T) {
+	ctx := SetRaw(context.Background(), sequentialRunRaw{n: 1})
+
+	var gotRaw []byte
+	var gotPM PropertyMap
+	err := RunRawEntity(ctx, NewQuery("Widget"), func(k *Key, raw []byte, pm PropertyMap) error {
+		gotRaw, gotPM = raw, pm
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunRawEntity: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(gotRaw, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal(raw): %v", err)
+	}
+	want := ToGeneric(gotPM)
+	if len(decoded) != len(want) {
+		t.Errorf("decoded = %v, want the same shape as ToGeneric(pm) = %v", decoded, want)
+	}
+}
+
+func TestRawEntityRoundTripsToGenericRepresentation(t *testing.T) {
+	pm := PropertyMap{"Name": MkProperty("widget"), "Count": MkProperty(int64(3))}
+
+	b, err := RawEntity(pm)
+	if err != nil {
+		t.Fatalf("RawEntity: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	var redecoded map[string]interface{}
+	b2, _ := json.Marshal(ToGeneric(pm))
+	if err := json.Unmarshal(b2, &redecoded); err != nil {
+		t.Fatalf("json.Unmarshal(ToGeneric): %v", err)
+	}
+	if len(decoded) != len(redecoded) {
+		t.Fatalf("RawEntity's decoded shape = %v, want it to match ToGeneric(pm)'s = %v", decoded, redecoded)
+	}
+	for k, v := range redecoded {
+		if !reflect.DeepEqual(decoded[k], v) {
+			t.Errorf("decoded[%q] = %v, want %v", k, decoded[k], v)
+		}
+	}
+}
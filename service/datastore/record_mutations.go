@@ -0,0 +1,86 @@
+package datastore
+
+import (
+	"context"
+	"sync"
+)
+
+// MutationOp identifies the write operation a Mutation records.
+type MutationOp int
+
+const (
+	// MutationPut marks a Mutation recorded by PutMulti.
+	MutationPut MutationOp = iota
+	// MutationDelete marks a Mutation recorded by DeleteMulti.
+	MutationDelete
+)
+
+// RecordedMutation is one write recorded by a MutationLog. It's a
+// distinct type from Mutate/CompareAndMutate's Mutation (a Put or
+// Delete to be applied) since a RecordedMutation instead describes one
+// already applied: it carries the operation that was performed rather
+// than a Put's new value.
+type RecordedMutation struct {
+	Op  MutationOp
+	Key *Key
+}
+
+// MutationLog accumulates the Put/Delete mutations made through a
+// RecordMutations-installed filter, in the order they occurred.
+// MutationLog is safe for concurrent use.
+type MutationLog struct {
+	mu      sync.Mutex
+	entries []RecordedMutation
+}
+
+func (l *MutationLog) record(op MutationOp, keys []*Key) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, k := range keys {
+		l.entries = append(l.entries, RecordedMutation{Op: op, Key: k})
+	}
+}
+
+// Take returns every mutation recorded since the log was installed or
+// last Take, in order, and clears the log.
+func (l *MutationLog) Take() []RecordedMutation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := l.entries
+	l.entries = nil
+	return out
+}
+
+// mutationLogFilter wraps a RawInterface, recording every key a
+// successful PutMulti/DeleteMulti touches into log.
+type mutationLogFilter struct {
+	RawInterface
+	log *MutationLog
+}
+
+// RecordMutations returns a context derived from ctx whose datastore
+// records every successful PutMulti/DeleteMulti it sees into the
+// returned *MutationLog, so a test can assert exactly which entities a
+// handler wrote or deleted without diffing the store's final state.
+func RecordMutations(ctx context.Context) (context.Context, *MutationLog) {
+	log := &MutationLog{}
+	return AddRawFilters(ctx, func(_ context.Context, raw RawInterface) RawInterface {
+		return &mutationLogFilter{RawInterface: raw, log: log}
+	}), log
+}
+
+func (f *mutationLogFilter) PutMulti(keys []*Key, vals []PropertyMap) ([]*Key, error) {
+	out, err := f.RawInterface.PutMulti(keys, vals)
+	if err == nil {
+		f.log.record(MutationPut, out)
+	}
+	return out, err
+}
+
+func (f *mutationLogFilter) DeleteMulti(keys []*Key) error {
+	err := f.RawInterface.DeleteMulti(keys)
+	if err == nil {
+		f.log.record(MutationDelete, keys)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestRecordMutationsLogsPutsAndDeletesInOrder(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ctx, log := ds.RecordMutations(ctx)
+
+	a := ds.NewKey("app", "", "Widget", "a", 0, nil)
+	b := ds.NewKey("app", "", "Widget", "b", 0, nil)
+
+	handler := func(ctx context.Context) error {
+		if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{a, b}, []ds.PropertyMap{{}, {}}); err != nil {
+			return err
+		}
+		return ds.Raw(ctx).DeleteMulti([]*ds.Key{a})
+	}
+	if err := handler(ctx); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	got := log.Take()
+	if len(got) != 3 {
+		t.Fatalf("got %d mutations, want 3: %+v", len(got), got)
+	}
+	wantOps := []ds.MutationOp{ds.MutationPut, ds.MutationPut, ds.MutationDelete}
+	wantKeys := []*ds.Key{a, b, a}
+	for i, m := range got {
+		if m.Op != wantOps[i] {
+			t.Errorf("got[%d].Op = %v, want %v", i, m.Op, wantOps[i])
+		}
+		if !m.Key.Equal(wantKeys[i]) {
+			t.Errorf("got[%d].Key = %v, want %v", i, m.Key, wantKeys[i])
+		}
+	}
+}
+
+func TestRecordMutationsTakeResetsTheLog(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	ctx, log := ds.RecordMutations(ctx)
+
+	k := ds.NewKey("app", "", "Widget", "a", 0, nil)
+	if _, err := ds.Raw(ctx).PutMulti([]*ds.Key{k}, []ds.PropertyMap{{}}); err != nil {
+		t.Fatalf("PutMulti: %v", err)
+	}
+	if got := log.Take(); len(got) != 1 {
+		t.Fatalf("first Take() = %v, want 1 entry", got)
+	}
+	if got := log.Take(); len(got) != 0 {
+		t.Fatalf("second Take() = %v, want 0 entries after reset", got)
+	}
+}
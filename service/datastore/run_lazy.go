@@ -0,0 +1,28 @@
+package datastore
+
+import "context"
+
+// RunLazy streams q's results one at a time, like Raw(ctx).Run, but
+// defers decoding each result into a struct until cb actually calls
+// decode, instead of doing it eagerly for every result the way
+// TypedQuery.Run does. This is worth it when cb often skips a result
+// based on its key alone (e.g. a kind holding mostly-irrelevant
+// entities): LoadStruct's reflection cost is only paid for results cb
+// actually wants.
+//
+// decode and getCursor are only valid for the duration of the call to
+// cb they were passed to.
+func RunLazy(ctx context.Context, q *Query, cb func(key *Key, decode func(dst interface{}) error, getCursor CursorCB) error) error {
+	fp, err := queryFingerprint(q)
+	if err != nil {
+		return err
+	}
+	n := 0
+	return Raw(ctx).Run(q, func(k *Key, pm PropertyMap) error {
+		n++
+		seen := n
+		decode := func(dst interface{}) error { return LoadStruct(dst, pm) }
+		getCursor := func() (Cursor, error) { return newCursor(fp, seen), nil }
+		return cb(k, decode, getCursor)
+	})
+}
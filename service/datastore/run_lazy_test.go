@@ -0,0 +1,72 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type lazyWidget struct {
+	Name string
+}
+
+func TestRunLazySkipsDecodeWhenNotCalled(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	keys := []*ds.Key{
+		ds.NewKey("app", "", "Widget", "keep", 0, nil),
+		ds.NewKey("app", "", "Widget", "skip", 0, nil),
+	}
+	vals := []ds.PropertyMap{{"Name": "keep"}, {"Name": "skip"}}
+	if _, err := ds.Raw(ctx).PutMulti(keys, vals); err != nil {
+		t.Fatalf("seed PutMulti: %v", err)
+	}
+
+	var decoded []string
+	err := ds.RunLazy(ctx, ds.NewQuery("Widget"), func(k *ds.Key, decode func(dst interface{}) error, getCursor ds.CursorCB) error {
+		if k.StringID() != "keep" {
+			return nil
+		}
+		var w lazyWidget
+		if err := decode(&w); err != nil {
+			return err
+		}
+		decoded = append(decoded, w.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunLazy: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0] != "keep" {
+		t.Errorf("decoded = %v, want [keep]", decoded)
+	}
+}
+
+func TestRunLazyCursorAdvancesPerResult(t *testing.T) {
+	ctx := ds.SetRaw(context.Background(), memory.NewDatastore("app"))
+	keys := []*ds.Key{
+		ds.NewKey("app", "", "Widget", "a", 0, nil),
+		ds.NewKey("app", "", "Widget", "b", 0, nil),
+	}
+	vals := []ds.PropertyMap{{"Name": "a"}, {"Name": "b"}}
+	if _, err := ds.Raw(ctx).PutMulti(keys, vals); err != nil {
+		t.Fatalf("seed PutMulti: %v", err)
+	}
+
+	var cursors []ds.Cursor
+	err := ds.RunLazy(ctx, ds.NewQuery("Widget"), func(k *ds.Key, decode func(dst interface{}) error, getCursor ds.CursorCB) error {
+		c, err := getCursor()
+		if err != nil {
+			return err
+		}
+		cursors = append(cursors, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunLazy: %v", err)
+	}
+	if len(cursors) != 2 || cursors[0] == cursors[1] {
+		t.Errorf("cursors = %v, want two distinct values", cursors)
+	}
+}
@@ -0,0 +1,121 @@
+package datastore
+
+import (
+	"context"
+	"sync"
+)
+
+// RunUnion runs each of queries concurrently (each is expected to be
+// restricted to its own Kind) and merges their results into a single
+// stream ordered by less, invoking cb once per merged result. This is a
+// client-side merge: each query's own results must already arrive in
+// less order (e.g. via a matching Query.Order), since RunUnion never
+// reorders within a single source, only across them.
+//
+// If one or more queries has a Limit set, the smallest such limit is
+// treated as an overall cap on the number of results RunUnion emits
+// across the merged stream, rather than per-query.
+//
+// Returning Stop from cb halts the merge early, as with Run.
+//
+// A panic inside one query's Run call (from that backend or any RunCB
+// it invokes along the way) is recovered on its own goroutine and
+// surfaced from RunUnion as an *ErrPanicInCallback, instead of crashing
+// the process.
+func RunUnion(ctx context.Context, queries []*Query, less func(a, b PropertyMap) bool, cb RunCB) error {
+	if len(queries) == 0 {
+		return nil
+	}
+
+	type item struct {
+		k  *Key
+		pm PropertyMap
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chans := make([]chan item, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	wg.Add(len(queries))
+	for i, q := range queries {
+		chans[i] = make(chan item)
+		go func(i int, q *Query) {
+			defer wg.Done()
+			defer close(chans[i])
+			defer recoverCallbackPanic(&errs[i])
+			errs[i] = Raw(ctx).Run(q, func(k *Key, pm PropertyMap) error {
+				select {
+				case chans[i] <- item{k, pm}:
+					return nil
+				case <-ctx.Done():
+					return Stop
+				}
+			})
+		}(i, q)
+	}
+
+	overallLimit, hasOverallLimit := int32(0), false
+	for _, q := range queries {
+		if l, ok := q.GetLimit(); ok && (!hasOverallLimit || l < overallLimit) {
+			overallLimit, hasOverallLimit = l, true
+		}
+	}
+
+	heads := make([]*item, len(chans))
+	for i, ch := range chans {
+		if it, ok := <-ch; ok {
+			heads[i] = &it
+		}
+	}
+
+	var emitted int32
+	var cbErr error
+loop:
+	for {
+		minIdx := -1
+		for i, h := range heads {
+			if h == nil {
+				continue
+			}
+			if minIdx == -1 || less(h.pm, heads[minIdx].pm) {
+				minIdx = i
+			}
+		}
+		if minIdx == -1 {
+			break
+		}
+		if hasOverallLimit && emitted >= overallLimit {
+			break
+		}
+
+		h := heads[minIdx]
+		if err := cb(h.k, h.pm); err != nil {
+			if err != Stop {
+				cbErr = err
+			}
+			break loop
+		}
+		emitted++
+
+		if it, ok := <-chans[minIdx]; ok {
+			heads[minIdx] = &it
+		} else {
+			heads[minIdx] = nil
+		}
+	}
+
+	cancel()
+	wg.Wait()
+
+	if cbErr != nil {
+		return cbErr
+	}
+	for _, err := range errs {
+		if err != nil && err != Stop {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package datastore
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+type byKindRaw struct {
+	dummyRaw
+	rows map[string][]PropertyMap // kind -> rows, already in timestamp order
+}
+
+func (r *byKindRaw) Run(q *Query, cb RunCB) error {
+	rows := r.rows[q.Kind()]
+	limit, hasLimit := q.GetLimit()
+	for i, pm := range rows {
+		if hasLimit && int32(i) >= limit {
+			break
+		}
+		if err := cb(NewKey("app", "", q.Kind(), "", int64(i+1), nil), pm); err != nil {
+			if err == Stop {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func byTimestamp(a, b PropertyMap) bool {
+	return a["When"].(int64) < b["When"].(int64)
+}
+
+func TestRunUnionMergesTwoKindsInOrder(t *testing.T) {
+	raw := &byKindRaw{rows: map[string][]PropertyMap{
+		"Post":    {{"When": int64(1)}, {"When": int64(4)}, {"When": int64(6)}},
+		"Comment": {{"When": int64(2)}, {"When": int64(3)}, {"When": int64(5)}},
+	}}
+	ctx := SetRaw(context.Background(), raw)
+
+	var got []int64
+	err := RunUnion(ctx, []*Query{NewQuery("Post"), NewQuery("Comment")}, byTimestamp, func(k *Key, pm PropertyMap) error {
+		got = append(got, pm["When"].(int64))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunUnion: %v", err)
+	}
+
+	want := []int64{1, 2, 3, 4, 5, 6}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+	if !sort.SliceIsSorted(got, func(i, j int) bool { return got[i] < got[j] }) {
+		t.Errorf("got %v is not globally ordered", got)
+	}
+}
+
+func TestRunUnionRespectsSmallestQueryLimit(t *testing.T) {
+	raw := &byKindRaw{rows: map[string][]PropertyMap{
+		"Post":    {{"When": int64(1)}, {"When": int64(4)}, {"When": int64(6)}},
+		"Comment": {{"When": int64(2)}, {"When": int64(3)}, {"When": int64(5)}},
+	}}
+	ctx := SetRaw(context.Background(), raw)
+
+	var got []int64
+	err := RunUnion(ctx, []*Query{NewQuery("Post").Limit(2), NewQuery("Comment")}, byTimestamp, func(k *Key, pm PropertyMap) error {
+		got = append(got, pm["When"].(int64))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunUnion: %v", err)
+	}
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("got %v, want the first 2 merged results [1 2]", got)
+	}
+}
@@ -0,0 +1,94 @@
+package datastore
+
+import "context"
+
+// Cursor is an opaque position marker a CheckpointStore persists so a
+// Scanner can resume a long-running Scan after a restart, or that
+// Iterator.Cursor, RunLazy and TypedQuery.Run hand out for any other
+// caller that wants to persist a resumable position. Its representation
+// pairs the count of results already processed (resuming replays and
+// skips that many results, which relies on the backend returning a
+// query's results in the same order across separate Run calls, true for
+// every RawInterface in this package) with a fingerprint of the query it
+// came from, so that passing it to the wrong Query's Start is rejected
+// instead of silently skipping the wrong number of results — see
+// Query.Start.
+type Cursor string
+
+// CheckpointStore persists a Scanner's progress so Scan can resume after
+// a restart. Load returns "" if no checkpoint has been saved yet.
+type CheckpointStore interface {
+	Load(ctx context.Context) (Cursor, error)
+	Save(ctx context.Context, c Cursor) error
+}
+
+// defaultCheckpointInterval is the CheckpointInterval Scan uses when
+// Scanner.CheckpointInterval is left at zero.
+const defaultCheckpointInterval = 100
+
+// Scanner runs a query over a (potentially huge) kind, invoking a
+// handler once per result, and periodically persists progress via a
+// CheckpointStore so a process that restarts mid-scan resumes roughly
+// where it left off instead of reprocessing the whole kind.
+type Scanner struct {
+	// CheckpointInterval is how many results Scan processes between
+	// checkpoints. Zero means defaultCheckpointInterval. A restart may
+	// re-process up to CheckpointInterval-1 results already handled
+	// before the last checkpoint, but never skips any.
+	CheckpointInterval int
+}
+
+// Scan runs q via Raw(ctx).Run, calling handler once for every result
+// not already covered by checkpoint's saved progress, and saves progress
+// to checkpoint every CheckpointInterval results and once more when the
+// query finishes. If handler or the query itself returns an error, Scan
+// returns it without saving again, so a restart resumes from the last
+// periodic checkpoint.
+func (s *Scanner) Scan(ctx context.Context, q *Query, handler func(*Key, PropertyMap) error, checkpoint CheckpointStore) error {
+	interval := s.CheckpointInterval
+	if interval <= 0 {
+		interval = defaultCheckpointInterval
+	}
+
+	fp, err := queryFingerprint(q)
+	if err != nil {
+		return err
+	}
+
+	start, err := checkpoint.Load(ctx)
+	if err != nil {
+		return err
+	}
+	skip := 0
+	if start != "" {
+		nq, err := q.Start(start)
+		if err != nil {
+			return err
+		}
+		skip, _ = QueryStart(nq)
+	}
+
+	n := 0
+	sinceCheckpoint := 0
+	runErr := Raw(ctx).Run(q, func(k *Key, pm PropertyMap) error {
+		defer func() { n++ }()
+		if n < skip {
+			return nil
+		}
+		if err := handler(k, pm); err != nil {
+			return err
+		}
+		sinceCheckpoint++
+		if sinceCheckpoint >= interval {
+			sinceCheckpoint = 0
+			if err := checkpoint.Save(ctx, newCursor(fp, n+1)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if runErr != nil {
+		return runErr
+	}
+	return checkpoint.Save(ctx, newCursor(fp, n))
+}
@@ -0,0 +1,105 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type sequentialRaw struct {
+	dummyRaw
+	keys []*Key
+}
+
+func (r *sequentialRaw) Run(q *Query, cb RunCB) error {
+	for _, k := range r.keys {
+		if err := cb(k, PropertyMap{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memCheckpointStore struct {
+	cur Cursor
+}
+
+func (m *memCheckpointStore) Load(ctx context.Context) (Cursor, error) { return m.cur, nil }
+func (m *memCheckpointStore) Save(ctx context.Context, c Cursor) error { m.cur = c; return nil }
+
+func TestScannerChecksPointsAsItGoes(t *testing.T) {
+	keys := make([]*Key, 10)
+	for i := range keys {
+		keys[i] = NewKey("app", "", "Widget", "", int64(i+1), nil)
+	}
+	ctx := SetRaw(context.Background(), &sequentialRaw{keys: keys})
+	cp := &memCheckpointStore{}
+
+	var processed []int64
+	scanner := &Scanner{CheckpointInterval: 3}
+	if err := scanner.Scan(ctx, NewQuery("Widget"), func(k *Key, pm PropertyMap) error {
+		processed = append(processed, k.IntID())
+		return nil
+	}, cp); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(processed) != 10 {
+		t.Fatalf("processed %d items, want 10", len(processed))
+	}
+	fp, err := queryFingerprint(NewQuery("Widget"))
+	if err != nil {
+		t.Fatalf("queryFingerprint: %v", err)
+	}
+	if want := newCursor(fp, 10); cp.cur != want {
+		t.Errorf("final checkpoint = %q, want %q", cp.cur, want)
+	}
+}
+
+func TestScannerResumesAfterSimulatedRestart(t *testing.T) {
+	keys := make([]*Key, 10)
+	for i := range keys {
+		keys[i] = NewKey("app", "", "Widget", "", int64(i+1), nil)
+	}
+	ctx := SetRaw(context.Background(), &sequentialRaw{keys: keys})
+	cp := &memCheckpointStore{}
+	crash := errors.New("simulated crash")
+
+	var firstRun []int64
+	scanner := &Scanner{CheckpointInterval: 3}
+	err := scanner.Scan(ctx, NewQuery("Widget"), func(k *Key, pm PropertyMap) error {
+		firstRun = append(firstRun, k.IntID())
+		if len(firstRun) == 7 {
+			return crash
+		}
+		return nil
+	}, cp)
+	if !errors.Is(err, crash) {
+		t.Fatalf("first Scan error = %v, want the simulated crash", err)
+	}
+
+	// A fresh Scanner (as if the process restarted) resumes from cp.
+	var secondRun []int64
+	scanner2 := &Scanner{CheckpointInterval: 3}
+	if err := scanner2.Scan(ctx, NewQuery("Widget"), func(k *Key, pm PropertyMap) error {
+		secondRun = append(secondRun, k.IntID())
+		return nil
+	}, cp); err != nil {
+		t.Fatalf("second Scan: %v", err)
+	}
+
+	seen := map[int64]int{}
+	for _, id := range firstRun {
+		seen[id]++
+	}
+	for _, id := range secondRun {
+		seen[id]++
+	}
+	for id := int64(1); id <= 10; id++ {
+		if seen[id] == 0 {
+			t.Errorf("item %d was skipped entirely", id)
+		}
+		if seen[id] > 2 {
+			t.Errorf("item %d was processed %d times, want at most 2", id, seen[id])
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrConcurrentTransaction is returned by a transaction body (and by
+// backends internally) when a transaction could not be committed because
+// another transaction modified the same entity group concurrently. The
+// RunInTransaction retry loop treats this as retryable; any other error
+// aborts immediately.
+var ErrConcurrentTransaction = errors.New("datastore: concurrent transaction")
+
+// TransactionOptions configures a RunInTransaction call.
+type TransactionOptions struct {
+	// XG allows the transaction to span multiple entity groups.
+	XG bool
+
+	// Attempts caps the number of times a backend that retries internally
+	// (see each backend's RunInTransaction doc) will attempt the
+	// transaction body before giving up and returning the last error. A
+	// zero value means "use the backend's default".
+	Attempts int
+}
+
+// RunInTransaction runs f inside a transaction on the backend installed
+// in ctx, retrying on ErrConcurrentTransaction per the backend's policy.
+// f receives a context scoped to the transaction; InTransaction(ctx)
+// reports true for it and any context derived from it.
+func RunInTransaction(ctx context.Context, f func(context.Context) error, opts *TransactionOptions) error {
+	return Raw(ctx).RunInTransaction(ctx, f, opts)
+}
+
+type txnKeyType struct{}
+
+var txnKey txnKeyType
+
+// MarkInTransaction returns a context derived from ctx for which
+// InTransaction reports true. Backend implementations of RunInTransaction
+// call this to build the context passed to the transaction body.
+func MarkInTransaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txnKey, true)
+}
+
+// InTransaction reports whether ctx is (transitively) inside a
+// RunInTransaction call.
+func InTransaction(ctx context.Context) bool {
+	v, _ := ctx.Value(txnKey).(bool)
+	return v
+}
+
+type retryObserverKeyType struct{}
+
+var retryObserverKey retryObserverKeyType
+
+// TransactionRetryObserver is invoked, if installed via
+// WithTransactionRetryObserver, before each retry of a RunInTransaction
+// body: attempt is the 1-based number of the attempt that just failed,
+// and err is the error that caused the retry.
+type TransactionRetryObserver func(attempt int, err error)
+
+// WithTransactionRetryObserver installs obs into ctx so that any
+// RunInTransaction call using this ctx (or a descendant) invokes it
+// before each retry. Backends that don't implement retries internally
+// (e.g. because the underlying RPC already retries) will not fire it;
+// see each backend's RunInTransaction doc for details.
+func WithTransactionRetryObserver(ctx context.Context, obs TransactionRetryObserver) context.Context {
+	return context.WithValue(ctx, retryObserverKey, obs)
+}
+
+// GetTransactionRetryObserver returns the observer installed via
+// WithTransactionRetryObserver, or nil if none was installed.
+func GetTransactionRetryObserver(ctx context.Context) TransactionRetryObserver {
+	obs, _ := ctx.Value(retryObserverKey).(TransactionRetryObserver)
+	return obs
+}
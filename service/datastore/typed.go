@@ -0,0 +1,73 @@
+package datastore
+
+import "context"
+
+// GetT fetches the entity at key and loads it into a newly allocated
+// *T via LoadStruct, giving callers a compile-time typed result instead
+// of working with PropertyMap directly.
+func GetT[T any](ctx context.Context, key *Key) (*T, error) {
+	pm := PropertyMap{}
+	if err := Raw(ctx).GetMulti([]*Key{key}, []PropertyMap{pm}); err != nil {
+		return nil, err
+	}
+	var out T
+	if err := LoadStruct(&out, pm); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PutT saves obj under key via SaveStruct. Unlike the PropertyMap-based
+// PutMulti, T carries no way to express an incomplete (to-be-allocated)
+// key, so key must already be complete.
+func PutT[T any](ctx context.Context, key *Key, obj *T) error {
+	pm, err := SaveStruct(obj)
+	if err != nil {
+		return err
+	}
+	_, err = Raw(ctx).PutMulti([]*Key{key}, []PropertyMap{pm})
+	return err
+}
+
+// GetAllT runs q and loads each result into a *T via LoadStruct,
+// returning the typed results in the order Run produced them.
+func GetAllT[T any](ctx context.Context, q *Query) ([]*T, error) {
+	var out []*T
+	err := Raw(ctx).Run(q, func(k *Key, pm PropertyMap) error {
+		var v T
+		if err := LoadStruct(&v, pm); err != nil {
+			return err
+		}
+		out = append(out, &v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RunInto runs q, decoding every result into buf via LoadStruct and
+// calling fn once per result, reusing buf for every decode instead of
+// allocating a fresh *T the way GetAllT does. Use it for hot paths that
+// scan many entities and only need one at a time (e.g. to aggregate or
+// filter them), where GetAllT's per-result allocation and the slice
+// holding all of them would otherwise dominate GC pressure.
+//
+// Aliasing hazard: fn is handed the same *T on every call, overwritten
+// in place by the next result's LoadStruct immediately after fn
+// returns. fn must not retain buf, or any value or slice/map/pointer
+// field reachable from it, past its own return — doing so silently
+// aliases a later (or, once RunInto returns, final) result's data
+// instead of the one fn was actually called for. A caller that needs to
+// keep results must copy what it needs out of *buf before returning.
+func RunInto[T any](ctx context.Context, q *Query, buf *T, fn func(*T) error) error {
+	return Raw(ctx).Run(q, func(k *Key, pm PropertyMap) error {
+		var zero T
+		*buf = zero
+		if err := LoadStruct(buf, pm); err != nil {
+			return err
+		}
+		return fn(buf)
+	})
+}
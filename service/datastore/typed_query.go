@@ -0,0 +1,78 @@
+package datastore
+
+import "context"
+
+// CursorCB, passed to a TypedQuery.Run callback, returns a Cursor
+// marking the scan's position immediately after the result the callback
+// is currently handling, so long scans can persist resumable progress
+// inline. It's fingerprinted against its originating Query the same way
+// every Cursor in this package is — see Query.Start.
+type CursorCB func() (Cursor, error)
+
+// TypedQuery wraps a *Query with a fluent, chainable builder that
+// decodes results into *T via LoadStruct, instead of requiring the
+// caller to separately build a Query and walk PropertyMap results.
+type TypedQuery[T any] struct {
+	q *Query
+}
+
+// NewTypedQuery returns a TypedQuery over all entities of the given
+// kind.
+func NewTypedQuery[T any](kind string) *TypedQuery[T] {
+	return &TypedQuery[T]{q: NewQuery(kind)}
+}
+
+// Filter appends a comparison against property. See Query.Filter.
+func (tq *TypedQuery[T]) Filter(property string, op FilterOp, value interface{}) *TypedQuery[T] {
+	return &TypedQuery[T]{q: tq.q.Filter(property, op, value)}
+}
+
+// Order appends a sort term. See Query.Order.
+func (tq *TypedQuery[T]) Order(property string) *TypedQuery[T] {
+	return &TypedQuery[T]{q: tq.q.Order(property)}
+}
+
+// Limit caps the number of results. See Query.Limit.
+func (tq *TypedQuery[T]) Limit(n int32) *TypedQuery[T] {
+	return &TypedQuery[T]{q: tq.q.Limit(n)}
+}
+
+// Ancestor restricts to descendants of anc. See Query.Ancestor.
+func (tq *TypedQuery[T]) Ancestor(anc *Key) *TypedQuery[T] {
+	return &TypedQuery[T]{q: tq.q.Ancestor(anc)}
+}
+
+// Namespace restricts to the given namespace. See Query.Namespace.
+func (tq *TypedQuery[T]) Namespace(ns string) *TypedQuery[T] {
+	return &TypedQuery[T]{q: tq.q.Namespace(ns)}
+}
+
+// Query returns the underlying *Query this TypedQuery has built, for
+// callers that need to drop down to the untyped API (e.g. ValidateQuery).
+func (tq *TypedQuery[T]) Query() *Query { return tq.q }
+
+// GetAll runs the query and decodes every result into a *T, in the order
+// Run produces them.
+func (tq *TypedQuery[T]) GetAll(ctx context.Context) ([]*T, error) {
+	return GetAllT[T](ctx, tq.q)
+}
+
+// Run streams results one at a time, each decoded into a *T and paired
+// with a CursorCB giving the callback a resumable position immediately
+// after it.
+func (tq *TypedQuery[T]) Run(ctx context.Context, cb func(*T, CursorCB) error) error {
+	fp, err := queryFingerprint(tq.q)
+	if err != nil {
+		return err
+	}
+	n := 0
+	return Raw(ctx).Run(tq.q, func(k *Key, pm PropertyMap) error {
+		n++
+		seen := n
+		var v T
+		if err := LoadStruct(&v, pm); err != nil {
+			return err
+		}
+		return cb(&v, func() (Cursor, error) { return newCursor(fp, seen), nil })
+	})
+}
@@ -0,0 +1,80 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+)
+
+type typedQueryWidget struct {
+	Name  string
+	Price int64
+}
+
+type queryCapturingRaw struct {
+	dummyRaw
+	got  *Query
+	rows []PropertyMap
+}
+
+func (r *queryCapturingRaw) Run(q *Query, cb RunCB) error {
+	r.got = q
+	for i, pm := range r.rows {
+		if err := cb(NewKey("app", "", "Widget", "", int64(i+1), nil), pm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestTypedQueryAppliesFiltersAndOrdering(t *testing.T) {
+	raw := &queryCapturingRaw{rows: []PropertyMap{
+		{"Name": "gizmo", "Price": int64(5)},
+	}}
+	ctx := SetRaw(context.Background(), raw)
+
+	got, err := NewTypedQuery[typedQueryWidget]("Widget").
+		Filter("Price", GreaterThan, 1).
+		Order("Price").
+		Limit(10).
+		GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "gizmo" || got[0].Price != 5 {
+		t.Errorf("GetAll = %+v, want [{gizmo 5}]", got)
+	}
+
+	if len(raw.got.Filters()) != 1 || raw.got.Filters()[0].Property != "Price" || raw.got.Filters()[0].Op != GreaterThan {
+		t.Errorf("query filters = %+v, want a single Price>1 filter", raw.got.Filters())
+	}
+	if len(raw.got.Orders()) != 1 || raw.got.Orders()[0].Property != "Price" {
+		t.Errorf("query orders = %+v, want a single Price order", raw.got.Orders())
+	}
+	if limit, ok := raw.got.GetLimit(); !ok || limit != 10 {
+		t.Errorf("query limit = %v (%v), want 10", limit, ok)
+	}
+}
+
+func TestTypedQueryRunProvidesAdvancingCursor(t *testing.T) {
+	raw := &queryCapturingRaw{rows: []PropertyMap{
+		{"Name": "a", "Price": int64(1)},
+		{"Name": "b", "Price": int64(2)},
+	}}
+	ctx := SetRaw(context.Background(), raw)
+
+	var cursors []Cursor
+	err := NewTypedQuery[typedQueryWidget]("Widget").Run(ctx, func(w *typedQueryWidget, cursor CursorCB) error {
+		c, err := cursor()
+		if err != nil {
+			return err
+		}
+		cursors = append(cursors, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(cursors) != 2 || cursors[0] == cursors[1] {
+		t.Errorf("cursors = %v, want two distinct, advancing cursors", cursors)
+	}
+}
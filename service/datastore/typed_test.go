@@ -0,0 +1,90 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+)
+
+func widgetRows(n int) []PropertyMap {
+	rows := make([]PropertyMap, n)
+	for i := range rows {
+		rows[i] = PropertyMap{"Name": "widget", "Price": int64(i)}
+	}
+	return rows
+}
+
+func TestRunIntoReusesBufferAcrossResults(t *testing.T) {
+	raw := &queryCapturingRaw{rows: widgetRows(3)}
+	ctx := SetRaw(context.Background(), raw)
+
+	var buf typedQueryWidget
+	var seenPtrs []*typedQueryWidget
+	var prices []int64
+	err := RunInto(ctx, NewQuery("Widget"), &buf, func(w *typedQueryWidget) error {
+		seenPtrs = append(seenPtrs, w)
+		prices = append(prices, w.Price)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInto: %v", err)
+	}
+	if len(prices) != 3 || prices[0] != 0 || prices[1] != 1 || prices[2] != 2 {
+		t.Errorf("prices seen = %v, want [0 1 2]", prices)
+	}
+	for _, p := range seenPtrs {
+		if p != &buf {
+			t.Errorf("fn was called with %p, want the same &buf (%p) every time", p, &buf)
+		}
+	}
+}
+
+func TestRunIntoStopsOnCallbackError(t *testing.T) {
+	raw := &queryCapturingRaw{rows: widgetRows(3)}
+	ctx := SetRaw(context.Background(), raw)
+	boom := errString("boom")
+
+	var buf typedQueryWidget
+	calls := 0
+	err := RunInto(ctx, NewQuery("Widget"), &buf, func(w *typedQueryWidget) error {
+		calls++
+		return boom
+	})
+	if err != boom {
+		t.Fatalf("RunInto err = %v, want %v", err, boom)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1 (Run should stop on the first error)", calls)
+	}
+}
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
+
+func BenchmarkGetAllTAllocatesPerResult(b *testing.B) {
+	raw := &queryCapturingRaw{rows: widgetRows(1000)}
+	ctx := SetRaw(context.Background(), raw)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetAllT[typedQueryWidget](ctx, NewQuery("Widget")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRunIntoReusesBuffer(b *testing.B) {
+	raw := &queryCapturingRaw{rows: widgetRows(1000)}
+	ctx := SetRaw(context.Background(), raw)
+
+	b.ReportAllocs()
+	var buf typedQueryWidget
+	for i := 0; i < b.N; i++ {
+		err := RunInto(ctx, NewQuery("Widget"), &buf, func(w *typedQueryWidget) error {
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// ValidateQuery checks q against Cloud Datastore's query constraints
+// (currently only one: at most one property may carry an inequality
+// filter, matching Cloud Datastore's composite-index rules)
+// without running it, so a handler can reject an expensive or
+// unsupported query at request time instead of discovering the failure
+// mid-query. ctx is accepted for symmetry with the rest of this package
+// (and so a future backend-specific validator can consult the installed
+// RawInterface) but is not currently used.
+func ValidateQuery(ctx context.Context, q *Query) error {
+	inequalityProps := map[string]bool{}
+	for _, f := range q.Filters() {
+		if f.Op.IsInequality() {
+			inequalityProps[f.Property] = true
+		}
+	}
+	if len(inequalityProps) > 1 {
+		props := make([]string, 0, len(inequalityProps))
+		for p := range inequalityProps {
+			props = append(props, p)
+		}
+		sort.Strings(props)
+		return fmt.Errorf("datastore: query has inequality filters on multiple properties %v; Cloud Datastore allows at most one", props)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidateQueryAcceptsSingleInequality(t *testing.T) {
+	q := NewQuery("Widget").Filter("Price", GreaterThan, 10).Filter("Kind", Equal, "gadget")
+	if err := ValidateQuery(context.Background(), q); err != nil {
+		t.Errorf("ValidateQuery = %v, want nil", err)
+	}
+}
+
+func TestValidateQueryRejectsInequalityOnMultipleProperties(t *testing.T) {
+	q := NewQuery("Widget").Filter("Price", GreaterThan, 10).Filter("Weight", LessThan, 5)
+	if err := ValidateQuery(context.Background(), q); err == nil {
+		t.Fatal("ValidateQuery = nil, want an error for inequalities on two properties")
+	}
+}
@@ -0,0 +1,88 @@
+package datastore
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	keyType      = reflect.TypeOf((*Key)(nil))
+	timeType     = reflect.TypeOf(time.Time{})
+	byteSliceTyp = reflect.TypeOf([]byte(nil))
+)
+
+// ValidateStruct checks that obj's type can be round-tripped through
+// SaveStruct/LoadStruct without surprises, so that a mapping mistake
+// (an unparseable `gae:"default=..."`, two fields mapping to the same
+// property name, a field type this package can't usefully store) fails
+// loudly at init() instead of at the first Put or Get an instance of
+// obj's type happens to reach.
+//
+// obj must be a pointer to a struct, the same shape SaveStruct/
+// LoadStruct require.
+//
+// This package has no struct-field convention for an entity's key
+// (`$id`/`$kind` or otherwise) — GetOrCreate's doc comment already notes
+// that a key must always be supplied explicitly, since there is no way
+// to derive one from an arbitrary obj — so ValidateStruct does not check
+// for one; there is nothing in obj's shape for such a check to inspect.
+func ValidateStruct(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("datastore: ValidateStruct requires a pointer to a struct, got %T", obj)
+	}
+	t := v.Elem().Type()
+
+	seen := map[string]string{} // property name -> field name that claimed it
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		if !isSupportedFieldType(f.Type) {
+			return fmt.Errorf("datastore: %s.%s has unsupported type %s", t.Name(), f.Name, f.Type)
+		}
+
+		name := propertyName(f)
+		if other, dup := seen[name]; dup {
+			return fmt.Errorf("datastore: %s.%s and %s.%s both map to property %q", t.Name(), other, t.Name(), f.Name, name)
+		}
+		seen[name] = f.Name
+
+		if def, hasDef := defaultTagValue(f.Tag); hasDef {
+			if err := applyDefault(f.Name, reflect.New(f.Type).Elem(), def); err != nil {
+				return fmt.Errorf("datastore: %s.%s: %w", t.Name(), f.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// isSupportedFieldType reports whether t is a type this package's
+// PropertyMap/Property plumbing can actually hold: the scalar kinds
+// MkProperty wraps directly (bool, the integer and float kinds,
+// string), []byte and time.Time (both stored as a single scalar value
+// by encodePropertyMap's toRawValue, see filter/dscache), *Key, or a
+// slice of any of the above for a multi-valued property.
+func isSupportedFieldType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Ptr:
+		return t == keyType
+	case reflect.Struct:
+		return t == timeType
+	case reflect.Slice:
+		if t == byteSliceTyp {
+			return true
+		}
+		return isSupportedFieldType(t.Elem())
+	default:
+		return false
+	}
+}
@@ -0,0 +1,59 @@
+package datastore_test
+
+import (
+	"strings"
+	"testing"
+
+	ds "github.com/luci/gae/service/datastore"
+)
+
+func TestValidateStructAcceptsAWellFormedStruct(t *testing.T) {
+	type Widget struct {
+		Name    string
+		Count   int `gae:"default=42"`
+		Tags    []string
+		Owner   *ds.Key
+		Deleted bool `gae:"IsDeleted,default=false"`
+	}
+	if err := ds.ValidateStruct(&Widget{}); err != nil {
+		t.Fatalf("ValidateStruct: %v", err)
+	}
+}
+
+func TestValidateStructRejectsNonPointer(t *testing.T) {
+	type Widget struct{ Name string }
+	if err := ds.ValidateStruct(Widget{}); err == nil {
+		t.Fatalf("expected an error for a non-pointer argument")
+	}
+}
+
+func TestValidateStructRejectsUnsupportedFieldType(t *testing.T) {
+	type Widget struct {
+		Callback func()
+	}
+	err := ds.ValidateStruct(&Widget{})
+	if err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Fatalf("err = %v, want an unsupported-type error", err)
+	}
+}
+
+func TestValidateStructRejectsBadDefaultTag(t *testing.T) {
+	type Widget struct {
+		Count int `gae:"default=not-a-number"`
+	}
+	err := ds.ValidateStruct(&Widget{})
+	if err == nil {
+		t.Fatalf("expected an error for an unparseable default")
+	}
+}
+
+func TestValidateStructRejectsDuplicatePropertyNames(t *testing.T) {
+	type Widget struct {
+		Count    int
+		ItemsQty int `gae:"Count"`
+	}
+	err := ds.ValidateStruct(&Widget{})
+	if err == nil || !strings.Contains(err.Error(), `property "Count"`) {
+		t.Fatalf("err = %v, want a duplicate-property error", err)
+	}
+}
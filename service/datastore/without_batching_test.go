@@ -0,0 +1,60 @@
+package datastore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luci/gae/impl/memory"
+	ds "github.com/luci/gae/service/datastore"
+)
+
+type countingRaw struct {
+	ds.RawInterface
+	calls *int
+}
+
+func (c countingRaw) GetMulti(keys []*ds.Key, vals []ds.PropertyMap) error {
+	*c.calls++
+	return c.RawInterface.GetMulti(keys, vals)
+}
+
+func TestWithoutBatchingSendsAllKeysInOneCall(t *testing.T) {
+	calls := 0
+	counted := countingRaw{RawInterface: memory.NewDatastore("app"), calls: &calls}
+
+	ctx := ds.SetRaw(context.Background(), counted)
+	ctx = ds.WithoutBatching(ctx)
+
+	keys := manyKeys(1200) // would be 3 sub-batches of <=500 without WithoutBatching
+	vals := make([]ds.PropertyMap, len(keys))
+	for i := range vals {
+		vals[i] = ds.PropertyMap{}
+	}
+
+	if err := ds.Raw(ctx).GetMulti(keys, vals); err == nil {
+		t.Fatalf("GetMulti: got nil error, want ErrNoSuchEntity MultiError for unwritten keys")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (WithoutBatching should send every key in a single call)", calls)
+	}
+}
+
+func TestWithBatchingByDefaultSplitsLargeGetMulti(t *testing.T) {
+	calls := 0
+	counted := countingRaw{RawInterface: memory.NewDatastore("app"), calls: &calls}
+
+	ctx := ds.SetRaw(context.Background(), counted)
+
+	keys := manyKeys(1200)
+	vals := make([]ds.PropertyMap, len(keys))
+	for i := range vals {
+		vals[i] = ds.PropertyMap{}
+	}
+
+	if err := ds.Raw(ctx).GetMulti(keys, vals); err == nil {
+		t.Fatalf("GetMulti: got nil error, want ErrNoSuchEntity MultiError for unwritten keys")
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3 sub-batches for 1200 keys", calls)
+	}
+}
@@ -0,0 +1,29 @@
+package memcache
+
+// batchFilter wraps a RawInterface so that GetMulti/SetMulti/DeleteMulti
+// called with zero items return immediately without reaching the inner
+// RawInterface. It is applied automatically by Raw.
+type batchFilter struct {
+	RawInterface
+}
+
+func (b batchFilter) GetMulti(keys []string) (map[string]*Item, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return b.RawInterface.GetMulti(keys)
+}
+
+func (b batchFilter) SetMulti(items []*Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return b.RawInterface.SetMulti(items)
+}
+
+func (b batchFilter) DeleteMulti(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return b.RawInterface.DeleteMulti(keys)
+}
@@ -0,0 +1,61 @@
+package memcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"time"
+)
+
+// Codec marshals values to and from the byte slices RawInterface stores,
+// so callers working with structured values don't have to serialize them
+// by hand. See GobCodec and JSONCodec for ready-made instances.
+type Codec struct {
+	Marshal   func(v interface{}) ([]byte, error)
+	Unmarshal func(data []byte, v interface{}) error
+}
+
+// NewCodec returns a Codec using the given marshal/unmarshal functions.
+func NewCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) *Codec {
+	return &Codec{Marshal: marshal, Unmarshal: unmarshal}
+}
+
+// Get fetches key and unmarshals it into dst, returning ErrCacheMiss if
+// it is absent.
+func (c *Codec) Get(ctx context.Context, key string, dst interface{}) error {
+	item, err := Raw(ctx).Get(key)
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(item.Value(), dst)
+}
+
+// Set marshals value and stores it under key with the given expiration
+// (0 meaning no expiration).
+func (c *Codec) Set(ctx context.Context, key string, value interface{}, exp time.Duration) error {
+	data, err := c.Marshal(value)
+	if err != nil {
+		return err
+	}
+	item := NewItem(key).SetValue(data).SetExpiration(exp)
+	return Raw(ctx).SetMulti([]*Item{item})
+}
+
+func gobMarshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gobUnmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// GobCodec marshals values using encoding/gob.
+var GobCodec = NewCodec(gobMarshal, gobUnmarshal)
+
+// JSONCodec marshals values using encoding/json.
+var JSONCodec = NewCodec(json.Marshal, json.Unmarshal)
@@ -0,0 +1,59 @@
+package memcache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeItem struct {
+	Name  string
+	Count int
+}
+
+type fakeRawForCodec struct {
+	items map[string]*Item
+}
+
+func (f *fakeRawForCodec) Get(key string) (*Item, error) {
+	it, ok := f.items[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	return it, nil
+}
+func (f *fakeRawForCodec) GetMulti(keys []string) (map[string]*Item, error) { return nil, nil }
+func (f *fakeRawForCodec) SetMulti(items []*Item) error {
+	for _, it := range items {
+		f.items[it.Key()] = it
+	}
+	return nil
+}
+func (f *fakeRawForCodec) DeleteMulti(keys []string) error { return nil }
+func (f *fakeRawForCodec) Stats() (*Statistics, error)     { return &Statistics{}, nil }
+
+func TestGobCodecRoundTrip(t *testing.T) {
+	ctx := SetRaw(context.Background(), &fakeRawForCodec{items: map[string]*Item{}})
+
+	want := fakeItem{Name: "widget", Count: 3}
+	if err := GobCodec.Set(ctx, "key", want, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	var got fakeItem
+	if err := GobCodec.Get(ctx, "key", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestGobCodecGetMissPropagatesErrCacheMiss(t *testing.T) {
+	ctx := SetRaw(context.Background(), &fakeRawForCodec{items: map[string]*Item{}})
+
+	var got fakeItem
+	if err := GobCodec.Get(ctx, "missing", &got); !IsErrCacheMiss(err) {
+		t.Errorf("Get on missing key = %v, want ErrCacheMiss", err)
+	}
+}
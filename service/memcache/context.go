@@ -0,0 +1,39 @@
+package memcache
+
+import "context"
+
+type rawKeyType struct{}
+
+var rawKey rawKeyType
+
+// SetRaw installs raw as the memcache.RawInterface implementation for
+// ctx. Service backends call this from their Use() helpers.
+func SetRaw(ctx context.Context, raw RawInterface) context.Context {
+	return context.WithValue(ctx, rawKey, raw)
+}
+
+// Raw returns the RawInterface installed in ctx, with every filter
+// installed via AddRawFilters applied, and the zero-input batch guard
+// (see batch.go) applied outermost so that an empty
+// GetMulti/SetMulti/DeleteMulti never reaches the backend. It panics if
+// none has been installed.
+func Raw(ctx context.Context) RawInterface {
+	raw, ok := ctx.Value(rawKey).(RawInterface)
+	if !ok {
+		panic("memcache: no RawInterface installed in context; did you forget to call Use()?")
+	}
+	return batchFilter{ApplyRawFilters(ctx, raw)}
+}
+
+// dummyRaw is a RawInterface whose every method panics, installed in
+// place of a real backend when the service is deliberately disabled.
+type dummyRaw struct{ reason string }
+
+// Dummy returns a RawInterface that panics with reason on every call.
+func Dummy(reason string) RawInterface { return dummyRaw{reason: reason} }
+
+func (d dummyRaw) Get(key string) (*Item, error)                    { panic(d.reason) }
+func (d dummyRaw) GetMulti(keys []string) (map[string]*Item, error) { panic(d.reason) }
+func (d dummyRaw) SetMulti(items []*Item) error                     { panic(d.reason) }
+func (d dummyRaw) DeleteMulti(keys []string) error                  { panic(d.reason) }
+func (d dummyRaw) Stats() (*Statistics, error)                      { panic(d.reason) }
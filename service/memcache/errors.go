@@ -0,0 +1,30 @@
+package memcache
+
+import "errors"
+
+// ErrCacheMiss is returned by Get when an item is not present in the
+// cache.
+var ErrCacheMiss = errors.New("memcache: cache miss")
+
+// MultiError is returned by the *Multi family of calls, with one entry
+// per input item. A nil entry means that item succeeded.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	for _, e := range m {
+		if e != nil {
+			return e.Error()
+		}
+	}
+	return "(0 errors)"
+}
+
+// Any returns true if at least one entry of m is non-nil.
+func (m MultiError) Any() bool {
+	for _, e := range m {
+		if e != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package memcache
+
+import "context"
+
+// Filter is a function which wraps a RawInterface with additional
+// behavior (sharding, validation, caching, ...). Filters are applied in
+// the order they were added, with the first-added filter being the
+// innermost wrapper and the last-added one the outermost.
+type Filter func(context.Context, RawInterface) RawInterface
+
+type filtersKeyType struct{}
+
+var filtersKey filtersKeyType
+
+// AddRawFilters installs one or more RawInterface filters into ctx,
+// appending them after any already present. Filters added later wrap
+// filters added earlier.
+func AddRawFilters(ctx context.Context, filts ...Filter) context.Context {
+	if len(filts) == 0 {
+		return ctx
+	}
+	cur, _ := ctx.Value(filtersKey).([]Filter)
+	next := make([]Filter, 0, len(cur)+len(filts))
+	next = append(next, cur...)
+	next = append(next, filts...)
+	return context.WithValue(ctx, filtersKey, next)
+}
+
+// ApplyRawFilters wraps raw with every filter installed in ctx, in
+// registration order, and returns the resulting RawInterface.
+func ApplyRawFilters(ctx context.Context, raw RawInterface) RawInterface {
+	filts, _ := ctx.Value(filtersKey).([]Filter)
+	for _, f := range filts {
+		raw = f(ctx, raw)
+	}
+	return raw
+}
@@ -0,0 +1,113 @@
+package memcache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotStored is returned by Adder.Add when the item's key is already
+// present.
+var ErrNotStored = errors.New("memcache: item not stored (already exists)")
+
+// Adder is an optional RawInterface extension a backend can implement to
+// store an item only if its key is not already present, atomically. It
+// exists for GetOrCompute's dogpile lock: without it, acquiring the lock
+// would need a separate Get followed by a SetMulti, racing against any
+// other caller doing the same between those two calls.
+type Adder interface {
+	Add(item *Item) error
+}
+
+const (
+	lockSuffix       = ".lock"
+	lockTTL          = 5 * time.Second
+	lockPollInterval = 20 * time.Millisecond
+)
+
+// GetOrCompute returns the cached value for key, computing and caching
+// it via compute on a miss. To prevent many concurrent callers all
+// recomputing the same hot key at once (dogpiling), a caller that misses
+// first acquires a short-lived lock key via Add before calling compute;
+// a caller that finds the lock already held instead polls Get for up to
+// lockTTL, falling back to computing the value itself if the holder
+// hasn't published a result by the time the lock would expire.
+//
+// If the installed backend, with its filters applied, doesn't implement
+// Adder (only impl/memory does today),
+// GetOrCompute falls back to a plain Get-then-SetMulti lock acquisition,
+// which narrows but doesn't close the race an Adder-backed lock closes;
+// this only risks compute running more than once under contention, never
+// an incorrect cached value.
+func GetOrCompute(ctx context.Context, key string, ttl time.Duration, compute func() ([]byte, error)) ([]byte, error) {
+	if item, err := Raw(ctx).Get(key); err == nil {
+		return item.Value(), nil
+	} else if !IsErrCacheMiss(err) {
+		return nil, err
+	}
+
+	lockKey := key + lockSuffix
+	if acquired, err := acquireLock(ctx, lockKey); err != nil {
+		return nil, err
+	} else if !acquired {
+		if value, ok := awaitResult(ctx, key); ok {
+			return value, nil
+		}
+		// The holder didn't publish a result before our wait elapsed;
+		// fall through and compute it ourselves rather than waiting
+		// forever on a holder that may have crashed.
+	}
+
+	value, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	if err := Raw(ctx).SetMulti([]*Item{NewItem(key).SetValue(value).SetExpiration(ttl)}); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// acquireLock reports whether it acquired lockKey, using Adder when the
+// installed backend (with its filters applied) supports it and a racy
+// Get-then-SetMulti fallback otherwise.
+func acquireLock(ctx context.Context, lockKey string) (bool, error) {
+	lockItem := NewItem(lockKey).SetValue([]byte{1}).SetExpiration(lockTTL)
+
+	// Raw(ctx)'s zero-input batch guard has no Add method, so look for
+	// Adder beneath it.
+	raw, _ := ctx.Value(rawKey).(RawInterface)
+	if adder, ok := ApplyRawFilters(ctx, raw).(Adder); ok {
+		switch err := adder.Add(lockItem); {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, ErrNotStored):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	if _, err := Raw(ctx).Get(lockKey); err == nil {
+		return false, nil
+	} else if !IsErrCacheMiss(err) {
+		return false, err
+	}
+	if err := Raw(ctx).SetMulti([]*Item{lockItem}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// awaitResult polls Get(key) every lockPollInterval until it succeeds or
+// lockTTL has elapsed, reporting the found value, if any.
+func awaitResult(ctx context.Context, key string) ([]byte, bool) {
+	deadline := time.Now().Add(lockTTL)
+	for time.Now().Before(deadline) {
+		time.Sleep(lockPollInterval)
+		if item, err := Raw(ctx).Get(key); err == nil {
+			return item.Value(), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,70 @@
+package memcache_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luci/gae/impl/memory"
+	mc "github.com/luci/gae/service/memcache"
+)
+
+func TestGetOrComputeCachesOnMiss(t *testing.T) {
+	ctx := mc.SetRaw(context.Background(), memory.NewMemcache())
+
+	var calls int32
+	compute := func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		return []byte("computed"), nil
+	}
+
+	for i := 0; i < 3; i++ {
+		v, err := mc.GetOrCompute(ctx, "key", time.Minute, compute)
+		if err != nil {
+			t.Fatalf("GetOrCompute: %v", err)
+		}
+		if string(v) != "computed" {
+			t.Errorf("GetOrCompute = %q, want %q", v, "computed")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("compute ran %d times, want 1 (later calls should hit the cache)", calls)
+	}
+}
+
+func TestGetOrComputeRunsOnceUnderConcurrentMisses(t *testing.T) {
+	ctx := mc.SetRaw(context.Background(), memory.NewMemcache())
+
+	var calls int32
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([][]byte, n)
+	errs := make([]error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = mc.GetOrCompute(ctx, "hot-key", time.Minute, func() ([]byte, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(10 * time.Millisecond)
+				return []byte("computed"), nil
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("GetOrCompute[%d]: %v", i, err)
+		}
+		if string(results[i]) != "computed" {
+			t.Errorf("GetOrCompute[%d] = %q, want %q", i, results[i], "computed")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("compute ran %d times, want exactly 1 (impl/memory implements mc.Adder, so the lock is race-free)", calls)
+	}
+}
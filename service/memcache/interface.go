@@ -0,0 +1,60 @@
+// Package memcache exposes a filterable, mockable interface to the
+// underlying memcache service, mirroring the structure of
+// service/datastore.
+package memcache
+
+import "time"
+
+// Item is a single memcache entry: a key, its value, and an optional
+// expiration. Setters return the Item itself to allow chaining.
+type Item struct {
+	key        string
+	value      []byte
+	expiration time.Duration
+}
+
+// NewItem returns a new, empty Item for key.
+func NewItem(key string) *Item { return &Item{key: key} }
+
+// Key returns the item's key.
+func (i *Item) Key() string { return i.key }
+
+// Value returns the item's value.
+func (i *Item) Value() []byte { return i.value }
+
+// SetValue sets the item's value and returns the item.
+func (i *Item) SetValue(v []byte) *Item { i.value = v; return i }
+
+// Expiration returns the item's expiration, or 0 if it never expires.
+func (i *Item) Expiration() time.Duration { return i.expiration }
+
+// SetExpiration sets the item's expiration and returns the item.
+func (i *Item) SetExpiration(d time.Duration) *Item { i.expiration = d; return i }
+
+// Statistics reports aggregate usage counters for a memcache instance.
+type Statistics struct {
+	Hits   uint64
+	Misses uint64
+	Items  uint64
+	Bytes  uint64
+}
+
+// RawInterface is the low-level memcache service surface that backend
+// implementations (impl/memory, ...) implement, and that filters wrap.
+type RawInterface interface {
+	// Get retrieves a single key, returning ErrCacheMiss if it is absent.
+	Get(key string) (*Item, error)
+
+	// GetMulti retrieves keys, returning a map of only the keys that were
+	// found. Missing keys are simply absent from the result, not errors.
+	GetMulti(keys []string) (map[string]*Item, error)
+
+	// SetMulti stores items, returning a MultiError with one entry per item.
+	SetMulti(items []*Item) error
+
+	// DeleteMulti deletes keys, returning a MultiError with one entry per key.
+	DeleteMulti(keys []string) error
+
+	// Stats returns current usage counters for this memcache instance.
+	Stats() (*Statistics, error)
+}
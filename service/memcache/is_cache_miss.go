@@ -0,0 +1,25 @@
+package memcache
+
+import "errors"
+
+// IsErrCacheMiss normalizes cache-miss detection across backends: it
+// returns true if err is ErrCacheMiss, wraps ErrCacheMiss, or is a
+// MultiError containing at least one ErrCacheMiss entry (as returned
+// through a RawItemCB-style per-item callback).
+func IsErrCacheMiss(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrCacheMiss) {
+		return true
+	}
+	var me MultiError
+	if errors.As(err, &me) {
+		for _, e := range me {
+			if IsErrCacheMiss(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
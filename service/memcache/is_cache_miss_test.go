@@ -0,0 +1,28 @@
+package memcache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIsErrCacheMiss(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"direct", ErrCacheMiss, true},
+		{"wrapped", fmt.Errorf("while fetching session: %w", ErrCacheMiss), true},
+		{"multi-error with miss", MultiError{nil, ErrCacheMiss}, true},
+		{"multi-error without miss", MultiError{nil, fmt.Errorf("boom")}, false},
+		{"unrelated", fmt.Errorf("boom"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := IsErrCacheMiss(c.err); got != c.want {
+				t.Errorf("IsErrCacheMiss(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package taskqueue
+
+// batchFilter wraps a RawInterface so that AddMulti/DeleteMulti called
+// with zero tasks return immediately without reaching the inner
+// RawInterface. It is applied automatically by Raw.
+type batchFilter struct {
+	RawInterface
+}
+
+func (b batchFilter) AddMulti(tasks []*Task) ([]*Task, error) {
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	return b.RawInterface.AddMulti(tasks)
+}
+
+func (b batchFilter) DeleteMulti(tasks []*Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	return b.RawInterface.DeleteMulti(tasks)
+}
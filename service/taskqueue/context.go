@@ -0,0 +1,46 @@
+package taskqueue
+
+import (
+	"context"
+	"time"
+)
+
+type rawKeyType struct{}
+
+var rawKey rawKeyType
+
+// SetRaw installs raw as the taskqueue.RawInterface implementation for
+// ctx. Service backends call this from their Use() helpers.
+func SetRaw(ctx context.Context, raw RawInterface) context.Context {
+	return context.WithValue(ctx, rawKey, raw)
+}
+
+// Raw returns the RawInterface installed in ctx, with every filter
+// installed via AddRawFilters applied, and the zero-input batch guard
+// (see batch.go) applied outermost. It panics if none has been
+// installed.
+func Raw(ctx context.Context) RawInterface {
+	raw, ok := ctx.Value(rawKey).(RawInterface)
+	if !ok {
+		panic("taskqueue: no RawInterface installed in context; did you forget to call Use()?")
+	}
+	return batchFilter{ApplyRawFilters(ctx, raw)}
+}
+
+// dummyRaw is a RawInterface whose every method panics, installed in
+// place of a real backend when the service is deliberately disabled.
+type dummyRaw struct{ reason string }
+
+// Dummy returns a RawInterface that panics with reason on every call.
+func Dummy(reason string) RawInterface { return dummyRaw{reason: reason} }
+
+func (d dummyRaw) AddMulti(tasks []*Task) ([]*Task, error) { panic(d.reason) }
+func (d dummyRaw) DeleteMulti(tasks []*Task) error         { panic(d.reason) }
+func (d dummyRaw) Lease(queue string, maxTasks int, leaseTime time.Duration) ([]*Task, error) {
+	panic(d.reason)
+}
+func (d dummyRaw) LeaseByTag(queue, tag string, maxTasks int, leaseTime time.Duration) ([]*Task, error) {
+	panic(d.reason)
+}
+func (d dummyRaw) ModifyLease(task *Task, leaseTime time.Duration) error { panic(d.reason) }
+func (d dummyRaw) QueueStats(queueNames []string) ([]QueueStats, error)  { panic(d.reason) }
@@ -0,0 +1,37 @@
+package taskqueue
+
+import "errors"
+
+// ErrTaskAlreadyExists is returned (per-task, inside a MultiError) by
+// AddMulti when a task's Name collides with an existing, undeleted task
+// in the same queue.
+var ErrTaskAlreadyExists = errors.New("taskqueue: task already exists")
+
+// ErrUnknownTask is returned (per-task, inside a MultiError, or alone)
+// by DeleteMulti and ModifyLease when no matching, currently-relevant
+// task exists: not found, already deleted, or (for ModifyLease) not
+// currently leased.
+var ErrUnknownTask = errors.New("taskqueue: no such task")
+
+// MultiError is returned by the *Multi family of calls, with one entry
+// per input item. A nil entry means that item succeeded.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	for _, e := range m {
+		if e != nil {
+			return e.Error()
+		}
+	}
+	return "(0 errors)"
+}
+
+// Any returns true if at least one entry of m is non-nil.
+func (m MultiError) Any() bool {
+	for _, e := range m {
+		if e != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package taskqueue
+
+import "time"
+
+// RawInterface is the low-level task queue service surface that backend
+// implementations (impl/memory, ...) implement, and that filters wrap.
+type RawInterface interface {
+	// AddMulti enqueues tasks, returning the (possibly Name-assigned)
+	// tasks and a MultiError with one entry per item. A task whose Name
+	// collides with an existing, undeleted task in the same queue is
+	// reported as ErrTaskAlreadyExists instead of being added again.
+	AddMulti(tasks []*Task) ([]*Task, error)
+
+	// DeleteMulti deletes tasks by Queue+Name, returning a MultiError
+	// with one entry per task; a task not found (or already deleted) is
+	// reported as ErrUnknownTask.
+	DeleteMulti(tasks []*Task) error
+
+	// Lease claims up to maxTasks tasks from queue whose ETA has passed
+	// and which are not currently leased by anyone else, marking them
+	// leased for leaseTime from now, and returns them in the order they
+	// were added. It never returns more than maxTasks tasks, and returns
+	// an empty (nil) slice, not an error, if none are eligible.
+	Lease(queue string, maxTasks int, leaseTime time.Duration) ([]*Task, error)
+
+	// LeaseByTag is like Lease, but only considers tasks whose Tag equals
+	// tag.
+	LeaseByTag(queue, tag string, maxTasks int, leaseTime time.Duration) ([]*Task, error)
+
+	// ModifyLease extends (or, with leaseTime 0, immediately releases)
+	// the lease on task, identified by its Queue and Name. It reports
+	// ErrUnknownTask if task does not exist, is deleted, or is not
+	// currently leased.
+	ModifyLease(task *Task, leaseTime time.Duration) error
+
+	// QueueStats reports current statistics for each of queueNames, in
+	// the same order, so a caller can check several queues' depth in one
+	// call instead of one RPC per queue.
+	QueueStats(queueNames []string) ([]QueueStats, error)
+}
+
+// QueueStats summarizes one queue's current state.
+type QueueStats struct {
+	// Tasks is the number of tasks currently in the queue (added, not
+	// deleted), whether leased or not.
+	Tasks int
+	// OldestETA is the earliest ETA among Tasks, or the zero Time if
+	// Tasks is 0.
+	OldestETA time.Time
+	// Leased is how many of Tasks currently hold an unexpired lease.
+	Leased int
+}
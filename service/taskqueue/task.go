@@ -0,0 +1,30 @@
+// Package taskqueue exposes a filterable, mockable interface to the
+// underlying task queue service, mirroring the structure of
+// service/datastore and service/memcache.
+package taskqueue
+
+import "time"
+
+// Task is a single task queue task.
+type Task struct {
+	// Name identifies the task within its Queue. If empty, AddMulti
+	// assigns one. A non-empty Name that collides with an existing,
+	// undeleted task in the same queue is a no-op: AddMulti reports
+	// ErrTaskAlreadyExists for that item instead of creating a duplicate.
+	Name string
+
+	// Queue is the queue this task belongs to. Empty means the default
+	// queue.
+	Queue string
+
+	// Payload is the task's opaque body, delivered to whatever handles
+	// the queue.
+	Payload []byte
+
+	// Tag groups related tasks, e.g. for LeaseByTag.
+	Tag string
+
+	// ETA is the earliest time the task becomes eligible for delivery or
+	// lease. The zero value means "immediately".
+	ETA time.Time
+}